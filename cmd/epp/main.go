@@ -17,11 +17,14 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 
 	"github.com/go-logr/logr"
@@ -41,12 +44,16 @@ import (
 	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling"
+	schedulingconfig "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/filter"
 	runserver "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/server"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
 )
 
 const (
-	defaultMetricsEndpoint = "/metrics"
+	defaultMetricsEndpoint         = "/metrics"
+	defaultDecisionHistoryEndpoint = "/debug/decisions"
 )
 
 var (
@@ -85,6 +92,25 @@ var (
 		"refreshPrometheusMetricsInterval",
 		runserver.DefaultRefreshPrometheusMetricsInterval,
 		"interval to flush prometheus metrics")
+	modelRolloutWindow = flag.Duration(
+		"modelRolloutWindow",
+		0,
+		"Window over which traffic is gradually shifted to the new target models when an InferenceModel changes. 0 switches immediately.")
+	decisionHistorySize = flag.Int(
+		"decisionHistorySize",
+		0,
+		"Number of recent scheduling decisions, with the pod metrics they were made on, served on "+defaultDecisionHistoryEndpoint+
+			" of the metrics port for debugging. 0 disables the decision history.")
+	decisionCaptureFile = flag.String(
+		"decisionCaptureFile",
+		"",
+		"Path to a file the scheduling decisions are appended to, with the pod metrics they were made on and "+
+			"the prompts redacted, for replaying them in tests. Decisions aren't captured if empty.")
+	validateFilterConfig = flag.String(
+		"validateFilterConfig",
+		"",
+		"Path to a JSON filter chain config to validate. When set, the config is validated and the process exits "+
+			"instead of serving, with a non-zero status if the config is invalid.")
 	logVerbosity  = flag.Int("v", logging.DEFAULT, "number for the log level verbosity")
 	secureServing = flag.Bool(
 		"secureServing", runserver.DefaultSecureServing, "Enables secure serving. Defaults to true.")
@@ -96,6 +122,9 @@ var (
 	totalQueuedRequestsMetric = flag.String("totalQueuedRequestsMetric",
 		"vllm:num_requests_waiting",
 		"Prometheus metric for the number of queued requests.")
+	totalRunningRequestsMetric = flag.String("totalRunningRequestsMetric",
+		"vllm:num_requests_running",
+		"Prometheus metric for the number of running requests.")
 	kvCacheUsagePercentageMetric = flag.String("kvCacheUsagePercentageMetric",
 		"vllm:gpu_cache_usage_perc",
 		"Prometheus metric for the fraction of KV-cache blocks currently in use (from 0 to 1).")
@@ -103,6 +132,21 @@ var (
 	loraInfoMetric = flag.String("loraInfoMetric",
 		"vllm:lora_requests_info",
 		"Prometheus metric for the LoRA info metrics (must be in vLLM label format).")
+	generationTokensMetric = flag.String("generationTokensMetric",
+		"vllm:generation_tokens_total",
+		"Prometheus counter of the tokens generated by the model server, from which the throughput of the pods is derived. "+
+			"Not scraped if empty.")
+	loraSwapLatencyMetric = flag.String("loraSwapLatencyMetric",
+		"",
+		"Prometheus histogram of the time the model server takes to swap in a LoRA adapter, from which the LoRA affinity threshold is tuned. "+
+			"Not scraped if empty.")
+	// pod metadata flags
+	podLabelKeys = flag.String("podLabelKeys",
+		"",
+		"Comma separated keys of the pod labels made available to the scheduler.")
+	podAnnotationKeys = flag.String("podAnnotationKeys",
+		"",
+		"Comma separated keys of the pod annotations made available to the scheduler.")
 
 	setupLog = ctrl.Log.WithName("setup")
 )
@@ -121,6 +165,10 @@ func run() error {
 	flag.Parse()
 	initLogging(&opts)
 
+	if *validateFilterConfig != "" {
+		return validateFilterConfigFile(*validateFilterConfig)
+	}
+
 	// Validate flags
 	if err := validateFlags(); err != nil {
 		setupLog.Error(err, "Failed to validate flags")
@@ -154,8 +202,11 @@ func run() error {
 	// Set up mapper for metric scraping.
 	mapping, err := backendmetrics.NewMetricMapping(
 		*totalQueuedRequestsMetric,
+		*totalRunningRequestsMetric,
 		*kvCacheUsagePercentageMetric,
 		*loraInfoMetric,
+		*generationTokensMetric,
+		*loraSwapLatencyMetric,
 	)
 	if err != nil {
 		setupLog.Error(err, "Failed to create metric mapping from flags.")
@@ -163,12 +214,28 @@ func run() error {
 	}
 	verifyMetricMapping(*mapping, setupLog)
 
-	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.PodMetricsClientImpl{MetricMapping: mapping}, *refreshMetricsInterval)
+	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.PodMetricsClientImpl{MetricMapping: mapping}, *refreshMetricsInterval).
+		WithPodMetadataKeys(schedulingconfig.ParseList(*podLabelKeys), podAnnotationKeysWithMaintenance(schedulingconfig.ParseList(*podAnnotationKeys)))
 	// Setup runner.
 	ctx := ctrl.SetupSignalHandler()
 
 	datastore := datastore.NewDatastore(ctx, pmf)
 
+	var decisionHistory *scheduling.DecisionHistory
+	if *decisionHistorySize > 0 {
+		decisionHistory = scheduling.NewDecisionHistory(*decisionHistorySize)
+	}
+	var decisionCapture *scheduling.DecisionCapture
+	if *decisionCaptureFile != "" {
+		f, err := os.OpenFile(*decisionCaptureFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			setupLog.Error(err, "Failed to open the decision capture file", "path", *decisionCaptureFile)
+			return err
+		}
+		defer f.Close()
+		decisionCapture = scheduling.NewDecisionCapture(f)
+	}
+
 	serverRunner := &runserver.ExtProcServerRunner{
 		GrpcPort:                                 *grpcPort,
 		DestinationEndpointHintMetadataNamespace: *destinationEndpointHintMetadataNamespace,
@@ -178,6 +245,9 @@ func run() error {
 		SecureServing:                            *secureServing,
 		CertPath:                                 *certPath,
 		RefreshPrometheusMetricsInterval:         *refreshPrometheusMetricsInterval,
+		ModelRolloutWindow:                       *modelRolloutWindow,
+		DecisionHistory:                          decisionHistory,
+		DecisionCapture:                          decisionCapture,
 	}
 	if err := serverRunner.SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "Failed to setup ext-proc controllers")
@@ -196,7 +266,7 @@ func run() error {
 	}
 
 	// Register metrics handler.
-	if err := registerMetricsHandler(mgr, *metricsPort, cfg); err != nil {
+	if err := registerMetricsHandler(mgr, *metricsPort, cfg, decisionHistory); err != nil {
 		return err
 	}
 
@@ -243,8 +313,9 @@ func registerHealthServer(mgr manager.Manager, logger logr.Logger, ds datastore.
 	return nil
 }
 
-// registerMetricsHandler adds the metrics HTTP handler as a Runnable to the given manager.
-func registerMetricsHandler(mgr manager.Manager, port int, cfg *rest.Config) error {
+// registerMetricsHandler adds the metrics HTTP handler as a Runnable to the given manager. The
+// decision history, if set, is served on the same port.
+func registerMetricsHandler(mgr manager.Manager, port int, cfg *rest.Config, decisionHistory *scheduling.DecisionHistory) error {
 	metrics.Register()
 
 	// Init HTTP server.
@@ -255,6 +326,13 @@ func registerMetricsHandler(mgr manager.Manager, port int, cfg *rest.Config) err
 
 	mux := http.NewServeMux()
 	mux.Handle(defaultMetricsEndpoint, h)
+	if decisionHistory != nil {
+		historyHandler, err := handlerWithAuthenticationAndAuthorization(cfg, decisionHistory, defaultDecisionHistoryEndpoint)
+		if err != nil {
+			return err
+		}
+		mux.Handle(defaultDecisionHistoryEndpoint, historyHandler)
+	}
 
 	srv := &http.Server{
 		Addr:    net.JoinHostPort("", strconv.Itoa(port)),
@@ -276,6 +354,10 @@ func metricsHandlerWithAuthenticationAndAuthorization(cfg *rest.Config) (http.Ha
 		legacyregistry.DefaultGatherer,
 		promhttp.HandlerOpts{},
 	)
+	return handlerWithAuthenticationAndAuthorization(cfg, h, defaultMetricsEndpoint)
+}
+
+func handlerWithAuthenticationAndAuthorization(cfg *rest.Config, h http.Handler, path string) (http.Handler, error) {
 	httpClient, err := rest.HTTPClientFor(cfg)
 	if err != nil {
 		setupLog.Error(err, "Failed to create http client for metrics auth")
@@ -287,7 +369,7 @@ func metricsHandlerWithAuthenticationAndAuthorization(cfg *rest.Config) (http.Ha
 		setupLog.Error(err, "Failed to create metrics filter for auth")
 		return nil, err
 	}
-	metricsLogger := ctrl.Log.WithName("metrics").WithValues("path", defaultMetricsEndpoint)
+	metricsLogger := ctrl.Log.WithName("metrics").WithValues("path", path)
 	metricsAuthHandler, err := filter(metricsLogger, h)
 	if err != nil {
 		setupLog.Error(err, "Failed to create metrics auth handler")
@@ -304,15 +386,55 @@ func validateFlags() error {
 	return nil
 }
 
+// validateFilterConfigFile validates the filter chain config in the given file.
+func validateFilterConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		setupLog.Error(err, "Failed to read filter config", "path", path)
+		return err
+	}
+	var cfg filter.FilterChainConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		setupLog.Error(err, "Failed to parse filter config", "path", path)
+		return err
+	}
+	if err := filter.ValidateFilterConfig(cfg); err != nil {
+		setupLog.Error(err, "Invalid filter config", "path", path)
+		return err
+	}
+	setupLog.Info("Filter config is valid", "path", path)
+	return nil
+}
+
 func verifyMetricMapping(mapping backendmetrics.MetricMapping, logger logr.Logger) {
 	if mapping.TotalQueuedRequests == nil {
 		logger.Info("Not scraping metric: TotalQueuedRequests")
 	}
+	if mapping.TotalRunningRequests == nil {
+		logger.Info("Not scraping metric: TotalRunningRequests")
+	}
 	if mapping.KVCacheUtilization == nil {
 		logger.Info("Not scraping metric: KVCacheUtilization")
 	}
 	if mapping.LoraRequestInfo == nil {
 		logger.Info("Not scraping metric: LoraRequestInfo")
 	}
+	if mapping.GenerationTokens == nil {
+		logger.Info("Not scraping metric: GenerationTokens")
+	}
+	if mapping.LoraSwapLatency == nil {
+		logger.Info("Not scraping metric: LoraSwapLatency")
+	}
 
 }
+
+// podAnnotationKeysWithMaintenance adds the maintenance annotation of the scheduler, if any, to the
+// captured pod annotation keys, so the scheduler can exclude the pods in maintenance.
+func podAnnotationKeysWithMaintenance(keys []string) []string {
+	if annotation := schedulingconfig.Conf.MaintenanceAnnotation; annotation != "" && !slices.Contains(keys, annotation) {
+		keys = append(keys, annotation)
+	}
+	return keys
+}
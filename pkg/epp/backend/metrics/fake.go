@@ -44,7 +44,10 @@ func (fpm *FakePodMetrics) GetMetrics() *Metrics {
 	return fpm.Metrics
 }
 func (fpm *FakePodMetrics) UpdatePod(pod *corev1.Pod) {
-	fpm.Pod = toInternalPod(pod)
+	fpm.Pod = toInternalPod(pod, nil, nil)
+}
+func (fpm *FakePodMetrics) RefreshMetrics(ctx context.Context) error {
+	return nil // noop
 }
 func (fpm *FakePodMetrics) StopRefreshLoop() {} // noop
 
@@ -93,6 +93,15 @@ func (p *PodMetricsClientImpl) promToPodMetrics(
 		}
 	}
 
+	if p.MetricMapping.TotalRunningRequests != nil {
+		running, err := p.getMetric(metricFamilies, *p.MetricMapping.TotalRunningRequests)
+		if err == nil {
+			updated.RunningQueueSize = int(running.GetGauge().GetValue())
+		} else {
+			errs = multierr.Append(errs, err)
+		}
+	}
+
 	if p.MetricMapping.KVCacheUtilization != nil {
 		usage, err := p.getMetric(metricFamilies, *p.MetricMapping.KVCacheUtilization)
 		if err == nil {
@@ -102,6 +111,25 @@ func (p *PodMetricsClientImpl) promToPodMetrics(
 		}
 	}
 
+	if p.MetricMapping.GenerationTokens != nil {
+		tokens, err := p.getMetric(metricFamilies, *p.MetricMapping.GenerationTokens)
+		if err == nil {
+			updated.GenerationTokensTotal = tokens.GetCounter().GetValue()
+		} else {
+			errs = multierr.Append(errs, err)
+		}
+	}
+
+	if p.MetricMapping.LoraSwapLatency != nil {
+		swaps, err := p.getMetric(metricFamilies, *p.MetricMapping.LoraSwapLatency)
+		if err == nil {
+			updated.LoraSwapSecondsTotal = swaps.GetHistogram().GetSampleSum()
+			updated.LoraSwapCount = swaps.GetHistogram().GetSampleCount()
+		} else {
+			errs = multierr.Append(errs, err)
+		}
+	}
+
 	// Handle LoRA metrics (only if all LoRA MetricSpecs are present)
 	if p.MetricMapping.LoraRequestInfo != nil {
 		loraMetrics, err := p.getLatestLoraMetric(metricFamilies)
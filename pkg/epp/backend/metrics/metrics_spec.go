@@ -29,9 +29,14 @@ type MetricSpec struct {
 
 // MetricMapping holds named MetricSpecs.
 type MetricMapping struct {
-	TotalQueuedRequests *MetricSpec
-	KVCacheUtilization  *MetricSpec
-	LoraRequestInfo     *MetricSpec
+	TotalQueuedRequests  *MetricSpec
+	TotalRunningRequests *MetricSpec
+	KVCacheUtilization   *MetricSpec
+	LoraRequestInfo      *MetricSpec
+	// GenerationTokens is the counter of the tokens generated by the pod.
+	GenerationTokens *MetricSpec
+	// LoraSwapLatency is the histogram of the time the pod takes to swap in a LoRA adapter.
+	LoraSwapLatency *MetricSpec
 }
 
 // stringToMetricSpec converts a string to a MetricSpec.
@@ -93,11 +98,15 @@ func stringToMetricSpec(specStr string) (*MetricSpec, error) {
 }
 
 // NewMetricMapping creates a MetricMapping from string values.
-func NewMetricMapping(queuedStr, kvUsageStr, loraReqInfoStr string) (*MetricMapping, error) {
+func NewMetricMapping(queuedStr, runningStr, kvUsageStr, loraReqInfoStr, generationTokensStr, loraSwapLatencyStr string) (*MetricMapping, error) {
 	queuedSpec, err := stringToMetricSpec(queuedStr)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing WaitingRequests: %w", err)
 	}
+	runningSpec, err := stringToMetricSpec(runningStr)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing RunningRequests: %w", err)
+	}
 	kvUsageSpec, err := stringToMetricSpec(kvUsageStr)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing KVCacheUsage: %w", err)
@@ -106,10 +115,21 @@ func NewMetricMapping(queuedStr, kvUsageStr, loraReqInfoStr string) (*MetricMapp
 	if err != nil {
 		return nil, fmt.Errorf("error parsing loraReqInfoStr: %w", err)
 	}
+	generationTokensSpec, err := stringToMetricSpec(generationTokensStr)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing GenerationTokens: %w", err)
+	}
+	loraSwapLatencySpec, err := stringToMetricSpec(loraSwapLatencyStr)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing LoraSwapLatency: %w", err)
+	}
 	mapping := &MetricMapping{
-		TotalQueuedRequests: queuedSpec,
-		KVCacheUtilization:  kvUsageSpec,
-		LoraRequestInfo:     loraReqInfoSpec,
+		TotalQueuedRequests:  queuedSpec,
+		TotalRunningRequests: runningSpec,
+		KVCacheUtilization:   kvUsageSpec,
+		LoraRequestInfo:      loraReqInfoSpec,
+		GenerationTokens:     generationTokensSpec,
+		LoraSwapLatency:      loraSwapLatencySpec,
 	}
 
 	return mapping, nil
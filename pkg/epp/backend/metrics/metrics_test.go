@@ -387,6 +387,9 @@ func TestPromToPodMetrics(t *testing.T) {
 					makeMetric(nil, 5.0, 1000),
 					makeMetric(nil, 7.0, 2000), // Newer
 				),
+				"vllm_running": makeMetricFamily("vllm_running",
+					makeMetric(nil, 12.0, 2000),
+				),
 				"vllm_usage": makeMetricFamily("vllm_usage",
 					makeMetric(nil, 0.8, 2000),
 					makeMetric(nil, 0.7, 500),
@@ -396,13 +399,15 @@ func TestPromToPodMetrics(t *testing.T) {
 				),
 			},
 			mapping: &MetricMapping{
-				TotalQueuedRequests: &MetricSpec{MetricName: "vllm_waiting"},
-				KVCacheUtilization:  &MetricSpec{MetricName: "vllm_usage"},
-				LoraRequestInfo:     &MetricSpec{MetricName: "vllm:lora_requests_info"},
+				TotalQueuedRequests:  &MetricSpec{MetricName: "vllm_waiting"},
+				TotalRunningRequests: &MetricSpec{MetricName: "vllm_running"},
+				KVCacheUtilization:   &MetricSpec{MetricName: "vllm_usage"},
+				LoraRequestInfo:      &MetricSpec{MetricName: "vllm:lora_requests_info"},
 			},
 			existingMetrics: &Metrics{},
 			expectedMetrics: &Metrics{
 				WaitingQueueSize:    7,
+				RunningQueueSize:    12,
 				KVCacheUsagePercent: 0.8,
 				ActiveModels:        map[string]int{"lora1": 0, "lora2": 0},
 				WaitingModels:       map[string]int{"lora3": 0},
@@ -465,6 +470,48 @@ func TestPromToPodMetrics(t *testing.T) {
 			},
 			expectedErr: errors.New("strconv.Atoi: parsing \"invalid\": invalid syntax"),
 		},
+		{
+			name: "generation tokens counter",
+			metricFamilies: map[string]*dto.MetricFamily{
+				"vllm:generation_tokens_total": {
+					Name:   proto.String("vllm:generation_tokens_total"),
+					Type:   dto.MetricType_COUNTER.Enum(),
+					Metric: []*dto.Metric{{Counter: &dto.Counter{Value: proto.Float64(1234)}}},
+				},
+			},
+			mapping: &MetricMapping{
+				GenerationTokens: &MetricSpec{MetricName: "vllm:generation_tokens_total"},
+			},
+			existingMetrics: &Metrics{GenerationTokensTotal: 1000},
+			expectedMetrics: &Metrics{
+				ActiveModels:          map[string]int{},
+				WaitingModels:         map[string]int{},
+				GenerationTokensTotal: 1234,
+			},
+		},
+		{
+			name: "lora swap latency histogram",
+			metricFamilies: map[string]*dto.MetricFamily{
+				"lora_swap_latency_seconds": {
+					Name: proto.String("lora_swap_latency_seconds"),
+					Type: dto.MetricType_HISTOGRAM.Enum(),
+					Metric: []*dto.Metric{{Histogram: &dto.Histogram{
+						SampleSum:   proto.Float64(4.5),
+						SampleCount: proto.Uint64(3),
+					}}},
+				},
+			},
+			mapping: &MetricMapping{
+				LoraSwapLatency: &MetricSpec{MetricName: "lora_swap_latency_seconds"},
+			},
+			existingMetrics: &Metrics{},
+			expectedMetrics: &Metrics{
+				ActiveModels:         map[string]int{},
+				WaitingModels:        map[string]int{},
+				LoraSwapSecondsTotal: 4.5,
+				LoraSwapCount:        3,
+			},
+		},
 	}
 
 	for _, tc := range tests {
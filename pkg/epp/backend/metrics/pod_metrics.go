@@ -40,6 +40,9 @@ type podMetrics struct {
 	pmc      PodMetricsClient
 	ds       Datastore
 	interval time.Duration
+	// labelKeys and annotationKeys are the keys of the pod labels and annotations captured in Pod.
+	labelKeys      []string
+	annotationKeys []string
 
 	once sync.Once // ensure the StartRefreshLoop is only called once.
 	done chan struct{}
@@ -64,19 +67,38 @@ func (pm *podMetrics) GetMetrics() *Metrics {
 }
 
 func (pm *podMetrics) UpdatePod(in *corev1.Pod) {
-	pm.pod.Store(toInternalPod(in))
+	pm.pod.Store(toInternalPod(in, pm.labelKeys, pm.annotationKeys))
 }
 
-func toInternalPod(in *corev1.Pod) *Pod {
+func toInternalPod(in *corev1.Pod, labelKeys, annotationKeys []string) *Pod {
 	return &Pod{
 		NamespacedName: types.NamespacedName{
 			Name:      in.Name,
 			Namespace: in.Namespace,
 		},
-		Address: in.Status.PodIP,
+		Address:           in.Status.PodIP,
+		DeletionTimestamp: in.DeletionTimestamp.DeepCopy(),
+		NodeName:          in.Spec.NodeName,
+		StartTime:         in.Status.StartTime.DeepCopy(),
+		Labels:            selectKeys(in.Labels, labelKeys),
+		Annotations:       selectKeys(in.Annotations, annotationKeys),
 	}
 }
 
+// selectKeys returns the entries of the given map with the given keys, or nil if there are none.
+func selectKeys(in map[string]string, keys []string) map[string]string {
+	var res map[string]string
+	for _, key := range keys {
+		if val, ok := in[key]; ok {
+			if res == nil {
+				res = make(map[string]string, len(keys))
+			}
+			res[key] = val
+		}
+	}
+	return res
+}
+
 // start starts a goroutine exactly once to periodically update metrics. The goroutine will be
 // stopped either when stop() is called, or the given ctx is cancelled.
 func (pm *podMetrics) startRefreshLoop(ctx context.Context) {
@@ -92,7 +114,7 @@ func (pm *podMetrics) startRefreshLoop(ctx context.Context) {
 				case <-ctx.Done():
 					return
 				case <-ticker.C: // refresh metrics periodically
-					if err := pm.refreshMetrics(); err != nil {
+					if err := pm.RefreshMetrics(context.Background()); err != nil {
 						pm.logger.V(logutil.TRACE).Error(err, "Failed to refresh metrics", "pod", pm.GetPod())
 					}
 				}
@@ -101,18 +123,15 @@ func (pm *podMetrics) startRefreshLoop(ctx context.Context) {
 	})
 }
 
-func (pm *podMetrics) refreshMetrics() error {
+func (pm *podMetrics) RefreshMetrics(ctx context.Context) error {
 	pool, err := pm.ds.PoolGet()
 	if err != nil {
 		// No inference pool or not initialize.
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), fetchMetricsTimeout)
+	ctx, cancel := context.WithTimeout(ctx, fetchMetricsTimeout)
 	defer cancel()
 	updated, err := pm.pmc.FetchMetrics(ctx, pm.GetPod(), pm.GetMetrics(), pool.Spec.TargetPortNumber)
-	if err != nil {
-		pm.logger.V(logutil.TRACE).Info("Failed to refreshed metrics:", "err", err)
-	}
 	// Optimistically update metrics even if there was an error.
 	// The FetchMetrics can return an error for the following reasons:
 	// 1. As refresher is running in the background, it's possible that the pod is deleted but
@@ -125,9 +144,10 @@ func (pm *podMetrics) refreshMetrics() error {
 		updated.UpdateTime = time.Now()
 		pm.logger.V(logutil.TRACE).Info("Refreshed metrics", "updated", updated)
 		pm.metrics.Store(updated)
+		pm.ds.PodMetricsUpdated(pm.GetPod().NamespacedName, updated)
 	}
 
-	return nil
+	return err
 }
 
 func (pm *podMetrics) StopRefreshLoop() {
@@ -17,6 +17,7 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -83,6 +84,29 @@ func TestMetricsRefresh(t *testing.T) {
 	assert.EventuallyWithT(t, condition, time.Second, time.Millisecond)
 }
 
+func TestRefreshMetricsError(t *testing.T) {
+	ctx := context.Background()
+	namespacedName := types.NamespacedName{Name: pod1.Name, Namespace: pod1.Namespace}
+	pmc := &FakePodMetricsClient{Err: map[types.NamespacedName]error{namespacedName: errors.New("connection refused")}}
+	// Use a long refresh interval so that only the on demand refresh fetches the metrics.
+	pmf := NewPodMetricsFactory(pmc, time.Hour)
+	pm := pmf.NewPodMetrics(ctx, pod1, &fakeDataStore{})
+	defer pm.StopRefreshLoop()
+
+	if err := pm.RefreshMetrics(ctx); err == nil {
+		t.Errorf("Expected an error when fetching the metrics fails")
+	}
+
+	pmc.SetErr(nil)
+	pmc.SetRes(map[types.NamespacedName]*Metrics{namespacedName: initial})
+	if err := pm.RefreshMetrics(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(initial, pm.GetMetrics(), cmpopts.IgnoreFields(Metrics{}, "UpdateTime")); diff != "" {
+		t.Errorf("Unexpected metrics diff (+got/-want): %s", diff)
+	}
+}
+
 type fakeDataStore struct{}
 
 func (f *fakeDataStore) PoolGet() (*v1alpha2.InferencePool, error) {
@@ -96,3 +120,6 @@ func (f *fakeDataStore) PodList(func(PodMetrics) bool) []PodMetrics {
 	// Not implemented.
 	return nil
 }
+func (f *fakeDataStore) PodMetricsUpdated(types.NamespacedName, *Metrics) {
+	// Not implemented.
+}
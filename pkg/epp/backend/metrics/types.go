@@ -20,10 +20,12 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -38,17 +40,30 @@ func NewPodMetricsFactory(pmc PodMetricsClient, refreshMetricsInterval time.Dura
 type PodMetricsFactory struct {
 	pmc                    PodMetricsClient
 	refreshMetricsInterval time.Duration
+	// labelKeys and annotationKeys are the keys of the pod labels and annotations captured in Pod.
+	labelKeys      []string
+	annotationKeys []string
+}
+
+// WithPodMetadataKeys sets the keys of the pod labels and annotations captured in Pod, so they
+// can be read by the scheduler without fetching the pods.
+func (f *PodMetricsFactory) WithPodMetadataKeys(labelKeys, annotationKeys []string) *PodMetricsFactory {
+	f.labelKeys = labelKeys
+	f.annotationKeys = annotationKeys
+	return f
 }
 
 func (f *PodMetricsFactory) NewPodMetrics(parentCtx context.Context, in *corev1.Pod, ds Datastore) PodMetrics {
-	pod := toInternalPod(in)
+	pod := toInternalPod(in, f.labelKeys, f.annotationKeys)
 	pm := &podMetrics{
-		pmc:      f.pmc,
-		ds:       ds,
-		interval: f.refreshMetricsInterval,
-		once:     sync.Once{},
-		done:     make(chan struct{}),
-		logger:   log.FromContext(parentCtx).WithValues("pod", pod.NamespacedName),
+		pmc:            f.pmc,
+		ds:             ds,
+		interval:       f.refreshMetricsInterval,
+		labelKeys:      f.labelKeys,
+		annotationKeys: f.annotationKeys,
+		once:           sync.Once{},
+		done:           make(chan struct{}),
+		logger:         log.FromContext(parentCtx).WithValues("pod", pod.NamespacedName),
 	}
 	pm.pod.Store(pod)
 	pm.metrics.Store(newMetrics())
@@ -61,6 +76,9 @@ type PodMetrics interface {
 	GetPod() *Pod
 	GetMetrics() *Metrics
 	UpdatePod(*corev1.Pod)
+	// RefreshMetrics fetches the pod metrics on demand, outside of the periodic refresh loop. Any
+	// partial update is still stored when the fetch fails, and the error is returned.
+	RefreshMetrics(ctx context.Context) error
 	StopRefreshLoop()
 	String() string
 }
@@ -68,6 +86,16 @@ type PodMetrics interface {
 type Pod struct {
 	NamespacedName types.NamespacedName
 	Address        string
+	// DeletionTimestamp is set when the pod is terminating.
+	DeletionTimestamp *metav1.Time
+	// NodeName is the name of the node the pod is scheduled on.
+	NodeName string
+	// StartTime is the time the pod was started by the kubelet, unset until then.
+	StartTime *metav1.Time
+	// Labels and Annotations hold the pod labels and annotations with the keys configured in the
+	// PodMetricsFactory. Other keys are not captured.
+	Labels      map[string]string
+	Annotations map[string]string
 }
 
 func (p *Pod) String() string {
@@ -86,7 +114,12 @@ func (p *Pod) Clone() *Pod {
 			Name:      p.NamespacedName.Name,
 			Namespace: p.NamespacedName.Namespace,
 		},
-		Address: p.Address,
+		Address:           p.Address,
+		DeletionTimestamp: p.DeletionTimestamp.DeepCopy(),
+		NodeName:          p.NodeName,
+		StartTime:         p.StartTime.DeepCopy(),
+		Labels:            maps.Clone(p.Labels),
+		Annotations:       maps.Clone(p.Annotations),
 	}
 }
 
@@ -100,6 +133,12 @@ type Metrics struct {
 	WaitingQueueSize        int
 	KVCacheUsagePercent     float64
 	KvCacheMaxTokenCapacity int
+	// GenerationTokensTotal is the number of tokens generated by the pod since it started.
+	GenerationTokensTotal float64
+	// LoraSwapSecondsTotal and LoraSwapCount are the sum and the number of the adapter swap
+	// latencies observed by the pod since it started.
+	LoraSwapSecondsTotal float64
+	LoraSwapCount        uint64
 
 	// UpdateTime record the last time when the metrics were updated.
 	UpdateTime time.Time
@@ -139,6 +178,9 @@ func (m *Metrics) Clone() *Metrics {
 		WaitingQueueSize:        m.WaitingQueueSize,
 		KVCacheUsagePercent:     m.KVCacheUsagePercent,
 		KvCacheMaxTokenCapacity: m.KvCacheMaxTokenCapacity,
+		GenerationTokensTotal:   m.GenerationTokensTotal,
+		LoraSwapSecondsTotal:    m.LoraSwapSecondsTotal,
+		LoraSwapCount:           m.LoraSwapCount,
 		UpdateTime:              m.UpdateTime,
 	}
 	return clone
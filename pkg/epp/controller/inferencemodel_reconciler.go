@@ -56,8 +56,9 @@ func (c *InferenceModelReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		notFound = true
 	}
 
-	if notFound || !infModel.DeletionTimestamp.IsZero() || infModel.Spec.PoolRef.Name != v1alpha2.ObjectName(c.PoolNamespacedName.Name) {
-		// InferenceModel object got deleted or changed the referenced pool.
+	if notFound || !infModel.DeletionTimestamp.IsZero() || !c.eventPredicate(infModel) {
+		// InferenceModel object got deleted or changed the referenced pool. Models in other namespaces
+		// can't reference the pool, even if the pool names are the same.
 		err := c.handleModelDeleted(ctx, req.NamespacedName)
 		return ctrl.Result{}, err
 	}
@@ -126,5 +127,5 @@ func (c *InferenceModelReconciler) SetupWithManager(ctx context.Context, mgr ctr
 }
 
 func (c *InferenceModelReconciler) eventPredicate(infModel *v1alpha2.InferenceModel) bool {
-	return string(infModel.Spec.PoolRef.Name) == c.PoolNamespacedName.Name
+	return string(infModel.Spec.PoolRef.Name) == c.PoolNamespacedName.Name && infModel.Namespace == c.PoolNamespacedName.Namespace
 }
@@ -56,6 +56,12 @@ var (
 			Criticality(*infModel1.Spec.Criticality).
 			CreationTimestamp(metav1.Unix(1002, 0)).
 			PoolName(pool.Name).ObjRef()
+	infModel1NS2Older = utiltest.MakeInferenceModel(infModel1.Name).
+				Namespace("ns2").
+				ModelName(infModel1.Spec.ModelName).
+				Criticality(*infModel1.Spec.Criticality).
+				CreationTimestamp(metav1.Unix(999, 0)).
+				PoolName(pool.Name).ObjRef()
 	infModel1Critical = utiltest.MakeInferenceModel(infModel1.Name).
 				Namespace(infModel1.Namespace).
 				ModelName(infModel1.Spec.ModelName).
@@ -129,6 +135,12 @@ func TestInferenceModelReconciler(t *testing.T) {
 			model:         infModel1NS2,
 			wantModels:    []*v1alpha2.InferenceModel{infModel1},
 		},
+		{
+			name:          "Older model referencing a pool with the same name in another namespace",
+			modelsInStore: []*v1alpha2.InferenceModel{infModel1},
+			model:         infModel1NS2Older,
+			wantModels:    []*v1alpha2.InferenceModel{infModel1},
+		},
 		{
 			name:              "Existing model changed pools, replaced with another",
 			modelsInStore:     []*v1alpha2.InferenceModel{infModel1},
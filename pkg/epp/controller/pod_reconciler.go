@@ -54,6 +54,7 @@ func (c *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	if err := c.Get(ctx, req.NamespacedName, pod); err != nil {
 		if apierrors.IsNotFound(err) {
 			c.Datastore.PodDelete(req.NamespacedName)
+			c.Datastore.PodSetPending(req.NamespacedName, false)
 			return ctrl.Result{}, nil
 		}
 		logger.V(logutil.DEFAULT).Error(err, "Unable to get pod", "name", req.NamespacedName)
@@ -68,20 +69,20 @@ func (c *PodReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	filter := predicate.Funcs{
 		CreateFunc: func(ce event.CreateEvent) bool {
 			pod := ce.Object.(*corev1.Pod)
-			return c.Datastore.PoolLabelsMatch(pod.GetLabels())
+			return c.podInPool(pod)
 		},
 		UpdateFunc: func(ue event.UpdateEvent) bool {
 			oldPod := ue.ObjectOld.(*corev1.Pod)
 			newPod := ue.ObjectNew.(*corev1.Pod)
-			return c.Datastore.PoolLabelsMatch(oldPod.GetLabels()) || c.Datastore.PoolLabelsMatch(newPod.GetLabels())
+			return c.podInPool(oldPod) || c.podInPool(newPod)
 		},
 		DeleteFunc: func(de event.DeleteEvent) bool {
 			pod := de.Object.(*corev1.Pod)
-			return c.Datastore.PoolLabelsMatch(pod.GetLabels())
+			return c.podInPool(pod)
 		},
 		GenericFunc: func(ge event.GenericEvent) bool {
 			pod := ge.Object.(*corev1.Pod)
-			return c.Datastore.PoolLabelsMatch(pod.GetLabels())
+			return c.podInPool(pod)
 		},
 	}
 	return ctrl.NewControllerManagedBy(mgr).
@@ -92,7 +93,9 @@ func (c *PodReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 func (c *PodReconciler) updateDatastore(logger logr.Logger, pod *corev1.Pod) {
 	namespacedName := types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}
-	if !podutil.IsPodReady(pod) || !c.Datastore.PoolLabelsMatch(pod.Labels) {
+	// Pods of the pool that are starting indicate that the pool is scaling up.
+	c.Datastore.PodSetPending(namespacedName, !podutil.IsPodReady(pod) && pod.DeletionTimestamp == nil && c.podInPool(pod))
+	if !podutil.IsPodReady(pod) || !c.podInPool(pod) {
 		logger.V(logutil.DEBUG).Info("Pod removed or not added", "name", namespacedName)
 		c.Datastore.PodDelete(namespacedName)
 	} else {
@@ -103,3 +106,13 @@ func (c *PodReconciler) updateDatastore(logger logr.Logger, pod *corev1.Pod) {
 		}
 	}
 }
+
+// podInPool returns whether the pod is selected by the pool. A pool only selects pods in its own
+// namespace, so pods in other namespaces never match even if their labels do.
+func (c *PodReconciler) podInPool(pod *corev1.Pod) bool {
+	pool, err := c.Datastore.PoolGet()
+	if err != nil || pod.Namespace != pool.Namespace {
+		return false
+	}
+	return c.Datastore.PoolLabelsMatch(pod.GetLabels())
+}
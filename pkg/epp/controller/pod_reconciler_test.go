@@ -165,6 +165,23 @@ func TestPodReconciler(t *testing.T) {
 				ReadyCondition().ObjRef(),
 			wantPods: []*corev1.Pod{basePod2},
 		},
+		{
+			name:         "Pod in another namespace matching the selector",
+			existingPods: []*corev1.Pod{basePod1, basePod2},
+			pool: &v1alpha2.InferencePool{
+				Spec: v1alpha2.InferencePoolSpec{
+					TargetPortNumber: int32(8000),
+					Selector: map[v1alpha2.LabelKey]v1alpha2.LabelValue{
+						"some-key": "some-val",
+					},
+				},
+			},
+			incomingPod: utiltest.FromBase(basePod3).
+				Namespace("other-namespace").
+				Labels(map[string]string{"some-key": "some-val"}).
+				ReadyCondition().ObjRef(),
+			wantPods: []*corev1.Pod{basePod1, basePod2},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -21,11 +21,14 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
@@ -36,6 +39,18 @@ import (
 
 const (
 	ModelNameIndexKey = "spec.modelName"
+	// DefaultCriticalityAnnotation is the InferencePool annotation holding the criticality of the
+	// requests for models that don't specify one.
+	DefaultCriticalityAnnotation = "inference.networking.x-k8s.io/default-criticality"
+	// ExtensionTimeoutAnnotation is the InferencePool annotation holding the time the gateway waits
+	// for the extension, as a duration such as "500ms". Scheduling doesn't take longer.
+	ExtensionTimeoutAnnotation = "inference.networking.x-k8s.io/extension-timeout"
+	// ModelAliasesAnnotation is the InferenceModel annotation holding a comma separated list of
+	// aliases clients can request the model by.
+	ModelAliasesAnnotation = "inference.networking.x-k8s.io/model-aliases"
+	// CostMultiplierAnnotation is the InferenceModel annotation holding the multiplier applied to
+	// the cost of the requests of the model, e.g. "2.5" for a model that is more expensive to serve.
+	CostMultiplierAnnotation = "inference.networking.x-k8s.io/cost-multiplier"
 )
 
 var (
@@ -52,10 +67,23 @@ type Datastore interface {
 	PoolGet() (*v1alpha2.InferencePool, error)
 	PoolHasSynced() bool
 	PoolLabelsMatch(podLabels map[string]string) bool
+	// PoolDefaultCriticality returns the default criticality set on the pool, or nil if none is
+	// set or the value is invalid.
+	PoolDefaultCriticality() *v1alpha2.Criticality
+	// PoolExtensionTimeout returns the extension timeout set on the pool, or 0 if none is set or
+	// the value is invalid.
+	PoolExtensionTimeout() time.Duration
 
 	// InferenceModel operations
 	ModelSetIfOlder(infModel *v1alpha2.InferenceModel) bool
 	ModelGet(modelName string) *v1alpha2.InferenceModel
+	// ResolveModelName returns the ModelName of the InferenceModel the requested name is an alias
+	// of, or the requested name itself if it's not an alias. An alias claimed by several
+	// InferenceModels resolves to the oldest one.
+	ResolveModelName(requested string) string
+	// ModelGetPrevious returns the InferenceModel that served the given model name before its
+	// target models last changed, and the time of the change, or nil if they never changed.
+	ModelGetPrevious(modelName string) (*v1alpha2.InferenceModel, time.Time)
 	ModelDelete(namespacedName types.NamespacedName) *v1alpha2.InferenceModel
 	ModelResync(ctx context.Context, ctrlClient client.Client, modelName string) (bool, error)
 	ModelGetAll() []*v1alpha2.InferenceModel
@@ -67,18 +95,47 @@ type Datastore interface {
 	PodList(predicate func(backendmetrics.PodMetrics) bool) []backendmetrics.PodMetrics
 	PodUpdateOrAddIfNotExist(pod *corev1.Pod) bool
 	PodDelete(namespacedName types.NamespacedName)
+	// PodSetPending records whether the given pod is selected by the pool but is not ready yet.
+	PodSetPending(namespacedName types.NamespacedName, pending bool)
+	// PoolIsScalingUp returns true if any pod selected by the pool is starting and not ready yet.
+	PoolIsScalingUp() bool
+	// RefreshPodMetrics fetches the metrics of the given pod on demand, without waiting for the
+	// periodic refresh.
+	RefreshPodMetrics(ctx context.Context, namespacedName types.NamespacedName) error
+	// MarkPodUnhealthy excludes the given pod from scheduling until the given time, or until the
+	// next successful resync of the pods, whichever comes first.
+	MarkPodUnhealthy(namespacedName types.NamespacedName, until time.Time)
+	// PodsWithAdapter returns the pods that reported the given model or LoRA adapter as active in
+	// their latest metrics.
+	PodsWithAdapter(name string) []backendmetrics.PodMetrics
+	// PodMetricsUpdated is called after the metrics of the given pod are refreshed.
+	PodMetricsUpdated(namespacedName types.NamespacedName, metrics *backendmetrics.Metrics)
+	// PodIsUnhealthy returns true if the given pod is currently marked unhealthy.
+	PodIsUnhealthy(namespacedName types.NamespacedName) bool
 
 	// Clears the store state, happens when the pool gets deleted.
 	Clear()
 }
 
 func NewDatastore(parentCtx context.Context, pmf *backendmetrics.PodMetricsFactory) Datastore {
+	return NewDatastoreWithClock(parentCtx, pmf, clock.RealClock{})
+}
+
+// NewDatastoreWithClock creates a datastore whose time based logic, such as the expiry of the
+// unhealthy pod marks, follows the given clock. It lets tests advance time deterministically.
+func NewDatastoreWithClock(parentCtx context.Context, pmf *backendmetrics.PodMetricsFactory, clock clock.Clock) Datastore {
 	store := &datastore{
 		parentCtx:       parentCtx,
+		clock:           clock,
 		poolAndModelsMu: sync.RWMutex{},
 		models:          make(map[string]*v1alpha2.InferenceModel),
+		previousModels:  make(map[string]modelChange),
 		pods:            &sync.Map{},
+		pendingPods:     &sync.Map{},
 		pmf:             pmf,
+		unhealthyPods:   make(map[types.NamespacedName]time.Time),
+		podAdapters:     make(map[types.NamespacedName]map[string]struct{}),
+		adapterPods:     make(map[string]map[types.NamespacedName]struct{}),
 	}
 	return store
 }
@@ -86,14 +143,35 @@ func NewDatastore(parentCtx context.Context, pmf *backendmetrics.PodMetricsFacto
 type datastore struct {
 	// parentCtx controls the lifecycle of the background metrics goroutines that spawn up by the datastore.
 	parentCtx context.Context
+	clock     clock.Clock
 	// poolAndModelsMu is used to synchronize access to pool and the models map.
 	poolAndModelsMu sync.RWMutex
 	pool            *v1alpha2.InferencePool
 	// key: InferenceModel.Spec.ModelName, value: *InferenceModel
 	models map[string]*v1alpha2.InferenceModel
+	// key: InferenceModel.Spec.ModelName, value: the model replaced by the last target models change
+	previousModels map[string]modelChange
 	// key: types.NamespacedName, value: backendmetrics.PodMetrics
 	pods *sync.Map
 	pmf  *backendmetrics.PodMetricsFactory
+	// key: types.NamespacedName, value: struct{} for the pods of the pool that are not ready yet
+	pendingPods *sync.Map
+	// unhealthyPodsMu is used to synchronize access to the unhealthyPods map.
+	unhealthyPodsMu sync.RWMutex
+	// key: types.NamespacedName, value: the time until which the pod is excluded from scheduling
+	unhealthyPods map[types.NamespacedName]time.Time
+	// adaptersMu is used to synchronize access to the podAdapters and adapterPods indexes.
+	adaptersMu sync.RWMutex
+	// key: types.NamespacedName, value: the set of adapters active on the pod
+	podAdapters map[types.NamespacedName]map[string]struct{}
+	// key: adapter name, value: the set of pods the adapter is active on
+	adapterPods map[string]map[types.NamespacedName]struct{}
+}
+
+// modelChange records an InferenceModel replaced by one with different target models.
+type modelChange struct {
+	previous *v1alpha2.InferenceModel
+	time     time.Time
 }
 
 func (ds *datastore) Clear() {
@@ -101,7 +179,14 @@ func (ds *datastore) Clear() {
 	defer ds.poolAndModelsMu.Unlock()
 	ds.pool = nil
 	ds.models = make(map[string]*v1alpha2.InferenceModel)
+	ds.previousModels = make(map[string]modelChange)
 	ds.pods.Clear()
+	ds.pendingPods.Clear()
+	ds.clearUnhealthyPods()
+	ds.adaptersMu.Lock()
+	defer ds.adaptersMu.Unlock()
+	ds.podAdapters = make(map[types.NamespacedName]map[string]struct{})
+	ds.adapterPods = make(map[string]map[types.NamespacedName]struct{})
 }
 
 // /// InferencePool APIs ///
@@ -158,6 +243,38 @@ func (ds *datastore) PoolLabelsMatch(podLabels map[string]string) bool {
 	return poolSelector.Matches(podSet)
 }
 
+func (ds *datastore) PoolDefaultCriticality() *v1alpha2.Criticality {
+	ds.poolAndModelsMu.RLock()
+	defer ds.poolAndModelsMu.RUnlock()
+	if ds.pool == nil {
+		return nil
+	}
+	criticality := v1alpha2.Criticality(ds.pool.Annotations[DefaultCriticalityAnnotation])
+	switch criticality {
+	case v1alpha2.Critical, v1alpha2.Standard, v1alpha2.Sheddable:
+		return &criticality
+	default:
+		return nil
+	}
+}
+
+func (ds *datastore) PoolExtensionTimeout() time.Duration {
+	ds.poolAndModelsMu.RLock()
+	defer ds.poolAndModelsMu.RUnlock()
+	if ds.pool == nil {
+		return 0
+	}
+	val, ok := ds.pool.Annotations[ExtensionTimeoutAnnotation]
+	if !ok {
+		return 0
+	}
+	timeout, err := time.ParseDuration(val)
+	if err != nil || timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
 func (ds *datastore) ModelSetIfOlder(infModel *v1alpha2.InferenceModel) bool {
 	ds.poolAndModelsMu.Lock()
 	defer ds.poolAndModelsMu.Unlock()
@@ -171,9 +288,22 @@ func (ds *datastore) ModelSetIfOlder(infModel *v1alpha2.InferenceModel) bool {
 		if diffObj && existing.ObjectMeta.CreationTimestamp.Before(&infModel.ObjectMeta.CreationTimestamp) {
 			return false
 		}
+		if !reflect.DeepEqual(existing.Spec.TargetModels, infModel.Spec.TargetModels) {
+			ds.previousModels[infModel.Spec.ModelName] = modelChange{previous: existing, time: ds.clock.Now()}
+		}
 	}
 	// Set the model.
 	ds.models[infModel.Spec.ModelName] = infModel
+	for _, alias := range modelAliases(infModel) {
+		if claimants := ds.aliasClaimants(alias); len(claimants) > 1 {
+			names := make([]string, 0, len(claimants))
+			for _, m := range claimants {
+				names = append(names, m.Spec.ModelName)
+			}
+			log.FromContext(ds.parentCtx).V(logutil.DEFAULT).Info("Model alias is claimed by several InferenceModels, resolving it to the oldest one",
+				"alias", alias, "models", names, "resolved", oldestModel(claimants).Spec.ModelName)
+		}
+	}
 	return true
 }
 
@@ -215,12 +345,78 @@ func (ds *datastore) ModelGet(modelName string) *v1alpha2.InferenceModel {
 	return ds.models[modelName]
 }
 
+func (ds *datastore) ModelGetPrevious(modelName string) (*v1alpha2.InferenceModel, time.Time) {
+	ds.poolAndModelsMu.RLock()
+	defer ds.poolAndModelsMu.RUnlock()
+	change := ds.previousModels[modelName]
+	return change.previous, change.time
+}
+
+func (ds *datastore) ResolveModelName(requested string) string {
+	ds.poolAndModelsMu.RLock()
+	defer ds.poolAndModelsMu.RUnlock()
+	// A model name always takes precedence over an alias.
+	if _, ok := ds.models[requested]; ok {
+		return requested
+	}
+	if claimants := ds.aliasClaimants(requested); len(claimants) > 0 {
+		return oldestModel(claimants).Spec.ModelName
+	}
+	return requested
+}
+
+// aliasClaimants returns the models listing the given alias. It must be called with the models
+// lock held.
+func (ds *datastore) aliasClaimants(alias string) []*v1alpha2.InferenceModel {
+	var claimants []*v1alpha2.InferenceModel
+	if alias == "" {
+		return claimants
+	}
+	for _, m := range ds.models {
+		for _, a := range modelAliases(m) {
+			if a == alias {
+				claimants = append(claimants, m)
+				break
+			}
+		}
+	}
+	return claimants
+}
+
+// modelAliases returns the aliases of the model listed in its ModelAliasesAnnotation.
+func modelAliases(m *v1alpha2.InferenceModel) []string {
+	var aliases []string
+	for _, alias := range strings.Split(m.Annotations[ModelAliasesAnnotation], ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+// oldestModel returns the model created first, like the model reconciler keeps the oldest
+// InferenceModel of a model name. Ties are broken by model name, so that the same model is
+// returned whatever the order of the models.
+func oldestModel(models []*v1alpha2.InferenceModel) *v1alpha2.InferenceModel {
+	var oldest *v1alpha2.InferenceModel
+	for _, m := range models {
+		switch {
+		case oldest == nil, m.CreationTimestamp.Before(&oldest.CreationTimestamp):
+			oldest = m
+		case m.CreationTimestamp.Equal(&oldest.CreationTimestamp) && m.Spec.ModelName < oldest.Spec.ModelName:
+			oldest = m
+		}
+	}
+	return oldest
+}
+
 func (ds *datastore) ModelDelete(namespacedName types.NamespacedName) *v1alpha2.InferenceModel {
 	ds.poolAndModelsMu.Lock()
 	defer ds.poolAndModelsMu.Unlock()
 	for _, m := range ds.models {
 		if m.Name == namespacedName.Name && m.Namespace == namespacedName.Namespace {
 			delete(ds.models, m.Spec.ModelName)
+			delete(ds.previousModels, m.Spec.ModelName)
 			return m
 		}
 	}
@@ -246,8 +442,8 @@ func (ds *datastore) PodGetAll() []backendmetrics.PodMetrics {
 func (ds *datastore) PodList(predicate func(backendmetrics.PodMetrics) bool) []backendmetrics.PodMetrics {
 	res := []backendmetrics.PodMetrics{}
 	fn := func(k, v any) bool {
-		pm := v.(backendmetrics.PodMetrics)
-		if predicate(pm) {
+		pm, ok := ds.toPodMetrics(k, v)
+		if ok && predicate(pm) {
 			res = append(res, pm)
 		}
 		return true
@@ -263,11 +459,13 @@ func (ds *datastore) PodUpdateOrAddIfNotExist(pod *corev1.Pod) bool {
 	}
 	var pm backendmetrics.PodMetrics
 	existing, ok := ds.pods.Load(namespacedName)
+	if ok {
+		pm, ok = ds.toPodMetrics(namespacedName, existing)
+	}
 	if !ok {
+		// The pod is new, or its entry is corrupt and gets replaced.
 		pm = ds.pmf.NewPodMetrics(ds.parentCtx, pod, ds)
 		ds.pods.Store(namespacedName, pm)
-	} else {
-		pm = existing.(backendmetrics.PodMetrics)
 	}
 	// Update pod properties if anything changed.
 	pm.UpdatePod(pod)
@@ -277,13 +475,132 @@ func (ds *datastore) PodUpdateOrAddIfNotExist(pod *corev1.Pod) bool {
 func (ds *datastore) PodDelete(namespacedName types.NamespacedName) {
 	v, ok := ds.pods.LoadAndDelete(namespacedName)
 	if ok {
-		pmr := v.(backendmetrics.PodMetrics)
-		pmr.StopRefreshLoop()
+		if pmr, ok := ds.toPodMetrics(namespacedName, v); ok {
+			pmr.StopRefreshLoop()
+		}
+	}
+	ds.unhealthyPodsMu.Lock()
+	delete(ds.unhealthyPods, namespacedName)
+	ds.unhealthyPodsMu.Unlock()
+	ds.adaptersMu.Lock()
+	defer ds.adaptersMu.Unlock()
+	ds.setPodAdapters(namespacedName, nil)
+}
+
+func (ds *datastore) PodSetPending(namespacedName types.NamespacedName, pending bool) {
+	if pending {
+		ds.pendingPods.Store(namespacedName, struct{}{})
+	} else {
+		ds.pendingPods.Delete(namespacedName)
+	}
+}
+
+func (ds *datastore) PoolIsScalingUp() bool {
+	scalingUp := false
+	ds.pendingPods.Range(func(k, v any) bool {
+		scalingUp = true
+		return false
+	})
+	return scalingUp
+}
+
+func (ds *datastore) RefreshPodMetrics(ctx context.Context, namespacedName types.NamespacedName) error {
+	v, ok := ds.pods.Load(namespacedName)
+	if !ok {
+		return fmt.Errorf("pod %s not found in datastore", namespacedName)
+	}
+	pm, ok := ds.toPodMetrics(namespacedName, v)
+	if !ok {
+		return fmt.Errorf("pod %s has an invalid entry in datastore", namespacedName)
+	}
+	return pm.RefreshMetrics(ctx)
+}
+
+func (ds *datastore) MarkPodUnhealthy(namespacedName types.NamespacedName, until time.Time) {
+	ds.unhealthyPodsMu.Lock()
+	defer ds.unhealthyPodsMu.Unlock()
+	ds.unhealthyPods[namespacedName] = until
+}
+
+func (ds *datastore) PodIsUnhealthy(namespacedName types.NamespacedName) bool {
+	ds.unhealthyPodsMu.RLock()
+	until, ok := ds.unhealthyPods[namespacedName]
+	ds.unhealthyPodsMu.RUnlock()
+	if !ok {
+		return false
+	}
+	if ds.clock.Now().Before(until) {
+		return true
+	}
+	// The window expired, remove the mark.
+	ds.unhealthyPodsMu.Lock()
+	defer ds.unhealthyPodsMu.Unlock()
+	if current, ok := ds.unhealthyPods[namespacedName]; ok && !ds.clock.Now().Before(current) {
+		delete(ds.unhealthyPods, namespacedName)
+	}
+	return false
+}
+
+func (ds *datastore) clearUnhealthyPods() {
+	ds.unhealthyPodsMu.Lock()
+	defer ds.unhealthyPodsMu.Unlock()
+	ds.unhealthyPods = make(map[types.NamespacedName]time.Time)
+}
+
+func (ds *datastore) PodsWithAdapter(name string) []backendmetrics.PodMetrics {
+	ds.adaptersMu.RLock()
+	defer ds.adaptersMu.RUnlock()
+	res := []backendmetrics.PodMetrics{}
+	for namespacedName := range ds.adapterPods[name] {
+		if v, ok := ds.pods.Load(namespacedName); ok {
+			if pm, ok := ds.toPodMetrics(namespacedName, v); ok {
+				res = append(res, pm)
+			}
+		}
+	}
+	return res
+}
+
+// PodMetricsUpdated keeps the adapter index consistent with the adapters reported by the pod.
+func (ds *datastore) PodMetricsUpdated(namespacedName types.NamespacedName, metrics *backendmetrics.Metrics) {
+	adapters := make(map[string]struct{}, len(metrics.ActiveModels))
+	for adapter := range metrics.ActiveModels {
+		adapters[adapter] = struct{}{}
+	}
+	ds.adaptersMu.Lock()
+	defer ds.adaptersMu.Unlock()
+	// The refresh loop may still report metrics for a pod that was just deleted.
+	if _, ok := ds.pods.Load(namespacedName); !ok {
+		adapters = nil
+	}
+	ds.setPodAdapters(namespacedName, adapters)
+}
+
+// setPodAdapters replaces the adapters indexed for the given pod. ds.adaptersMu must be held.
+func (ds *datastore) setPodAdapters(namespacedName types.NamespacedName, adapters map[string]struct{}) {
+	for adapter := range ds.podAdapters[namespacedName] {
+		if _, ok := adapters[adapter]; ok {
+			continue
+		}
+		delete(ds.adapterPods[adapter], namespacedName)
+		if len(ds.adapterPods[adapter]) == 0 {
+			delete(ds.adapterPods, adapter)
+		}
+	}
+	for adapter := range adapters {
+		if ds.adapterPods[adapter] == nil {
+			ds.adapterPods[adapter] = make(map[types.NamespacedName]struct{})
+		}
+		ds.adapterPods[adapter][namespacedName] = struct{}{}
+	}
+	if len(adapters) == 0 {
+		delete(ds.podAdapters, namespacedName)
+	} else {
+		ds.podAdapters[namespacedName] = adapters
 	}
 }
 
 func (ds *datastore) podResyncAll(ctx context.Context, ctrlClient client.Client) error {
-	logger := log.FromContext(ctx)
 	podList := &corev1.PodList{}
 	if err := ctrlClient.List(ctx, podList, &client.ListOptions{
 		LabelSelector: selectorFromInferencePoolSelector(ds.pool.Spec.Selector),
@@ -292,32 +609,82 @@ func (ds *datastore) podResyncAll(ctx context.Context, ctrlClient client.Client)
 		return fmt.Errorf("failed to list pods - %w", err)
 	}
 
-	activePods := make(map[string]bool)
-	for _, pod := range podList.Items {
-		if !podutil.IsPodReady(&pod) {
+	readyPods := make([]*corev1.Pod, 0, len(podList.Items))
+	ds.pendingPods.Clear()
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if !podutil.IsPodReady(pod) {
+			ds.PodSetPending(types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, pod.DeletionTimestamp == nil)
 			continue
 		}
-		namespacedName := types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}
-		activePods[pod.Name] = true
-		if ds.PodUpdateOrAddIfNotExist(&pod) {
-			logger.V(logutil.DEFAULT).Info("Pod added", "name", namespacedName)
-		} else {
-			logger.V(logutil.DEFAULT).Info("Pod already exists", "name", namespacedName)
-		}
+		readyPods = append(readyPods, pod)
 	}
+	ds.podUpdateAll(ctx, readyPods)
 
-	// Remove pods that don't belong to the pool or not ready any more.
-	deleteFn := func(k, v any) bool {
-		pm := v.(backendmetrics.PodMetrics)
-		if exist := activePods[pm.GetPod().NamespacedName.Name]; !exist {
-			logger.V(logutil.VERBOSE).Info("Removing pod", "pod", pm.GetPod())
-			ds.PodDelete(pm.GetPod().NamespacedName)
+	// A full resync reflects the latest known state of the pods, so pods marked unhealthy are
+	// given another chance.
+	ds.clearUnhealthyPods()
+
+	return nil
+}
+
+// podUpdateAll makes the pods in the datastore match the given ready pods in a single pass over
+// the existing pods: existing pods are updated, pods that aren't ready any more or don't belong to
+// the pool are removed, and the remaining ready pods are added.
+func (ds *datastore) podUpdateAll(ctx context.Context, pods []*corev1.Pod) {
+	logger := log.FromContext(ctx)
+	toAdd := make(map[types.NamespacedName]*corev1.Pod, len(pods))
+	for _, pod := range pods {
+		toAdd[types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}] = pod
+	}
+
+	ds.pods.Range(func(k, v any) bool {
+		namespacedName, ok := k.(types.NamespacedName)
+		if !ok {
+			logger.Error(nil, "Unexpected key in the pods map, removing it", "key", k, "type", fmt.Sprintf("%T", k))
+			ds.pods.Delete(k)
+			return true
 		}
+		pod, ok := toAdd[namespacedName]
+		if !ok {
+			logger.V(logutil.VERBOSE).Info("Removing pod", "pod", namespacedName)
+			ds.PodDelete(namespacedName)
+			return true
+		}
+		pm, ok := ds.toPodMetrics(k, v)
+		if !ok {
+			// Drop the corrupt entry, so the pod is added again below.
+			ds.pods.Delete(k)
+			return true
+		}
+		pm.UpdatePod(pod)
+		delete(toAdd, namespacedName)
 		return true
+	})
+
+	for namespacedName, pod := range toAdd {
+		pm := ds.pmf.NewPodMetrics(ds.parentCtx, pod, ds)
+		// The pod reconciler may have added the pod concurrently.
+		if existing, loaded := ds.pods.LoadOrStore(namespacedName, pm); loaded {
+			if existingPM, ok := ds.toPodMetrics(namespacedName, existing); ok {
+				pm.StopRefreshLoop()
+				existingPM.UpdatePod(pod)
+				continue
+			}
+			ds.pods.Store(namespacedName, pm)
+		}
+		logger.V(logutil.DEFAULT).Info("Pod added", "name", namespacedName)
 	}
-	ds.pods.Range(deleteFn)
+}
 
-	return nil
+// toPodMetrics returns the PodMetrics held by a value of the pods map. A value of an unexpected
+// type, which would indicate a bug, is logged and reported as missing rather than crashing the EPP.
+func (ds *datastore) toPodMetrics(k, v any) (backendmetrics.PodMetrics, bool) {
+	pm, ok := v.(backendmetrics.PodMetrics)
+	if !ok {
+		log.FromContext(ds.parentCtx).Error(nil, "Unexpected value in the pods map, skipping it", "key", k, "type", fmt.Sprintf("%T", v))
+	}
+	return pm, ok
 }
 
 func selectorFromInferencePoolSelector(selector map[v1alpha2.LabelKey]v1alpha2.LabelValue) labels.Selector {
@@ -19,6 +19,7 @@ package datastore
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -30,6 +31,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
 	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
@@ -104,6 +107,86 @@ func TestPool(t *testing.T) {
 	}
 }
 
+func TestPoolDefaultCriticality(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *v1alpha2.Criticality
+	}{
+		{
+			name: "No annotation",
+		},
+		{
+			name:        "Sheddable default",
+			annotations: map[string]string{DefaultCriticalityAnnotation: "Sheddable"},
+			want:        ptr.To(v1alpha2.Sheddable),
+		},
+		{
+			name:        "Invalid default",
+			annotations: map[string]string{DefaultCriticalityAnnotation: "Urgent"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = clientgoscheme.AddToScheme(scheme)
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				Build()
+			pool := testutil.MakeInferencePool("pool1").Namespace("default").ObjRef()
+			pool.Annotations = tt.annotations
+			pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Second)
+			datastore := NewDatastore(context.Background(), pmf)
+			_ = datastore.PoolSet(context.Background(), fakeClient, pool)
+			if diff := cmp.Diff(tt.want, datastore.PoolDefaultCriticality()); diff != "" {
+				t.Errorf("Unexpected default criticality diff (+got/-want): %s", diff)
+			}
+		})
+	}
+}
+
+func TestPoolExtensionTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        time.Duration
+	}{
+		{
+			name: "No annotation",
+		},
+		{
+			name:        "Valid timeout",
+			annotations: map[string]string{ExtensionTimeoutAnnotation: "250ms"},
+			want:        250 * time.Millisecond,
+		},
+		{
+			name:        "Invalid timeout",
+			annotations: map[string]string{ExtensionTimeoutAnnotation: "soon"},
+		},
+		{
+			name:        "Negative timeout",
+			annotations: map[string]string{ExtensionTimeoutAnnotation: "-1s"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			_ = clientgoscheme.AddToScheme(scheme)
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				Build()
+			pool := testutil.MakeInferencePool("pool1").Namespace("default").ObjRef()
+			pool.Annotations = tt.annotations
+			pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Second)
+			datastore := NewDatastore(context.Background(), pmf)
+			_ = datastore.PoolSet(context.Background(), fakeClient, pool)
+			if got := datastore.PoolExtensionTimeout(); got != tt.want {
+				t.Errorf("Unexpected extension timeout, got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestModel(t *testing.T) {
 	chatModel := "chat"
 	tsModel := "food-review"
@@ -271,6 +354,99 @@ var (
 	}
 )
 
+func TestModelGetPrevious(t *testing.T) {
+	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Second)
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	ds := NewDatastoreWithClock(context.Background(), pmf, fakeClock)
+	v1 := testutil.MakeInferenceModel("model").ModelName("chat").TargetModel("chat-v1").ObjRef()
+	v1Relabeled := testutil.MakeInferenceModel("model").ModelName("chat").TargetModel("chat-v1").ObjRef()
+	v1Relabeled.Labels = map[string]string{"team": "a"}
+	v2 := testutil.MakeInferenceModel("model").ModelName("chat").TargetModel("chat-v2").ObjRef()
+
+	ds.ModelSetIfOlder(v1)
+	if previous, _ := ds.ModelGetPrevious("chat"); previous != nil {
+		t.Errorf("Expected no previous model before any change, got %v", previous)
+	}
+	// Changes that keep the target models don't start a rollout.
+	ds.ModelSetIfOlder(v1Relabeled)
+	if previous, _ := ds.ModelGetPrevious("chat"); previous != nil {
+		t.Errorf("Expected no previous model when the target models are unchanged, got %v", previous)
+	}
+
+	fakeClock.Step(time.Minute)
+	ds.ModelSetIfOlder(v2)
+	previous, changed := ds.ModelGetPrevious("chat")
+	if previous != v1Relabeled {
+		t.Errorf("Unexpected previous model, got %v, want %v", previous, v1Relabeled)
+	}
+	if !changed.Equal(fakeClock.Now()) {
+		t.Errorf("Unexpected change time %v, want %v", changed, fakeClock.Now())
+	}
+
+	ds.ModelDelete(types.NamespacedName{Name: v2.Name, Namespace: v2.Namespace})
+	if previous, _ := ds.ModelGetPrevious("chat"); previous != nil {
+		t.Errorf("Expected no previous model after the model is deleted, got %v", previous)
+	}
+}
+
+func TestResolveModelName(t *testing.T) {
+	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Second)
+	ds := NewDatastore(context.Background(), pmf)
+	canonical := testutil.MakeInferenceModel("canonical").ModelName("llama-3-8b-instruct").ObjRef()
+	canonical.Annotations = map[string]string{ModelAliasesAnnotation: "llama, llama-3 "}
+	other := testutil.MakeInferenceModel("other").ModelName("llama").ObjRef()
+	ds.ModelSetIfOlder(canonical)
+
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		{requested: "llama-3-8b-instruct", want: "llama-3-8b-instruct"},
+		{requested: "llama", want: "llama-3-8b-instruct"},
+		{requested: "llama-3", want: "llama-3-8b-instruct"},
+		{requested: "unknown", want: "unknown"},
+		{requested: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := ds.ResolveModelName(tt.requested); got != tt.want {
+			t.Errorf("Unexpected resolved model for %q, got %q, want %q", tt.requested, got, tt.want)
+		}
+	}
+
+	// A model name takes precedence over an alias.
+	ds.ModelSetIfOlder(other)
+	if got := ds.ResolveModelName("llama"); got != "llama" {
+		t.Errorf("Unexpected resolved model, got %q, want %q", got, "llama")
+	}
+}
+
+func TestResolveModelNameConflict(t *testing.T) {
+	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Second)
+	ds := NewDatastore(context.Background(), pmf)
+	newModel := func(name string, created int64) *v1alpha2.InferenceModel {
+		m := testutil.MakeInferenceModel(name).ModelName(name).CreationTimestamp(metav1.Unix(created, 0)).ObjRef()
+		m.Annotations = map[string]string{ModelAliasesAnnotation: "mistral"}
+		return m
+	}
+	// The oldest model claiming the alias wins, whatever the order the models are stored in.
+	ds.ModelSetIfOlder(newModel("mistral-a", 1002))
+	ds.ModelSetIfOlder(newModel("mistral-z", 1000))
+	ds.ModelSetIfOlder(newModel("mistral-b", 1001))
+	for i := 0; i < 20; i++ {
+		if got := ds.ResolveModelName("mistral"); got != "mistral-z" {
+			t.Fatalf("Unexpected resolved model, got %q, want %q", got, "mistral-z")
+		}
+	}
+
+	// Models created at the same time are ordered by name.
+	ds.ModelSetIfOlder(newModel("mistral-c", 1000))
+	for i := 0; i < 20; i++ {
+		if got := ds.ResolveModelName("mistral"); got != "mistral-c" {
+			t.Fatalf("Unexpected resolved model, got %q, want %q", got, "mistral-c")
+		}
+	}
+}
+
 func TestMetrics(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -356,6 +532,298 @@ func TestMetrics(t *testing.T) {
 	}
 }
 
+func TestRefreshPodMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		Build()
+	pmc := &backendmetrics.FakePodMetricsClient{
+		Res: map[types.NamespacedName]*backendmetrics.Metrics{
+			pod1NamespacedName: pod1Metrics,
+			pod2NamespacedName: pod2Metrics,
+		},
+	}
+	// Use a long refresh interval so that only the on demand refresh updates the metrics.
+	pmf := backendmetrics.NewPodMetricsFactory(pmc, time.Hour)
+	ds := NewDatastore(ctx, pmf)
+	_ = ds.PoolSet(ctx, fakeClient, inferencePool)
+	ds.PodUpdateOrAddIfNotExist(pod1)
+	ds.PodUpdateOrAddIfNotExist(pod2)
+
+	if err := ds.RefreshPodMetrics(ctx, pod1NamespacedName); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := map[types.NamespacedName]*backendmetrics.Metrics{
+		pod1NamespacedName: pod1Metrics,
+		// pod2 was not refreshed, so its metrics remain the default values.
+		pod2NamespacedName: {ActiveModels: map[string]int{}, WaitingModels: map[string]int{}},
+	}
+	got := map[types.NamespacedName]*backendmetrics.Metrics{}
+	for _, pm := range ds.PodGetAll() {
+		got[pm.GetPod().NamespacedName] = pm.GetMetrics()
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(backendmetrics.Metrics{}, "UpdateTime")); diff != "" {
+		t.Errorf("Unexpected metrics diff (+got/-want): %s", diff)
+	}
+
+	if err := ds.RefreshPodMetrics(ctx, types.NamespacedName{Name: "unknown"}); err == nil {
+		t.Errorf("Expected an error when refreshing an unknown pod")
+	}
+}
+
+func TestPodsWithAdapter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		Build()
+	pmc := &backendmetrics.FakePodMetricsClient{
+		Res: map[types.NamespacedName]*backendmetrics.Metrics{
+			pod1NamespacedName: {ActiveModels: map[string]int{"foo": 1, "bar": 1}},
+			pod2NamespacedName: {ActiveModels: map[string]int{"foo": 1}},
+		},
+	}
+	// Use a long refresh interval so that only the on demand refreshes update the metrics.
+	pmf := backendmetrics.NewPodMetricsFactory(pmc, time.Hour)
+	ds := NewDatastore(ctx, pmf)
+	_ = ds.PoolSet(ctx, fakeClient, inferencePool)
+	ds.PodUpdateOrAddIfNotExist(pod1)
+	ds.PodUpdateOrAddIfNotExist(pod2)
+
+	refresh := func() {
+		for _, name := range []types.NamespacedName{pod1NamespacedName, pod2NamespacedName} {
+			if err := ds.RefreshPodMetrics(ctx, name); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+	}
+	podsWithAdapter := func(adapter string) []types.NamespacedName {
+		res := []types.NamespacedName{}
+		for _, pm := range ds.PodsWithAdapter(adapter) {
+			res = append(res, pm.GetPod().NamespacedName)
+		}
+		return res
+	}
+	sortNames := cmpopts.SortSlices(func(a, b types.NamespacedName) bool { return a.String() < b.String() })
+
+	refresh()
+	if diff := cmp.Diff([]types.NamespacedName{pod1NamespacedName, pod2NamespacedName}, podsWithAdapter("foo"), sortNames); diff != "" {
+		t.Errorf("Unexpected pods with adapter foo (-want +got): %s", diff)
+	}
+	if diff := cmp.Diff([]types.NamespacedName{pod1NamespacedName}, podsWithAdapter("bar"), sortNames); diff != "" {
+		t.Errorf("Unexpected pods with adapter bar (-want +got): %s", diff)
+	}
+
+	// pod1 unloads foo and pod2 loads bar.
+	pmc.SetRes(map[types.NamespacedName]*backendmetrics.Metrics{
+		pod1NamespacedName: {ActiveModels: map[string]int{"bar": 1}},
+		pod2NamespacedName: {ActiveModels: map[string]int{"foo": 1, "bar": 1}},
+	})
+	refresh()
+	if diff := cmp.Diff([]types.NamespacedName{pod2NamespacedName}, podsWithAdapter("foo"), sortNames); diff != "" {
+		t.Errorf("Unexpected pods with adapter foo (-want +got): %s", diff)
+	}
+	if diff := cmp.Diff([]types.NamespacedName{pod1NamespacedName, pod2NamespacedName}, podsWithAdapter("bar"), sortNames); diff != "" {
+		t.Errorf("Unexpected pods with adapter bar (-want +got): %s", diff)
+	}
+
+	// Deleted pods are removed from the index.
+	ds.PodDelete(pod2NamespacedName)
+	if diff := cmp.Diff([]types.NamespacedName{}, podsWithAdapter("foo"), sortNames); diff != "" {
+		t.Errorf("Unexpected pods with adapter foo (-want +got): %s", diff)
+	}
+	if diff := cmp.Diff([]types.NamespacedName{pod1NamespacedName}, podsWithAdapter("bar"), sortNames); diff != "" {
+		t.Errorf("Unexpected pods with adapter bar (-want +got): %s", diff)
+	}
+}
+
+func TestPodMetadata(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Second).
+		WithPodMetadataKeys([]string{"zone"}, []string{"cost"})
+	ds := NewDatastore(ctx, pmf)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pod1",
+			Labels:      map[string]string{"zone": "a", "app": "vllm"},
+			Annotations: map[string]string{"cost": "2", "other": "ignored"},
+		},
+	}
+	ds.PodUpdateOrAddIfNotExist(pod)
+	want := &backendmetrics.Pod{
+		NamespacedName: types.NamespacedName{Name: "pod1"},
+		Labels:         map[string]string{"zone": "a"},
+		Annotations:    map[string]string{"cost": "2"},
+	}
+	if diff := cmp.Diff(want, ds.PodGetAll()[0].GetPod()); diff != "" {
+		t.Errorf("Unexpected pod diff (+got/-want): %s", diff)
+	}
+
+	// Label changes propagate on pod update.
+	updated := pod.DeepCopy()
+	updated.Labels["zone"] = "b"
+	ds.PodUpdateOrAddIfNotExist(updated)
+	want.Labels = map[string]string{"zone": "b"}
+	if diff := cmp.Diff(want, ds.PodGetAll()[0].GetPod()); diff != "" {
+		t.Errorf("Unexpected pod diff (+got/-want): %s", diff)
+	}
+}
+
+func TestMarkPodUnhealthy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Second)
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	ds := NewDatastoreWithClock(ctx, pmf, fakeClock)
+
+	ds.MarkPodUnhealthy(pod1NamespacedName, fakeClock.Now().Add(100*time.Millisecond))
+	if !ds.PodIsUnhealthy(pod1NamespacedName) {
+		t.Errorf("Expected pod %v to be unhealthy", pod1NamespacedName)
+	}
+	if ds.PodIsUnhealthy(pod2NamespacedName) {
+		t.Errorf("Expected pod %v to be healthy", pod2NamespacedName)
+	}
+
+	// The mark holds until the window expires, and is cleared once it does.
+	fakeClock.Step(99 * time.Millisecond)
+	if !ds.PodIsUnhealthy(pod1NamespacedName) {
+		t.Errorf("Expected pod %v to be unhealthy until the window expires", pod1NamespacedName)
+	}
+	fakeClock.Step(time.Millisecond)
+	if ds.PodIsUnhealthy(pod1NamespacedName) {
+		t.Errorf("Expected pod %v to be healthy after the window expired", pod1NamespacedName)
+	}
+
+	// The mark is cleared when the datastore is cleared.
+	ds.MarkPodUnhealthy(pod1NamespacedName, fakeClock.Now().Add(time.Hour))
+	ds.Clear()
+	if ds.PodIsUnhealthy(pod1NamespacedName) {
+		t.Errorf("Expected pod %v to be healthy after clearing the datastore", pod1NamespacedName)
+	}
+}
+
+func TestPodResyncAll(t *testing.T) {
+	selector := map[string]string{"app": "vllm"}
+	pool := testutil.MakeInferencePool("pool1").Namespace("default").Selector(selector).ObjRef()
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			testutil.MakePod("updated").Namespace("default").Labels(selector).ReadyCondition().IP("10.0.0.2").ObjRef(),
+			testutil.MakePod("added").Namespace("default").Labels(selector).ReadyCondition().IP("10.0.0.3").ObjRef(),
+			testutil.MakePod("not-ready").Namespace("default").Labels(selector).IP("10.0.0.4").ObjRef(),
+			testutil.MakePod("other-pool").Namespace("default").Labels(map[string]string{"app": "other"}).ReadyCondition().ObjRef(),
+			testutil.MakePod("other-namespace").Namespace("other").Labels(selector).ReadyCondition().ObjRef(),
+		).
+		Build()
+	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Second)
+	ds := NewDatastore(t.Context(), pmf)
+	ds.PodUpdateOrAddIfNotExist(testutil.MakePod("updated").Namespace("default").IP("10.0.0.1").ObjRef())
+	ds.PodUpdateOrAddIfNotExist(testutil.MakePod("removed").Namespace("default").ObjRef())
+
+	if err := ds.PoolSet(context.Background(), fakeClient, pool); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, pm := range ds.PodGetAll() {
+		got[pm.GetPod().NamespacedName.Name] = pm.GetPod().Address
+	}
+	want := map[string]string{"updated": "10.0.0.2", "added": "10.0.0.3"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected pods diff (+got/-want): %s", diff)
+	}
+	if !ds.PoolIsScalingUp() {
+		t.Error("Expected the pool to be scaling up with a pod that is not ready")
+	}
+}
+
+func TestCorruptPodEntries(t *testing.T) {
+	selector := map[string]string{"app": "vllm"}
+	pool := testutil.MakeInferencePool("pool1").Namespace("default").Selector(selector).ObjRef()
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			testutil.MakePod("healthy").Namespace("default").Labels(selector).ReadyCondition().ObjRef(),
+			testutil.MakePod("corrupt").Namespace("default").Labels(selector).ReadyCondition().IP("10.0.0.2").ObjRef(),
+		).
+		Build()
+	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Second)
+	ds := NewDatastore(t.Context(), pmf)
+	ds.PodUpdateOrAddIfNotExist(testutil.MakePod("healthy").Namespace("default").ObjRef())
+	corrupt := types.NamespacedName{Name: "corrupt", Namespace: "default"}
+	removed := types.NamespacedName{Name: "removed", Namespace: "default"}
+	ds.(*datastore).pods.Store(corrupt, "not a PodMetrics")
+	ds.(*datastore).pods.Store(removed, 42)
+	ds.(*datastore).pods.Store("not a NamespacedName", 42)
+
+	// Corrupt entries are skipped.
+	if got := len(ds.PodGetAll()); got != 1 {
+		t.Errorf("Unexpected number of pods, got %d, want 1", got)
+	}
+	if err := ds.RefreshPodMetrics(context.Background(), corrupt); err == nil {
+		t.Error("Expected an error refreshing the metrics of a corrupt entry")
+	}
+	ds.PodDelete(removed)
+
+	// A resync replaces the corrupt entries of ready pods and removes the others.
+	if err := ds.PoolSet(context.Background(), fakeClient, pool); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := map[string]string{}
+	for _, pm := range ds.PodGetAll() {
+		got[pm.GetPod().NamespacedName.Name] = pm.GetPod().Address
+	}
+	want := map[string]string{"healthy": "", "corrupt": "10.0.0.2"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected pods diff (+got/-want): %s", diff)
+	}
+	entries := 0
+	ds.(*datastore).pods.Range(func(k, v any) bool {
+		entries++
+		return true
+	})
+	if entries != 2 {
+		t.Errorf("Unexpected number of entries in the pods map, got %d, want 2", entries)
+	}
+}
+
+func BenchmarkPodResyncAll(b *testing.B) {
+	selector := map[string]string{"app": "vllm"}
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	builder := fake.NewClientBuilder().WithScheme(scheme)
+	for i := 0; i < 500; i++ {
+		builder = builder.WithObjects(testutil.MakePod(fmt.Sprintf("pod-%d", i)).Namespace("default").Labels(selector).ReadyCondition().ObjRef())
+	}
+	fakeClient := builder.Build()
+	pmf := backendmetrics.NewPodMetricsFactory(&backendmetrics.FakePodMetricsClient{}, time.Hour)
+	ds := NewDatastore(b.Context(), pmf)
+	pool := testutil.MakeInferencePool("pool1").Namespace("default").Selector(selector).ObjRef()
+	if err := ds.PoolSet(context.Background(), fakeClient, pool); err != nil {
+		b.Fatalf("Unexpected error: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Every pod is already in the datastore, so the resync only updates them.
+		if err := ds.(*datastore).podResyncAll(context.Background(), fakeClient); err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
 func TestPods(t *testing.T) {
 	updatedPod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
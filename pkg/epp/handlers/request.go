@@ -20,12 +20,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
 	"strconv"
+	"strings"
 	"time"
 
+	configPb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extProcPb "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/propagation"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	schedulingconfig "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
 	schedulingtypes "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
 	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
@@ -42,11 +50,13 @@ func (s *StreamingServer) HandleRequestBody(
 	logger := log.FromContext(ctx)
 
 	// Resolve target models.
-	model, ok := requestBodyMap["model"].(string)
+	requestedModel, ok := requestBodyMap["model"].(string)
 	if !ok {
 		return reqCtx, errutil.Error{Code: errutil.BadRequest, Msg: "model not found in request"}
 	}
 
+	// The requested model may be an alias of the model name of an InferenceModel.
+	model := s.datastore.ResolveModelName(requestedModel)
 	modelName := model
 
 	// NOTE: The nil checking for the modelObject means that we DO allow passthrough currently.
@@ -56,22 +66,43 @@ func (s *StreamingServer) HandleRequestBody(
 	if modelObj == nil {
 		return reqCtx, errutil.Error{Code: errutil.BadConfiguration, Msg: fmt.Sprintf("error finding a model object in InferenceModel for input %v", model)}
 	}
-	if len(modelObj.Spec.TargetModels) > 0 {
-		modelName = RandomWeightedDraw(logger, modelObj, 0)
+	if targetModelObj := s.rolloutModel(model, modelObj); len(targetModelObj.Spec.TargetModels) > 0 {
+		modelName = RandomWeightedDraw(logger, targetModelObj, 0)
 		if modelName == "" {
-			return reqCtx, errutil.Error{Code: errutil.BadConfiguration, Msg: fmt.Sprintf("error getting target model name for model %v", modelObj.Name)}
+			return reqCtx, errutil.Error{Code: errutil.BadConfiguration, Msg: fmt.Sprintf("error getting target model name for model %v", targetModelObj.Name)}
 		}
 	}
 	llmReq := &schedulingtypes.LLMRequest{
 		Model:               model,
 		ResolvedTargetModel: modelName,
-		Critical:            modelObj.Spec.Criticality != nil && *modelObj.Spec.Criticality == v1alpha2.Critical,
+		Critical:            isCritical(modelObj, s.datastore.PoolDefaultCriticality()),
+		PromptTokens:        estimatePromptTokens(requestBodyMap),
+		CostMultiplier:      costMultiplier(logger, modelObj),
+		ScorerOverrides:     reqCtx.ScorerOverrides,
+		ExperimentKey:       reqCtx.ExperimentKey,
+		TenantID:            reqCtx.TenantID,
+		WantBackupPod:       reqCtx.WantBackupPod,
+		Redundant:           reqCtx.Redundant,
+		PreviousNodes:       reqCtx.PreviousNodes,
+		ModelPreferences:    allowedModelPreferences(logger, modelObj, reqCtx.ModelPreferences),
 	}
 	logger.V(logutil.DEBUG).Info("LLM request assembled", "request", llmReq)
 
-	var err error
-	// Update target models in the body.
-	if llmReq.Model != llmReq.ResolvedTargetModel {
+	res, err := s.schedule(withTraceContext(ctx, reqCtx.TraceParent), llmReq)
+	if err != nil {
+		// The code of the scheduling errors tells the failure mode, which decides the status returned.
+		if e, ok := err.(errutil.Error); ok {
+			e.Msg = "failed to find target pod: " + e.Msg
+			return reqCtx, e
+		}
+		return reqCtx, errutil.Error{Code: errutil.InferencePoolResourceExhausted, Msg: fmt.Errorf("failed to find target pod: %w", err).Error()}
+	}
+	reqCtx.release = res.Release
+	targetPod := res.TargetPod.GetPod()
+
+	// Update target models in the body. The scheduler may have resolved the target model from the
+	// model preferences of the request.
+	if requestedModel != llmReq.ResolvedTargetModel {
 		requestBodyMap["model"] = llmReq.ResolvedTargetModel
 	}
 
@@ -81,12 +112,6 @@ func (s *StreamingServer) HandleRequestBody(
 		return reqCtx, errutil.Error{Code: errutil.Internal, Msg: fmt.Sprintf("error marshaling request body: %v", err)}
 	}
 
-	res, err := s.scheduler.Schedule(ctx, llmReq)
-	if err != nil {
-		return reqCtx, errutil.Error{Code: errutil.InferencePoolResourceExhausted, Msg: fmt.Errorf("failed to find target pod: %w", err).Error()}
-	}
-	targetPod := res.TargetPod.GetPod()
-
 	// Insert target endpoint to instruct Envoy to route requests to the specified target pod.
 	// Attach the port number
 	pool, err := s.datastore.PoolGet()
@@ -94,9 +119,13 @@ func (s *StreamingServer) HandleRequestBody(
 		return reqCtx, err
 	}
 	endpoint := targetPod.Address + ":" + strconv.Itoa(int(pool.Spec.TargetPortNumber))
+	backupEndpoint := ""
+	if res.BackupPod != nil {
+		backupEndpoint = res.BackupPod.GetPod().Address + ":" + strconv.Itoa(int(pool.Spec.TargetPortNumber))
+	}
 
 	logger.V(logutil.DEFAULT).Info("Request handled",
-		"model", llmReq.Model, "targetModel", llmReq.ResolvedTargetModel, "endpoint", targetPod)
+		"model", llmReq.Model, "targetModel", llmReq.ResolvedTargetModel, "endpoint", targetPod, "backupEndpoint", backupEndpoint)
 
 	reqCtx.Model = llmReq.Model
 	reqCtx.ResolvedTargetModel = llmReq.ResolvedTargetModel
@@ -104,7 +133,7 @@ func (s *StreamingServer) HandleRequestBody(
 	reqCtx.TargetPod = targetPod.NamespacedName.String()
 	reqCtx.TargetEndpoint = endpoint
 
-	s.populateRequestHeaderResponse(reqCtx, endpoint, len(requestBodyBytes))
+	s.populateRequestHeaderResponse(reqCtx, endpoint, backupEndpoint, len(requestBodyBytes))
 
 	reqCtx.reqBodyResp = &extProcPb.ProcessingResponse{
 		// The Endpoint Picker supports two approaches to communicating the target endpoint, as a request header
@@ -128,9 +157,54 @@ func (s *StreamingServer) HandleRequestBody(
 	return reqCtx, nil
 }
 
+// schedule schedules the request within the extension timeout of the pool, if any, so that the
+// gateway doesn't give up on the extension before it responds. A tighter deadline on the context
+// is kept.
+func (s *StreamingServer) schedule(ctx context.Context, req *schedulingtypes.LLMRequest) (*schedulingtypes.Result, error) {
+	if timeout := s.datastore.PoolExtensionTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return s.scheduler.Schedule(ctx, req)
+}
+
 func (s *StreamingServer) HandleRequestHeaders(ctx context.Context, reqCtx *RequestContext, req *extProcPb.ProcessingRequest_RequestHeaders) error {
 	reqCtx.RequestReceivedTimestamp = time.Now()
 
+	for _, header := range req.RequestHeaders.GetHeaders().GetHeaders() {
+		switch header.Key {
+		case ScorerWeightsHeader:
+			overrides, err := parseScorerOverrides(string(header.RawValue), schedulingconfig.Conf.ScorerOverrideLimits)
+			if err != nil {
+				return errutil.Error{Code: errutil.BadRequest, Msg: err.Error()}
+			}
+			reqCtx.ScorerOverrides = overrides
+		case ExperimentKeyHeader:
+			reqCtx.ExperimentKey = string(header.RawValue)
+		case TenantIDHeader:
+			reqCtx.TenantID = string(header.RawValue)
+		case TraceParentHeader:
+			reqCtx.TraceParent = string(header.RawValue)
+		case BackupPodHeader:
+			want, err := parseBoolHeader(header)
+			if err != nil {
+				return err
+			}
+			reqCtx.WantBackupPod = want
+		case ModelPreferencesHeader:
+			reqCtx.ModelPreferences = schedulingconfig.ParseList(string(header.RawValue))
+		case PreviousNodesHeader:
+			reqCtx.PreviousNodes = schedulingconfig.ParseList(string(header.RawValue))
+		case RedundantHeader:
+			redundant, err := parseBoolHeader(header)
+			if err != nil {
+				return err
+			}
+			reqCtx.Redundant = redundant
+		}
+	}
+
 	// an EoS in the request headers means this request has no body or trailers.
 	if req.RequestHeaders.EndOfStream {
 		// We will route this request to a random pod as this is assumed to just be a GET
@@ -146,7 +220,196 @@ func (s *StreamingServer) HandleRequestHeaders(ctx context.Context, reqCtx *Requ
 			return err
 		}
 		endpoint := pod.Address + ":" + strconv.Itoa(int(pool.Spec.TargetPortNumber))
-		s.populateRequestHeaderResponse(reqCtx, endpoint, 0)
+		s.populateRequestHeaderResponse(reqCtx, endpoint, "", 0)
 	}
 	return nil
 }
+
+// charactersPerToken is the rough number of characters per token used to estimate the prompt
+// length without tokenizing it.
+const charactersPerToken = 4
+
+// estimatePromptTokens estimates the number of prompt tokens of a completions request ("prompt")
+// or a chat completions request ("messages").
+func estimatePromptTokens(requestBodyMap map[string]interface{}) int {
+	chars := 0
+	if prompt, ok := requestBodyMap["prompt"].(string); ok {
+		chars += len(prompt)
+	}
+	if messages, ok := requestBodyMap["messages"].([]interface{}); ok {
+		for _, m := range messages {
+			if message, ok := m.(map[string]interface{}); ok {
+				if content, ok := message["content"].(string); ok {
+					chars += len(content)
+				}
+			}
+		}
+	}
+	return chars / charactersPerToken
+}
+
+// costMultiplier returns the cost multiplier of the model, or 1 if it is missing or invalid.
+func costMultiplier(logger logr.Logger, modelObj *v1alpha2.InferenceModel) float64 {
+	val, ok := modelObj.Annotations[datastore.CostMultiplierAnnotation]
+	if !ok {
+		return 1
+	}
+	multiplier, err := strconv.ParseFloat(val, 64)
+	if err != nil || math.IsNaN(multiplier) || math.IsInf(multiplier, 0) || multiplier <= 0 {
+		logger.V(logutil.DEFAULT).Info("Ignoring invalid cost multiplier annotation", "model", modelObj.Name, "value", val)
+		return 1
+	}
+	return multiplier
+}
+
+// allowedModelPreferences returns the preferred models that the InferenceModel targets, in order.
+// The other models are ignored, so that the preferences don't route requests to models outside of
+// the InferenceModel.
+func allowedModelPreferences(logger logr.Logger, modelObj *v1alpha2.InferenceModel, preferences []string) []string {
+	var allowed []string
+	for _, preferred := range preferences {
+		if isTargetModel(modelObj, preferred) {
+			allowed = append(allowed, preferred)
+		} else {
+			logger.V(logutil.DEBUG).Info("Ignoring a preferred model the InferenceModel doesn't target", "model", modelObj.Name, "preferredModel", preferred)
+		}
+	}
+	return allowed
+}
+
+// isTargetModel returns whether the InferenceModel targets the given model, i.e. it is one of its
+// target models, or its model name if it has none.
+func isTargetModel(modelObj *v1alpha2.InferenceModel, name string) bool {
+	if len(modelObj.Spec.TargetModels) == 0 {
+		return name == modelObj.Spec.ModelName
+	}
+	for _, target := range modelObj.Spec.TargetModels {
+		if target.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isCritical returns whether the requests for the model are critical. The pool default criticality
+// applies to models that don't specify one.
+func isCritical(modelObj *v1alpha2.InferenceModel, poolDefault *v1alpha2.Criticality) bool {
+	criticality := modelObj.Spec.Criticality
+	if criticality == nil {
+		criticality = poolDefault
+	}
+	return criticality != nil && *criticality == v1alpha2.Critical
+}
+
+// ScorerWeightsHeader is the request header overriding scorer weights for the request, as a comma
+// separated list of name:weight pairs like the configured weights, e.g.
+// "queue:2,kv-cache-utilization:0". Only the scorers allow-listed in the scheduler config can be
+// overridden, within their configured limits.
+const ScorerWeightsHeader = "x-gateway-scorer-weights"
+
+// ExperimentKeyHeader is the request header holding a stable identifier of the client or session,
+// e.g. a session ID, used to consistently assign its requests to an experiment group.
+const ExperimentKeyHeader = "x-gateway-experiment-key"
+
+// TenantIDHeader is the request header identifying the tenant of the request, used to share the
+// pool fairly between tenants when it is saturated.
+const TenantIDHeader = "x-gateway-tenant-id"
+
+// TraceParentHeader is the W3C trace context header of the request. The trace it identifies is
+// attached to the scheduling metrics as exemplars.
+const TraceParentHeader = "traceparent"
+
+// ModelPreferencesHeader is the request header listing the target models the client accepts, most
+// preferred first and comma separated. The request is sent to the first of these models that has
+// an available pod, in place of the target model drawn from the InferenceModel, which is used if
+// none of them has. Only the target models of the InferenceModel are accepted, the others are
+// ignored.
+const ModelPreferencesHeader = "x-gateway-model-preferences"
+
+// PreviousNodesHeader is the request header listing the nodes the previous attempts of a retried
+// request were sent to, comma separated, so that the retry is scheduled on another node.
+const PreviousNodesHeader = "x-gateway-previous-nodes"
+
+// BackupPodHeader is the request header asking for a backup pod, distinct from the target pod, when
+// set to "true". The endpoint of the backup pod is returned in the BackupEndpointHintKey of the
+// dynamic metadata.
+const BackupPodHeader = "x-gateway-backup-pod"
+
+// RedundantHeader is the request header asking for a standby pod on another node than the target
+// pod when set to "true", e.g. to send a critical request to both. The endpoint of the standby pod
+// is returned in the BackupEndpointHintKey of the dynamic metadata.
+const RedundantHeader = "x-gateway-redundant"
+
+// BackupEndpointHintKey is the key of the backup or standby endpoint in the dynamic metadata, next
+// to the destination endpoint hint.
+const BackupEndpointHintKey = "x-gateway-backup-destination-endpoint"
+
+// parseBoolHeader parses the value of a boolean request header.
+func parseBoolHeader(header *configPb.HeaderValue) (bool, error) {
+	val, err := strconv.ParseBool(string(header.RawValue))
+	if err != nil {
+		return false, errutil.Error{Code: errutil.BadRequest, Msg: fmt.Sprintf("invalid value for header %s: %v", header.Key, err)}
+	}
+	return val, nil
+}
+
+// withTraceContext returns the context carrying the remote span identified by the trace parent,
+// or the context itself if the trace parent is unset or invalid.
+func withTraceContext(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{TraceParentHeader: traceParent})
+}
+
+// parseScorerOverrides parses the value of the ScorerWeightsHeader, rejecting scorers missing from
+// the given limits and weights that aren't finite numbers in [0, limit].
+func parseScorerOverrides(val string, limits map[string]float64) (map[string]float64, error) {
+	overrides := map[string]float64{}
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		name, weight, found := strings.Cut(item, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid scorer weight %q in header %s", item, ScorerWeightsHeader)
+		}
+		name = strings.TrimSpace(name)
+		limit, ok := limits[name]
+		if !ok {
+			return nil, fmt.Errorf("scorer %q can't be overridden", name)
+		}
+		w, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight for scorer %q: %w", name, err)
+		}
+		if math.IsNaN(w) || math.IsInf(w, 0) || w < 0 || w > limit {
+			return nil, fmt.Errorf("weight %v for scorer %q is out of the range [0, %v]", w, name, limit)
+		}
+		overrides[name] = w
+	}
+	return overrides, nil
+}
+
+// rolloutModel returns the InferenceModel to draw the target model from. Within the rollout window
+// after the target models of the model changed, the previous InferenceModel is still returned for
+// a share of the requests that decreases linearly over the window.
+func (s *StreamingServer) rolloutModel(modelName string, current *v1alpha2.InferenceModel) *v1alpha2.InferenceModel {
+	if s.modelRolloutWindow <= 0 {
+		return current
+	}
+	previous, changed := s.datastore.ModelGetPrevious(modelName)
+	if previous == nil || rand.Float64() < rolloutShare(time.Since(changed), s.modelRolloutWindow) {
+		return current
+	}
+	return previous
+}
+
+// rolloutShare returns the share of the requests sent to the new target models once the given
+// time elapsed since the change, ramping up from 0 to 1 over the window.
+func rolloutShare(elapsed, window time.Duration) float64 {
+	if elapsed >= window {
+		return 1
+	}
+	return math.Max(0, float64(elapsed)/float64(window))
+}
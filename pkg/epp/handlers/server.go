@@ -42,12 +42,13 @@ import (
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
 )
 
-func NewStreamingServer(scheduler Scheduler, destinationEndpointHintMetadataNamespace, destinationEndpointHintKey string, datastore datastore.Datastore) *StreamingServer {
+func NewStreamingServer(scheduler Scheduler, destinationEndpointHintMetadataNamespace, destinationEndpointHintKey string, datastore datastore.Datastore, modelRolloutWindow time.Duration) *StreamingServer {
 	return &StreamingServer{
 		scheduler:                                scheduler,
 		destinationEndpointHintMetadataNamespace: destinationEndpointHintMetadataNamespace,
 		destinationEndpointHintKey:               destinationEndpointHintKey,
 		datastore:                                datastore,
+		modelRolloutWindow:                       modelRolloutWindow,
 	}
 }
 
@@ -62,6 +63,9 @@ type StreamingServer struct {
 	// back the picked endpoints.
 	destinationEndpointHintMetadataNamespace string
 	datastore                                datastore.Datastore
+	// modelRolloutWindow is the window over which traffic shifts from the previous to the new
+	// target models of an InferenceModel that changed.
+	modelRolloutWindow time.Duration
 }
 
 type Scheduler interface {
@@ -82,9 +86,27 @@ type RequestContext struct {
 	ResponseComplete          bool
 	ResponseStatusCode        string
 	RequestRunning            bool
+	// ScorerOverrides are the per-request scorer weights parsed from the ScorerWeightsHeader.
+	ScorerOverrides map[string]float64
+	// ExperimentKey is the value of the ExperimentKeyHeader.
+	ExperimentKey string
+	// TenantID is the value of the TenantIDHeader.
+	TenantID string
+	// TraceParent is the value of the TraceParentHeader.
+	TraceParent string
+	// WantBackupPod is the value of the BackupPodHeader.
+	WantBackupPod bool
+	// Redundant is the value of the RedundantHeader.
+	Redundant bool
+	// PreviousNodes are the nodes listed in the PreviousNodesHeader.
+	PreviousNodes []string
+	// ModelPreferences are the models listed in the ModelPreferencesHeader.
+	ModelPreferences []string
 
 	RequestState         StreamRequestState
 	modelServerStreaming bool
+	// release, if set, frees the slot the request holds in the scheduler once it completes.
+	release func()
 
 	reqHeaderResp  *extProcPb.ProcessingResponse
 	reqBodyResp    *extProcPb.ProcessingResponse
@@ -136,6 +158,9 @@ func (s *StreamingServer) Process(srv extProcPb.ExternalProcessor_ProcessServer)
 		if reqCtx.RequestRunning {
 			metrics.DecRunningRequests(reqCtx.Model)
 		}
+		if reqCtx.release != nil {
+			reqCtx.release()
+		}
 	}(err, reqCtx)
 
 	for {
@@ -358,7 +383,7 @@ func (r *RequestContext) updateStateAndSendIfNeeded(srv extProcPb.ExternalProces
 	return nil
 }
 
-func (s *StreamingServer) populateRequestHeaderResponse(reqCtx *RequestContext, endpoint string, requestBodyLength int) {
+func (s *StreamingServer) populateRequestHeaderResponse(reqCtx *RequestContext, endpoint, backupEndpoint string, requestBodyLength int) {
 	headers := []*configPb.HeaderValueOption{
 		{
 			Header: &configPb.HeaderValue{
@@ -387,6 +412,9 @@ func (s *StreamingServer) populateRequestHeaderResponse(reqCtx *RequestContext,
 			},
 		},
 	}
+	if backupEndpoint != "" {
+		targetEndpointValue.Fields[BackupEndpointHintKey] = structpb.NewStringValue(backupEndpoint)
+	}
 	dynamicMetadata := targetEndpointValue
 	if s.destinationEndpointHintMetadataNamespace != "" {
 		// If a namespace is defined, wrap the selected endpoint with that.
@@ -473,8 +501,44 @@ func BuildErrResponse(err error) (*extProcPb.ProcessingResponse, error) {
 				},
 			},
 		}
-	// This code can be returned by when EPP processes the request and run into server-side errors.
-	case errutil.Internal:
+	// These codes can be returned by the scheduler when no pod can serve the request, regardless of
+	// its criticality, or when it is shutting down.
+	case errutil.NoReadyEndpoints, errutil.NoCandidateAfterFilter, errutil.PoolNotReady, errutil.SchedulerShuttingDown:
+		resp = &extProcPb.ProcessingResponse{
+			Response: &extProcPb.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extProcPb.ImmediateResponse{
+					Status: &envoyTypePb.HttpStatus{
+						Code: envoyTypePb.StatusCode_ServiceUnavailable,
+					},
+				},
+			},
+		}
+	// This code can be returned by the scheduler when it runs out of time to pick a pod.
+	case errutil.SchedulingTimeout:
+		resp = &extProcPb.ProcessingResponse{
+			Response: &extProcPb.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extProcPb.ImmediateResponse{
+					Status: &envoyTypePb.HttpStatus{
+						Code: envoyTypePb.StatusCode_GatewayTimeout,
+					},
+				},
+			},
+		}
+	// This code can be returned by the scheduler when the client disconnects while scheduling. The
+	// client won't get the response, which is still needed to end the stream.
+	case errutil.SchedulingCanceled:
+		resp = &extProcPb.ProcessingResponse{
+			Response: &extProcPb.ProcessingResponse_ImmediateResponse{
+				ImmediateResponse: &extProcPb.ImmediateResponse{
+					Status: &envoyTypePb.HttpStatus{
+						Code: envoyTypePb.StatusCode_ServiceUnavailable,
+					},
+				},
+			},
+		}
+	// This code can be returned by when EPP processes the request and run into server-side errors,
+	// or by the scheduler when a scorer fails.
+	case errutil.Internal, errutil.ScoringFailed:
 		resp = &extProcPb.ProcessingResponse{
 			Response: &extProcPb.ProcessingResponse_ImmediateResponse{
 				ImmediateResponse: &extProcPb.ImmediateResponse{
@@ -508,5 +572,17 @@ func BuildErrResponse(err error) (*extProcPb.ProcessingResponse, error) {
 	default:
 		return nil, status.Errorf(status.Code(err), "failed to handle request: %v", err)
 	}
+	if e, ok := err.(errutil.Error); ok {
+		if e.HTTPStatus != 0 {
+			resp.GetImmediateResponse().Status.Code = envoyTypePb.StatusCode(e.HTTPStatus)
+		}
+		if e.RetryAfterSeconds > 0 {
+			resp.GetImmediateResponse().Headers = &extProcPb.HeaderMutation{
+				SetHeaders: []*configPb.HeaderValueOption{{
+					Header: &configPb.HeaderValue{Key: "Retry-After", RawValue: []byte(strconv.Itoa(e.RetryAfterSeconds))},
+				}},
+			}
+		}
+	}
 	return resp, nil
 }
@@ -17,16 +17,27 @@ limitations under the License.
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"math"
 	"testing"
 	"time"
 
+	configPb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extProcPb "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	envoyTypePb "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"sigs.k8s.io/gateway-api-inference-extension/api/v1alpha2"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/datastore"
+	schedulingtypes "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+	testutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/testing"
 )
 
 func TestRandomWeightedDraw(t *testing.T) {
@@ -181,6 +192,679 @@ func TestGetRandomPod(t *testing.T) {
 	}
 }
 
+func TestRolloutShare(t *testing.T) {
+	window := 10 * time.Minute
+	tests := []struct {
+		elapsed time.Duration
+		want    float64
+	}{
+		{elapsed: 0, want: 0},
+		{elapsed: 150 * time.Second, want: 0.25},
+		{elapsed: 5 * time.Minute, want: 0.5},
+		{elapsed: window, want: 1},
+		{elapsed: time.Hour, want: 1},
+	}
+	for _, test := range tests {
+		if got := rolloutShare(test.elapsed, window); got != test.want {
+			t.Errorf("Unexpected rollout share after %v, got %v, want %v", test.elapsed, got, test.want)
+		}
+	}
+}
+
+func TestRolloutModel(t *testing.T) {
+	pmf := metrics.NewPodMetricsFactory(&metrics.FakePodMetricsClient{}, time.Millisecond)
+	ds := datastore.NewDatastore(t.Context(), pmf)
+	v1 := testutil.MakeInferenceModel("model").ModelName("chat").TargetModel("chat-v1").ObjRef()
+	v2 := testutil.MakeInferenceModel("model").ModelName("chat").TargetModel("chat-v2").ObjRef()
+	ds.ModelSetIfOlder(v1)
+
+	// Before any change, the current model is always used.
+	server := NewStreamingServer(nil, "", "", ds, time.Hour)
+	if got := server.rolloutModel("chat", v1); got != v1 {
+		t.Errorf("Unexpected model before the change, got %v, want %v", got.Spec.TargetModels, v1.Spec.TargetModels)
+	}
+
+	// Right after the swap, the previous model still receives nearly all the traffic.
+	ds.ModelSetIfOlder(v2)
+	previous := 0
+	for i := 0; i < 100; i++ {
+		if server.rolloutModel("chat", v2) == v1 {
+			previous++
+		}
+	}
+	if previous < 95 {
+		t.Errorf("Expected the previous model to receive most requests right after the change, got %d of 100", previous)
+	}
+
+	// Without a rollout window, the switch is immediate.
+	server = NewStreamingServer(nil, "", "", ds, 0)
+	if got := server.rolloutModel("chat", v2); got != v2 {
+		t.Errorf("Unexpected model without a rollout window, got %v, want %v", got.Spec.TargetModels, v2.Spec.TargetModels)
+	}
+}
+
+func TestIsCritical(t *testing.T) {
+	critical := v1alpha2.Critical
+	sheddable := v1alpha2.Sheddable
+	tests := []struct {
+		name        string
+		model       *v1alpha2.InferenceModel
+		poolDefault *v1alpha2.Criticality
+		want        bool
+	}{
+		{
+			name:  "model criticality without pool default",
+			model: &v1alpha2.InferenceModel{Spec: v1alpha2.InferenceModelSpec{Criticality: &critical}},
+			want:  true,
+		},
+		{
+			name:        "model criticality takes precedence over pool default",
+			model:       &v1alpha2.InferenceModel{Spec: v1alpha2.InferenceModelSpec{Criticality: &sheddable}},
+			poolDefault: &critical,
+			want:        false,
+		},
+		{
+			name:        "pool default applies to models without criticality",
+			model:       &v1alpha2.InferenceModel{},
+			poolDefault: &critical,
+			want:        true,
+		},
+		{
+			name:  "neither model nor pool set the criticality",
+			model: &v1alpha2.InferenceModel{},
+			want:  false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isCritical(test.model, test.poolDefault); got != test.want {
+				t.Errorf("Unexpected criticality, got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCostMultiplier(t *testing.T) {
+	model := func(annotations map[string]string) *v1alpha2.InferenceModel {
+		return &v1alpha2.InferenceModel{ObjectMeta: metav1.ObjectMeta{Name: "model", Annotations: annotations}}
+	}
+	tests := []struct {
+		name  string
+		model *v1alpha2.InferenceModel
+		want  float64
+	}{
+		{
+			name:  "annotated model",
+			model: model(map[string]string{datastore.CostMultiplierAnnotation: "2.5"}),
+			want:  2.5,
+		},
+		{
+			name:  "missing annotation",
+			model: model(nil),
+			want:  1,
+		},
+		{
+			name:  "invalid annotation",
+			model: model(map[string]string{datastore.CostMultiplierAnnotation: "expensive"}),
+			want:  1,
+		},
+		{
+			name:  "non-positive annotation",
+			model: model(map[string]string{datastore.CostMultiplierAnnotation: "0"}),
+			want:  1,
+		},
+		{
+			name:  "NaN annotation",
+			model: model(map[string]string{datastore.CostMultiplierAnnotation: "NaN"}),
+			want:  1,
+		},
+		{
+			name:  "infinite annotation",
+			model: model(map[string]string{datastore.CostMultiplierAnnotation: "Inf"}),
+			want:  1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := costMultiplier(logutil.NewTestLogger(), test.model); got != test.want {
+				t.Errorf("Unexpected cost multiplier, got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseScorerOverrides(t *testing.T) {
+	limits := map[string]float64{"queue": 2, "kv-cache-utilization": 1}
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{
+			name:  "allow-listed scorers within limits",
+			value: "queue:2, kv-cache-utilization:0",
+			want:  map[string]float64{"queue": 2, "kv-cache-utilization": 0},
+		},
+		{
+			name:    "scorer outside the allow-list",
+			value:   "in-flight:1",
+			wantErr: true,
+		},
+		{
+			name:    "weight above the limit",
+			value:   "kv-cache-utilization:3",
+			wantErr: true,
+		},
+		{
+			name:    "negative weight",
+			value:   "queue:-1",
+			wantErr: true,
+		},
+		{
+			name:    "malformed pair",
+			value:   "queue",
+			wantErr: true,
+		},
+		{
+			name:    "pair separated by an equal sign",
+			value:   "queue=2",
+			wantErr: true,
+		},
+		{
+			name:    "NaN weight",
+			value:   "queue:NaN",
+			wantErr: true,
+		},
+		{
+			name:    "infinite weight",
+			value:   "queue:Inf",
+			wantErr: true,
+		},
+		{
+			name:    "infinite weight under an infinite limit",
+			value:   "unlimited:+Inf",
+			wantErr: true,
+		},
+	}
+	limits["unlimited"] = math.Inf(1)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseScorerOverrides(test.value, limits)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Unexpected error, got %v, want error %v", err, test.wantErr)
+			}
+			if diff := cmp.Diff(test.want, got); !test.wantErr && diff != "" {
+				t.Errorf("Unexpected overrides (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestBuildErrResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     envoyTypePb.StatusCode
+		wantRetryAfter string
+	}{
+		{
+			name:       "resource exhausted",
+			err:        errutil.Error{Code: errutil.InferencePoolResourceExhausted, Msg: "saturated"},
+			wantStatus: envoyTypePb.StatusCode_TooManyRequests,
+		},
+		{
+			name:       "resource exhausted with a status override",
+			err:        errutil.Error{Code: errutil.InferencePoolResourceExhausted, Msg: "saturated", HTTPStatus: 503},
+			wantStatus: envoyTypePb.StatusCode_ServiceUnavailable,
+		},
+		{
+			name:       "bad request",
+			err:        errutil.Error{Code: errutil.BadRequest, Msg: "invalid"},
+			wantStatus: envoyTypePb.StatusCode_BadRequest,
+		},
+		{
+			name:       "no ready endpoints",
+			err:        errutil.Error{Code: errutil.NoReadyEndpoints, Msg: "empty pool"},
+			wantStatus: envoyTypePb.StatusCode_ServiceUnavailable,
+		},
+		{
+			name:       "no ready endpoints while scaling up",
+			err:        errutil.Error{Code: errutil.NoReadyEndpoints, Msg: "empty pool", HTTPStatus: 429},
+			wantStatus: envoyTypePb.StatusCode_TooManyRequests,
+		},
+		{
+			name:       "no candidate after filter",
+			err:        errutil.Error{Code: errutil.NoCandidateAfterFilter, Msg: "all unhealthy"},
+			wantStatus: envoyTypePb.StatusCode_ServiceUnavailable,
+		},
+		{
+			name:       "scheduling timeout",
+			err:        errutil.Error{Code: errutil.SchedulingTimeout, Msg: "deadline exceeded"},
+			wantStatus: envoyTypePb.StatusCode_GatewayTimeout,
+		},
+		{
+			name:       "scheduling canceled",
+			err:        errutil.Error{Code: errutil.SchedulingCanceled, Msg: "client disconnected"},
+			wantStatus: envoyTypePb.StatusCode_ServiceUnavailable,
+		},
+		{
+			name:       "scoring failed",
+			err:        errutil.Error{Code: errutil.ScoringFailed, Msg: "scorer panicked"},
+			wantStatus: envoyTypePb.StatusCode_InternalServerError,
+		},
+		{
+			name:           "pool not ready",
+			err:            errutil.Error{Code: errutil.PoolNotReady, Msg: "not synced", RetryAfterSeconds: 1},
+			wantStatus:     envoyTypePb.StatusCode_ServiceUnavailable,
+			wantRetryAfter: "1",
+		},
+		{
+			name:           "scheduler shutting down",
+			err:            errutil.Error{Code: errutil.SchedulerShuttingDown, Msg: "shutting down", RetryAfterSeconds: 1},
+			wantStatus:     envoyTypePb.StatusCode_ServiceUnavailable,
+			wantRetryAfter: "1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := BuildErrResponse(test.err)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := resp.GetImmediateResponse().GetStatus().GetCode(); got != test.wantStatus {
+				t.Errorf("Unexpected status, got %v, want %v", got, test.wantStatus)
+			}
+			gotRetryAfter := ""
+			for _, header := range resp.GetImmediateResponse().GetHeaders().GetSetHeaders() {
+				if header.GetHeader().GetKey() == "Retry-After" {
+					gotRetryAfter = string(header.GetHeader().GetRawValue())
+				}
+			}
+			if gotRetryAfter != test.wantRetryAfter {
+				t.Errorf("Unexpected Retry-After header, got %q, want %q", gotRetryAfter, test.wantRetryAfter)
+			}
+		})
+	}
+}
+
 func pointer(v int32) *int32 {
 	return &v
 }
+
+// timeoutDatastore is a datastore with the given pool extension timeout.
+type timeoutDatastore struct {
+	datastore.Datastore
+	timeout time.Duration
+}
+
+func (ds *timeoutDatastore) PoolExtensionTimeout() time.Duration {
+	return ds.timeout
+}
+
+// deadlineScheduler records the deadline of the scheduling context, and waits for it if any.
+type deadlineScheduler struct {
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (s *deadlineScheduler) Schedule(ctx context.Context, req *schedulingtypes.LLMRequest) (*schedulingtypes.Result, error) {
+	s.deadline, s.hasDeadline = ctx.Deadline()
+	if !s.hasDeadline {
+		return &schedulingtypes.Result{}, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestScheduleWithinExtensionTimeout(t *testing.T) {
+	tests := []struct {
+		name           string
+		poolTimeout    time.Duration
+		requestTimeout time.Duration
+		wantTimeout    time.Duration
+	}{
+		{
+			name: "no timeout",
+		},
+		{
+			name:        "pool timeout",
+			poolTimeout: 50 * time.Millisecond,
+			wantTimeout: 50 * time.Millisecond,
+		},
+		{
+			name:           "tighter request deadline",
+			poolTimeout:    time.Hour,
+			requestTimeout: 20 * time.Millisecond,
+			wantTimeout:    20 * time.Millisecond,
+		},
+		{
+			name:           "tighter pool timeout",
+			poolTimeout:    20 * time.Millisecond,
+			requestTimeout: time.Hour,
+			wantTimeout:    20 * time.Millisecond,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := &deadlineScheduler{}
+			server := NewStreamingServer(scheduler, "", "", &timeoutDatastore{timeout: test.poolTimeout}, 0)
+			ctx := context.Background()
+			if test.requestTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.requestTimeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			_, err := server.schedule(ctx, &schedulingtypes.LLMRequest{Model: "model"})
+			elapsed := time.Since(start)
+			if test.wantTimeout == 0 {
+				if err != nil || scheduler.hasDeadline {
+					t.Errorf("Expected scheduling without a deadline, got error %v and deadline %v", err, scheduler.deadline)
+				}
+				return
+			}
+			if err != context.DeadlineExceeded {
+				t.Errorf("Unexpected error, got %v, want %v", err, context.DeadlineExceeded)
+			}
+			if elapsed < test.wantTimeout || elapsed > test.wantTimeout+time.Second {
+				t.Errorf("Unexpected scheduling time, got %v, want about %v", elapsed, test.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestWithTraceContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceParent string
+		wantTraceID string
+		wantSampled bool
+	}{
+		{
+			name:        "sampled trace",
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantSampled: true,
+		},
+		{
+			name:        "unsampled trace",
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:        "invalid trace parent",
+			traceParent: "invalid",
+		},
+		{
+			name: "no trace parent",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spanCtx := trace.SpanContextFromContext(withTraceContext(context.Background(), test.traceParent))
+			gotTraceID := ""
+			if spanCtx.IsValid() {
+				gotTraceID = spanCtx.TraceID().String()
+			}
+			if gotTraceID != test.wantTraceID || spanCtx.IsSampled() != test.wantSampled {
+				t.Errorf("Unexpected span context, got trace %q sampled %t, want trace %q sampled %t",
+					gotTraceID, spanCtx.IsSampled(), test.wantTraceID, test.wantSampled)
+			}
+		})
+	}
+}
+
+// requestDatastore serves a pool and a single InferenceModel to the request handlers.
+type requestDatastore struct {
+	datastore.Datastore
+	pool  *v1alpha2.InferencePool
+	model *v1alpha2.InferenceModel
+}
+
+func (ds *requestDatastore) PoolGet() (*v1alpha2.InferencePool, error) {
+	return ds.pool, nil
+}
+
+func (ds *requestDatastore) ModelGet(modelName string) *v1alpha2.InferenceModel {
+	if modelName == ds.model.Spec.ModelName {
+		return ds.model
+	}
+	return nil
+}
+
+func (ds *requestDatastore) ResolveModelName(requested string) string {
+	return requested
+}
+
+func (ds *requestDatastore) PoolDefaultCriticality() *v1alpha2.Criticality {
+	return nil
+}
+
+func (ds *requestDatastore) PoolExtensionTimeout() time.Duration {
+	return 0
+}
+
+// resultScheduler records the scheduled request and returns the given result. If set, the
+// resolved target model of the request is replaced, as when scheduling by model preferences.
+type resultScheduler struct {
+	req                 *schedulingtypes.LLMRequest
+	res                 *schedulingtypes.Result
+	resolvedTargetModel string
+}
+
+func (s *resultScheduler) Schedule(ctx context.Context, req *schedulingtypes.LLMRequest) (*schedulingtypes.Result, error) {
+	s.req = req
+	if s.resolvedTargetModel != "" {
+		req.ResolvedTargetModel = s.resolvedTargetModel
+	}
+	return s.res, nil
+}
+
+// handleRequest runs the request handlers for a request with the given headers, returning the
+// request context. The request is scheduled by the given scheduler on a pool listening on port 8000.
+func handleRequest(t *testing.T, scheduler *resultScheduler, headers map[string]string) (*RequestContext, error) {
+	t.Helper()
+	return handleModelRequest(t, scheduler, testutil.MakeInferenceModel("model").ModelName("chat").ObjRef(), headers)
+}
+
+// handleModelRequest is handleRequest for the given InferenceModel, whose model name is "chat".
+func handleModelRequest(t *testing.T, scheduler *resultScheduler, model *v1alpha2.InferenceModel, headers map[string]string) (*RequestContext, error) {
+	t.Helper()
+	ds := &requestDatastore{
+		pool:  testutil.MakeInferencePool("pool").TargetPortNumber(8000).ObjRef(),
+		model: model,
+	}
+	server := NewStreamingServer(scheduler, "envoy.lb", "x-gateway-destination-endpoint", ds, 0)
+	reqHeaders := &extProcPb.HttpHeaders{Headers: &configPb.HeaderMap{}}
+	for key, value := range headers {
+		reqHeaders.Headers.Headers = append(reqHeaders.Headers.Headers, &configPb.HeaderValue{Key: key, RawValue: []byte(value)})
+	}
+	reqCtx := &RequestContext{}
+	if err := server.HandleRequestHeaders(context.Background(), reqCtx, &extProcPb.ProcessingRequest_RequestHeaders{RequestHeaders: reqHeaders}); err != nil {
+		return reqCtx, err
+	}
+	return server.HandleRequestBody(context.Background(), reqCtx, nil, map[string]interface{}{"model": "chat", "prompt": "hello"})
+}
+
+// endpointHints returns the endpoint hints of the dynamic metadata of the request headers response.
+func endpointHints(reqCtx *RequestContext) map[string]string {
+	hints := map[string]string{}
+	for key, value := range reqCtx.reqHeaderResp.GetDynamicMetadata().GetFields()["envoy.lb"].GetStructValue().GetFields() {
+		hints[key] = value.GetStringValue()
+	}
+	return hints
+}
+
+func TestHandleRequestBackupPod(t *testing.T) {
+	target := &schedulingtypes.PodMetrics{Pod: &metrics.Pod{Address: "10.0.0.1"}, Metrics: &metrics.Metrics{}}
+	backup := &schedulingtypes.PodMetrics{Pod: &metrics.Pod{Address: "10.0.0.2"}, Metrics: &metrics.Metrics{}}
+	tests := []struct {
+		name          string
+		headers       map[string]string
+		res           *schedulingtypes.Result
+		wantErrCode   string
+		wantBackupPod bool
+		wantRedundant bool
+		wantHints     map[string]string
+	}{
+		{
+			name:      "backup not requested",
+			res:       &schedulingtypes.Result{TargetPod: target},
+			wantHints: map[string]string{"x-gateway-destination-endpoint": "10.0.0.1:8000"},
+		},
+		{
+			name:          "backup requested",
+			headers:       map[string]string{BackupPodHeader: "true"},
+			res:           &schedulingtypes.Result{TargetPod: target, BackupPod: backup},
+			wantBackupPod: true,
+			wantHints: map[string]string{
+				"x-gateway-destination-endpoint": "10.0.0.1:8000",
+				BackupEndpointHintKey:            "10.0.0.2:8000",
+			},
+		},
+		{
+			name:          "no backup available",
+			headers:       map[string]string{BackupPodHeader: "true"},
+			res:           &schedulingtypes.Result{TargetPod: target},
+			wantBackupPod: true,
+			wantHints:     map[string]string{"x-gateway-destination-endpoint": "10.0.0.1:8000"},
+		},
+		{
+			name:          "standby requested",
+			headers:       map[string]string{RedundantHeader: "true"},
+			res:           &schedulingtypes.Result{TargetPod: target, BackupPod: backup},
+			wantRedundant: true,
+			wantHints: map[string]string{
+				"x-gateway-destination-endpoint": "10.0.0.1:8000",
+				BackupEndpointHintKey:            "10.0.0.2:8000",
+			},
+		},
+		{
+			name:        "invalid header",
+			headers:     map[string]string{BackupPodHeader: "maybe"},
+			wantErrCode: errutil.BadRequest,
+		},
+		{
+			name:        "invalid redundant header",
+			headers:     map[string]string{RedundantHeader: "maybe"},
+			wantErrCode: errutil.BadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := &resultScheduler{res: test.res}
+			reqCtx, err := handleRequest(t, scheduler, test.headers)
+			if test.wantErrCode != "" {
+				if errutil.CanonicalCode(err) != test.wantErrCode {
+					t.Fatalf("Unexpected error, got %v, want code %s", err, test.wantErrCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if scheduler.req.WantBackupPod != test.wantBackupPod || scheduler.req.Redundant != test.wantRedundant {
+				t.Errorf("Unexpected WantBackupPod and Redundant, got %v and %v, want %v and %v",
+					scheduler.req.WantBackupPod, scheduler.req.Redundant, test.wantBackupPod, test.wantRedundant)
+			}
+			if diff := cmp.Diff(test.wantHints, endpointHints(reqCtx)); diff != "" {
+				t.Errorf("Unexpected endpoint hints (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleRequestPreviousNodes(t *testing.T) {
+	target := &schedulingtypes.PodMetrics{Pod: &metrics.Pod{Address: "10.0.0.1"}, Metrics: &metrics.Metrics{}}
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    []string
+	}{
+		{
+			name: "first attempt",
+		},
+		{
+			name:    "retry",
+			headers: map[string]string{PreviousNodesHeader: "node-a, node-b,,"},
+			want:    []string{"node-a", "node-b"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := &resultScheduler{res: &schedulingtypes.Result{TargetPod: target}}
+			if _, err := handleRequest(t, scheduler, test.headers); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.want, scheduler.req.PreviousNodes); diff != "" {
+				t.Errorf("Unexpected previous nodes (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestHandleRequestModelPreferences(t *testing.T) {
+	target := &schedulingtypes.PodMetrics{Pod: &metrics.Pod{Address: "10.0.0.1"}, Metrics: &metrics.Metrics{}}
+	// The InferenceModel splits the traffic between two sizes of the model.
+	model := testutil.MakeInferenceModel("model").ModelName("chat").TargetModel("chat-large").TargetModel("chat-small").ObjRef()
+	tests := []struct {
+		name                string
+		model               *v1alpha2.InferenceModel
+		headers             map[string]string
+		resolvedTargetModel string
+		wantPreferences     []string
+		wantBodyModel       string
+	}{
+		{
+			name:          "no preferences",
+			model:         testutil.MakeInferenceModel("model").ModelName("chat").ObjRef(),
+			wantBodyModel: "chat",
+		},
+		{
+			// The scheduler falls back to the second preferred model.
+			name:                "preferences",
+			model:               model,
+			headers:             map[string]string{ModelPreferencesHeader: "chat-large, chat-small"},
+			resolvedTargetModel: "chat-small",
+			wantPreferences:     []string{"chat-large", "chat-small"},
+			wantBodyModel:       "chat-small",
+		},
+		{
+			name:                "preferences outside of the InferenceModel are ignored",
+			model:               model,
+			headers:             map[string]string{ModelPreferencesHeader: "other-adapter, chat-small, chat"},
+			resolvedTargetModel: "chat-small",
+			wantPreferences:     []string{"chat-small"},
+			wantBodyModel:       "chat-small",
+		},
+		{
+			name:            "model without target models only accepts its own name",
+			model:           testutil.MakeInferenceModel("model").ModelName("chat").ObjRef(),
+			headers:         map[string]string{ModelPreferencesHeader: "other-adapter, chat"},
+			wantPreferences: []string{"chat"},
+			wantBodyModel:   "chat",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := &resultScheduler{res: &schedulingtypes.Result{TargetPod: target}, resolvedTargetModel: test.resolvedTargetModel}
+			reqCtx, err := handleModelRequest(t, scheduler, test.model, test.headers)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.wantPreferences, scheduler.req.ModelPreferences); diff != "" {
+				t.Errorf("Unexpected model preferences (-want +got): %s", diff)
+			}
+			body := map[string]interface{}{}
+			if err := json.Unmarshal(reqCtx.reqBodyResp.GetRequestBody().GetResponse().GetBodyMutation().GetStreamedResponse().GetBody(), &body); err != nil {
+				t.Fatalf("Failed to decode the request body: %v", err)
+			}
+			if body["model"] != test.wantBodyModel || reqCtx.ResolvedTargetModel != test.wantBodyModel {
+				t.Errorf("Unexpected target model, got %v in the body and %v in the context, want %v",
+					body["model"], reqCtx.ResolvedTargetModel, test.wantBodyModel)
+			}
+		})
+	}
+}
@@ -18,9 +18,13 @@ package metrics
 
 import (
 	"context"
+	"strconv"
 	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 	compbasemetrics "k8s.io/component-base/metrics"
 	"k8s.io/component-base/metrics/legacyregistry"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -191,6 +195,85 @@ var (
 		},
 		[]string{"plugin_type", "plugin_name"},
 	)
+
+	schedulerE2ELatency = compbasemetrics.NewHistogram(
+		&compbasemetrics.HistogramOpts{
+			Subsystem: EPPComponent,
+			Name:      "scheduler_e2e_duration_seconds",
+			Help:      "End-to-end scheduling latency distribution in seconds.",
+			Buckets: []float64{
+				0.0001, 0.0002, 0.0005, 0.001, 0.002, 0.005, 0.01, 0.02, 0.05, 0.1,
+			},
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+	)
+
+	SchedulerFilterInputPods = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "scheduler_filter_input_pods_total",
+			Help:           "Counter of the candidate pods entering each scheduler filter.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"filter_name"},
+	)
+
+	SchedulerFilterOutputPods = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "scheduler_filter_output_pods_total",
+			Help:           "Counter of the candidate pods kept by each scheduler filter.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"filter_name"},
+	)
+
+	schedulerPoolSaturationSheds = compbasemetrics.NewCounter(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "scheduler_pool_saturation_shed_total",
+			Help:           "Counter of sheddable requests dropped because the inference pool as a whole is saturated.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+	)
+
+	schedulerTenantFairnessSheds = compbasemetrics.NewCounter(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "scheduler_tenant_fairness_shed_total",
+			Help:           "Counter of sheddable requests dropped because their tenant exceeded its fair share of the saturated inference pool.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+	)
+
+	SchedulerDecisionReportsDropped = compbasemetrics.NewCounter(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "scheduler_decision_reports_dropped_total",
+			Help:           "Counter of scheduling decisions not reported to the analytics sink because the report buffer was full.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+	)
+
+	schedulerScorerTimeouts = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "scheduler_scorer_timeouts_total",
+			Help:           "Counter of pod scores cut off because the scorer exceeded its timeout.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"scorer_name"},
+	)
+
+	SchedulerScorerDisagreements = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Subsystem:      EPPComponent,
+			Name:           "scheduler_scorer_disagreements_total",
+			Help:           "Counter of scheduling decisions where two scorers preferred different pods that ended up with close combined scores.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"scorer_name", "other_scorer_name"},
+	)
 )
 
 var registerMetrics sync.Once
@@ -213,6 +296,14 @@ func Register() {
 		legacyregistry.MustRegister(inferencePoolReadyPods)
 
 		legacyregistry.MustRegister(SchedulerPluginProcessingLatencies)
+		legacyregistry.MustRegister(schedulerE2ELatency)
+		legacyregistry.MustRegister(SchedulerFilterInputPods)
+		legacyregistry.MustRegister(SchedulerFilterOutputPods)
+		legacyregistry.MustRegister(schedulerPoolSaturationSheds)
+		legacyregistry.MustRegister(schedulerTenantFairnessSheds)
+		legacyregistry.MustRegister(SchedulerDecisionReportsDropped)
+		legacyregistry.MustRegister(schedulerScorerTimeouts)
+		legacyregistry.MustRegister(SchedulerScorerDisagreements)
 	})
 }
 
@@ -315,3 +406,67 @@ func RecordinferencePoolReadyPods(name string, runningPods float64) {
 func RecordSchedulerPluginProcessingLatency(pluginType, pluginName string, duration time.Duration) {
 	SchedulerPluginProcessingLatencies.WithLabelValues(pluginType, pluginName).Observe(duration.Seconds())
 }
+
+// RecordSchedulerE2ELatency records the end-to-end latency of a scheduling decision. When the
+// context carries a sampled trace and a pod was selected, the trace ID, the pod and its score are
+// attached to the sample as an exemplar, to correlate the routing decisions with the traces.
+func RecordSchedulerE2ELatency(ctx context.Context, duration time.Duration, targetPod string, score float64) {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if observer, ok := schedulerE2ELatency.ObserverMetric.(prometheus.ExemplarObserver); ok && targetPod != "" && spanCtx.IsValid() && spanCtx.IsSampled() {
+		observer.ObserveWithExemplar(duration.Seconds(), schedulingExemplar(spanCtx.TraceID().String(), targetPod, score))
+		return
+	}
+	schedulerE2ELatency.Observe(duration.Seconds())
+}
+
+// schedulingExemplar returns the exemplar labels of a scheduling decision. The pod is truncated to
+// keep the labels within the length limit of the exemplars.
+func schedulingExemplar(traceID, pod string, score float64) prometheus.Labels {
+	labels := prometheus.Labels{
+		"trace_id": traceID,
+		"score":    strconv.FormatFloat(score, 'g', 6, 64),
+	}
+	budget := prometheus.ExemplarMaxRunes - len("pod")
+	for name, value := range labels {
+		budget -= utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+	if runes := []rune(pod); len(runes) > budget {
+		pod = string(runes[:max(budget, 0)])
+	}
+	labels["pod"] = pod
+	return labels
+}
+
+// RecordSchedulerFilterPods records the number of pods entering and kept by a scheduler filter.
+func RecordSchedulerFilterPods(filterName string, in, out int) {
+	SchedulerFilterInputPods.WithLabelValues(filterName).Add(float64(in))
+	SchedulerFilterOutputPods.WithLabelValues(filterName).Add(float64(out))
+}
+
+// RecordPoolSaturationShed records a sheddable request dropped because the pool is saturated.
+func RecordPoolSaturationShed() {
+	schedulerPoolSaturationSheds.Inc()
+}
+
+// RecordTenantFairnessShed records a sheddable request dropped because its tenant exceeded its fair
+// share of the saturated pool. The tenant isn't a label to keep the cardinality bounded.
+func RecordTenantFairnessShed() {
+	schedulerTenantFairnessSheds.Inc()
+}
+
+// RecordDecisionReportDropped records a scheduling decision dropped because the report buffer was
+// full.
+func RecordDecisionReportDropped() {
+	SchedulerDecisionReportsDropped.Inc()
+}
+
+// RecordScorerTimeout records a pod score cut off because the scorer exceeded its timeout.
+func RecordScorerTimeout(scorerName string) {
+	schedulerScorerTimeouts.WithLabelValues(scorerName).Inc()
+}
+
+// RecordScorerDisagreement records a scheduling decision where the two scorers preferred different
+// pods that ended up with close combined scores.
+func RecordScorerDisagreement(scorerName, otherScorerName string) {
+	SchedulerScorerDisagreements.WithLabelValues(scorerName, otherScorerName).Inc()
+}
@@ -19,9 +19,14 @@ package metrics
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/component-base/metrics/testutil"
 	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
@@ -620,3 +625,87 @@ func TestSchedulerPluginProcessingLatencies(t *testing.T) {
 		})
 	}
 }
+
+func TestSchedulerFilterPods(t *testing.T) {
+	Register()
+	RecordSchedulerFilterPods("FilterA", 10, 4)
+	RecordSchedulerFilterPods("FilterA", 5, 5)
+	RecordSchedulerFilterPods("FilterB", 3, 0)
+
+	wantFilterPods, err := os.Open("testdata/scheduler_filter_pods_metric")
+	defer func() {
+		if err := wantFilterPods.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := testutil.GatherAndCompare(legacyregistry.DefaultGatherer, wantFilterPods,
+		"endpoint_picker_scheduler_filter_input_pods_total", "endpoint_picker_scheduler_filter_output_pods_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSchedulerE2ELatencyExemplars(t *testing.T) {
+	Register()
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	traced := trace.ContextWithSpanContext(context.Background(), spanCtx)
+	unsampled := trace.ContextWithSpanContext(context.Background(), spanCtx.WithTraceFlags(0))
+
+	RecordSchedulerE2ELatency(traced, 3*time.Millisecond, "default/pod1", 0.75)
+	// Without a sampled trace or a selected pod, no exemplar is attached.
+	RecordSchedulerE2ELatency(context.Background(), 30*time.Millisecond, "default/pod2", 0.5)
+	RecordSchedulerE2ELatency(unsampled, 30*time.Millisecond, "default/pod2", 0.5)
+	RecordSchedulerE2ELatency(traced, 300*time.Microsecond, "", 0)
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather the metrics: %v", err)
+	}
+	var gotExemplars []map[string]string
+	var gotCount uint64
+	for _, family := range families {
+		if family.GetName() != EPPComponent+"_scheduler_e2e_duration_seconds" {
+			continue
+		}
+		histogram := family.GetMetric()[0].GetHistogram()
+		gotCount = histogram.GetSampleCount()
+		for _, bucket := range histogram.GetBucket() {
+			if exemplar := bucket.GetExemplar(); exemplar != nil {
+				labels := map[string]string{}
+				for _, label := range exemplar.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				gotExemplars = append(gotExemplars, labels)
+			}
+		}
+	}
+	if gotCount != 4 {
+		t.Errorf("Unexpected number of samples, got %d, want 4", gotCount)
+	}
+	wantExemplars := []map[string]string{{"trace_id": traceID.String(), "pod": "default/pod1", "score": "0.75"}}
+	if diff := cmp.Diff(wantExemplars, gotExemplars); diff != "" {
+		t.Errorf("Unexpected exemplars (-want +got): %s", diff)
+	}
+}
+
+func TestSchedulingExemplarTruncatesPod(t *testing.T) {
+	pod := "default/" + strings.Repeat("p", 200)
+	labels := schedulingExemplar(strings.Repeat("a", 32), pod, 0.123456789)
+	runes := 0
+	for name, value := range labels {
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+	if runes != prometheus.ExemplarMaxRunes {
+		t.Errorf("Unexpected exemplar length, got %d runes, want %d", runes, prometheus.ExemplarMaxRunes)
+	}
+	if !strings.HasPrefix(pod, labels["pod"]) {
+		t.Errorf("Unexpected truncated pod %q", labels["pod"])
+	}
+}
@@ -16,12 +16,54 @@ limitations under the License.
 
 package scheduling
 
-import "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+import (
+	"time"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+)
 
 type SchedulerConfig struct {
-	preSchedulePlugins  []plugins.PreSchedule
-	scorers             []plugins.Scorer
-	filters             []plugins.Filter
-	postSchedulePlugins []plugins.PostSchedule
-	picker              plugins.Picker
+	// requestPreprocessors run in order on each request before it is scheduled.
+	requestPreprocessors []plugins.RequestPreprocessor
+	preSchedulePlugins   []plugins.PreSchedule
+	scorers              []plugins.Scorer
+	filters              []plugins.Filter
+	postSchedulePlugins  []plugins.PostSchedule
+	picker               plugins.Picker
+	// criticalScorerWeights and sheddableScorerWeights map scorer names to the weight applied to
+	// their scores, depending on the request criticality. Unlisted scorers have a weight of 1.
+	criticalScorerWeights  map[string]float64
+	sheddableScorerWeights map[string]float64
+	// scoreCombination is the strategy combining the weighted scores of the scorers. Defaults to
+	// ScoreCombinationSum.
+	scoreCombination string
+	// decisionLogSampleRate logs 1 in every decisionLogSampleRate successful scheduling decisions.
+	// Failed decisions are always logged, but identical errors are logged at most once every
+	// errorLogInterval.
+	decisionLogSampleRate int
+	errorLogInterval      time.Duration
+	// pinSingleReplicaModels schedules requests for models a single pod can serve among that pod
+	// alone.
+	pinSingleReplicaModels bool
+	// tieBreaker, if set, chooses among the pods sharing the top score before picking.
+	tieBreaker plugins.Scorer
+	// freshnessDecay, if set, discounts the scores of the pods as their metrics age.
+	freshnessDecay *freshnessDecay
+	// decisionReporter, if set, receives every scheduling decision.
+	decisionReporter DecisionReporter
+	// scorerTimeouts maps scorer names to the time they have to score all the pods before they are
+	// left out of the scores.
+	scorerTimeouts map[string]time.Duration
+	// poolConcurrencyLimit caps the number of requests in flight across the pool. When it is
+	// reached, critical requests wait for up to poolQueueTimeout and sheddable requests are
+	// dropped. 0 disables the limit.
+	poolConcurrencyLimit int
+	poolQueueTimeout     time.Duration
+	// poolSyncGrace is the time a request received before the pool is synced waits for the sync
+	// before it is rejected. 0 rejects it right away.
+	poolSyncGrace time.Duration
+	// minCandidatePods is the number of pods the filters must leave for scoring. If fewer pods
+	// pass the filters, the dropped pods of the last filter that dropped any are re-added. 0
+	// disables the minimum.
+	minCandidatePods int
 }
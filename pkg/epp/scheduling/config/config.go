@@ -17,6 +17,11 @@ limitations under the License.
 package config
 
 import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	envutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/env"
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
@@ -28,6 +33,140 @@ type Config struct {
 	QueueThresholdCritical int
 	QueueingThresholdLoRA  int
 	LoraAffinityThreshold  float64
+	// Scorers lists the names of the registered scorers the scheduler instantiates.
+	Scorers []string
+	// CriticalScorerWeights and SheddableScorerWeights are the scorer weight profiles applied to
+	// critical and sheddable requests, respectively. Scorers without a weight have a weight of 1.
+	CriticalScorerWeights  map[string]float64
+	SheddableScorerWeights map[string]float64
+	// InFlightWindowSeconds is the window over which the in-flight reservations of a pod decay.
+	InFlightWindowSeconds float64
+	// InFlightCostUnit weights the in-flight reservations by the estimated cost of the requests,
+	// in units of InFlightCostUnit prompt tokens. 0 counts every request as one.
+	InFlightCostUnit float64
+	// PrefillBoundPromptTokens is the number of prompt tokens from which the "queue-split" scorer
+	// considers a request prefill-bound rather than decode-bound. 0 considers all requests
+	// prefill-bound.
+	PrefillBoundPromptTokens int
+	// SaturatedStatusCode and ScalingUpStatusCode are the HTTP status codes returned when no pod
+	// can serve a request, while the pool is saturated or while it is scaling up, respectively.
+	// E.g. 503 signals that there is no capacity, while 429 asks the client to try again later.
+	SaturatedStatusCode int
+	ScalingUpStatusCode int
+	// ExpensiveRequestCost is the request cost from which requests are spread across pods rather
+	// than packed onto fewer pods.
+	ExpensiveRequestCost float64
+	// PickerStickinessMargin is the score margin by which a pod must beat the pod most recently
+	// chosen for the same model to replace it. 0 disables stickiness.
+	PickerStickinessMargin float64
+	// ScoreCombination is the strategy combining the weighted scores of the scorers, one of
+	// "sum", "product", "max" or "min".
+	ScoreCombination string
+	// ScorerOverrideLimits allow-lists the scorers whose weight can be overridden per request and
+	// maps them to the maximum weight a request can set.
+	ScorerOverrideLimits map[string]float64
+	// DecisionLogSampleRate logs 1 in every DecisionLogSampleRate successful scheduling decisions.
+	// Failed decisions are always logged. 0 disables the logging of successful decisions.
+	DecisionLogSampleRate int
+	// Picker overrides the picker of the scheduler. The supported values are "capacity", which
+	// picks pods proportionally to their remaining capacity, and "weighted-round-robin", which
+	// distributes requests in proportion to the static weight in the PickerWeightLabel pod label.
+	Picker string
+	// PickerWeightLabel is the pod label holding the weight of the pod for the weighted round-robin
+	// picker. It must be listed in the captured pod label keys.
+	PickerWeightLabel string
+	// PoolSaturationThreshold is the average KV cache utilization of the pool above which sheddable
+	// requests are dropped before the per-pod filters run. 0 disables the check.
+	PoolSaturationThreshold float64
+	// TenantFairnessThreshold is the average KV cache utilization of the pool above which the
+	// sheddable requests of the tenants exceeding their share of the recent requests are dropped.
+	// It should be lower than PoolSaturationThreshold, which drops all sheddable requests. 0
+	// disables the fairness.
+	TenantFairnessThreshold float64
+	// TenantFairnessWindowSeconds is the time over which the recent requests of the tenants decay.
+	TenantFairnessWindowSeconds float64
+	// TenantShares maps tenant IDs to their relative share of the pool. Unlisted tenants have a
+	// share of 1.
+	TenantShares map[string]float64
+	// DecisionReportURL is the URL the scheduling decisions are posted to in batches, for
+	// capacity planning. Empty disables the reporting.
+	DecisionReportURL string
+	// DecisionReportBufferSize is the number of decisions buffered while a batch is being sent.
+	// Decisions are dropped when the buffer is full.
+	DecisionReportBufferSize int
+	// DecisionReportBatchSize is the maximum number of decisions per batch.
+	DecisionReportBatchSize int
+	// DecisionReportIntervalSeconds is the time after which a partial batch is sent.
+	DecisionReportIntervalSeconds float64
+	// PinSingleReplicaModels schedules requests for a model that a single pod can serve, i.e. the
+	// only pod that has the adapter loaded or room to load it, among that pod alone. The filters
+	// still apply, but the request fails rather than going to a pod that can't serve the model.
+	PinSingleReplicaModels bool
+	// TieBreakScorer is the name of a registered scorer that chooses among the pods sharing the
+	// top score, e.g. "lowest-address". Empty leaves ties to the picker.
+	TieBreakScorer string
+	// MetricsFreshnessMaxAgeSeconds is the metrics age from which the scores of a pod are fully
+	// discounted. Younger metrics are discounted following MetricsFreshnessDecayCurve, "linear" or
+	// "quadratic", once older than MetricsFreshnessGraceSeconds. 0 disables the discount.
+	MetricsFreshnessMaxAgeSeconds float64
+	MetricsFreshnessGraceSeconds  float64
+	MetricsFreshnessDecayCurve    string
+	// ExperimentPercentage is the percentage of the experiment keys, set by request header, routed
+	// to the pods matching ExperimentPodSelector, a "key=value" pod label. The label key must be
+	// one of the pod label keys made available to the scheduler. 0 disables experiment routing.
+	ExperimentPercentage  float64
+	ExperimentPodSelector string
+	// ScorerTimeoutsSeconds maps scorer names to the time a scorer has to score all the pods, after
+	// which the scorer is left out of the scores of the request. Scorers without a timeout aren't
+	// cut off. A timed scorer must stop when its context is done: until a call that timed out
+	// returns, the scorer is left out of the scores of the next requests.
+	ScorerTimeoutsSeconds map[string]float64
+	// RTTProbePort is the pod port the "rtt" scorer opens TCP connections to in order to measure
+	// the round-trip time to the pods, every RTTRefreshIntervalSeconds.
+	RTTProbePort              int
+	RTTRefreshIntervalSeconds float64
+	// ThroughputWindowSeconds is the time over which the throughput measurements of the
+	// "throughput" scorer decay.
+	ThroughputWindowSeconds float64
+	// UptimeWarmUpSeconds is the uptime over which the "uptime" scorer ramps up its preference for
+	// a pod for cache-sensitive requests, i.e. those with at least UptimeCacheSensitiveTokens tokens.
+	UptimeWarmUpSeconds float64
+	// UptimeCacheSensitiveTokens is the number of prompt tokens from which the "uptime" scorer
+	// considers a request cache-sensitive.
+	UptimeCacheSensitiveTokens int
+	// MaintenanceAnnotation is the pod annotation marking the pods in maintenance, which don't get
+	// new requests unless all pods are in maintenance. Empty disables the check.
+	MaintenanceAnnotation string
+	// ErrorLogIntervalSeconds is the interval at which a scheduling error that repeats identically
+	// is logged, along with the number of its occurrences suppressed in between. An error that
+	// differs from the previous one is logged immediately. 0 logs every error.
+	ErrorLogIntervalSeconds float64
+	// PoolConcurrencyLimit caps the number of requests in flight across the whole pool, from the
+	// time they are scheduled until they complete. When it is reached, sheddable requests are
+	// dropped and critical requests wait up to PoolQueueTimeoutSeconds for a request to complete.
+	// 0 disables the limit.
+	PoolConcurrencyLimit    int
+	PoolQueueTimeoutSeconds float64
+	// PoolSyncGraceSeconds is the time a request received before the pool is synced waits for the
+	// sync before it is rejected as not ready. 0 rejects it right away.
+	PoolSyncGraceSeconds float64
+	// LoraSwapExpensiveSeconds enables the tuning of LoraAffinityThreshold from the adapter swap
+	// latency reported by the pods. At an average latency of LoraSwapExpensiveSeconds or more, the
+	// threshold is raised to LoraAffinityMaxThreshold; at LoraSwapCheapSeconds or less, it is
+	// relaxed to LoraAffinityMinThreshold. 0 keeps the threshold static.
+	LoraSwapExpensiveSeconds float64
+	LoraSwapCheapSeconds     float64
+	LoraAffinityMinThreshold float64
+	LoraAffinityMaxThreshold float64
+	// LargePromptTokens is the number of prompt tokens from which a sheddable request requires a
+	// free KV cache margin above the one of KVCacheThreshold, growing with the size of the prompt
+	// up to half of the KV cache, but never below the standard margin. 0 applies the standard
+	// threshold to all requests.
+	LargePromptTokens int
+	// MinCandidatePods is the number of pods the filters must leave for scoring. If fewer pods,
+	// but at least one, pass the filters, the least loaded pods dropped by the last filter are
+	// re-added to reach it, with a lower score than the passing pods. 0 disables the minimum.
+	MinCandidatePods int
 }
 
 const (
@@ -36,6 +175,51 @@ const (
 	defaultQueueThresholdCritical = 5
 	defaultQueueingThresholdLoRA  = 128
 	defaultLoraAffinityThreshold  = 0.999
+	defaultScorers                = ""
+	defaultScorerWeights          = ""
+	defaultInFlightWindowSeconds  = 1.0
+	defaultInFlightCostUnit       = 0
+	defaultPrefillBoundTokens     = 0
+	defaultSaturatedStatusCode    = 429
+	defaultScalingUpStatusCode    = 429
+	defaultExpensiveRequestCost   = 1024
+	defaultStickinessMargin       = 0
+	defaultScoreCombination       = "sum"
+	defaultDecisionLogSampleRate  = 0
+	defaultPicker                 = ""
+	defaultPickerWeightLabel      = "capacity"
+	defaultPoolSaturation         = 0
+	defaultTenantFairness         = 0
+	defaultTenantFairnessWindow   = 60
+	defaultTenantShares           = ""
+	defaultDecisionReportURL      = ""
+	defaultDecisionReportBuffer   = 10000
+	defaultDecisionReportBatch    = 100
+	defaultDecisionReportFlush    = 5
+	defaultPinSingleReplicaModels = "false"
+	defaultTieBreakScorer         = ""
+	defaultFreshnessMaxAge        = 0
+	defaultFreshnessGrace         = 0
+	defaultFreshnessDecayCurve    = "linear"
+	defaultExperimentPercentage   = 0
+	defaultExperimentPodSelector  = ""
+	defaultScorerTimeouts         = ""
+	defaultRTTProbePort           = 8000
+	defaultRTTRefreshInterval     = 10
+	defaultThroughputWindow       = 30
+	defaultMaintenanceAnnotation  = ""
+	defaultUptimeWarmUp           = 300
+	defaultUptimeCacheTokens      = 512
+	defaultErrorLogInterval       = 10
+	defaultPoolConcurrencyLimit   = 0
+	defaultPoolQueueTimeout       = 1
+	defaultPoolSyncGrace          = 0
+	defaultLoraSwapExpensive      = 0
+	defaultLoraSwapCheap          = 0.1
+	defaultLoraAffinityMin        = 0.9
+	defaultLoraAffinityMax        = 0.9999
+	defaultLargePromptTokens      = 0
+	defaultMinCandidatePods       = 0
 )
 
 // LoadConfig loads configuration from environment variables
@@ -44,10 +228,57 @@ func LoadConfig() Config {
 	baseLogger := log.Log.WithName("scheduling-config")
 
 	config := Config{
-		KVCacheThreshold:       envutil.GetEnvFloat("KV_CACHE_THRESHOLD", defaultKVCacheThreshold, baseLogger),
-		QueueThresholdCritical: envutil.GetEnvInt("QUEUE_THRESHOLD_CRITICAL", defaultQueueThresholdCritical, baseLogger),
-		QueueingThresholdLoRA:  envutil.GetEnvInt("QUEUING_THRESHOLD_LORA", defaultQueueingThresholdLoRA, baseLogger),
-		LoraAffinityThreshold:  envutil.GetEnvFloat("LORA_AFFINITY_THRESHOLD", defaultLoraAffinityThreshold, baseLogger),
+		KVCacheThreshold:              envutil.GetEnvFloat("KV_CACHE_THRESHOLD", defaultKVCacheThreshold, baseLogger),
+		QueueThresholdCritical:        envutil.GetEnvInt("QUEUE_THRESHOLD_CRITICAL", defaultQueueThresholdCritical, baseLogger),
+		QueueingThresholdLoRA:         envutil.GetEnvInt("QUEUING_THRESHOLD_LORA", defaultQueueingThresholdLoRA, baseLogger),
+		LoraAffinityThreshold:         envutil.GetEnvFloat("LORA_AFFINITY_THRESHOLD", defaultLoraAffinityThreshold, baseLogger),
+		Scorers:                       ParseList(envutil.GetEnvString("SCORERS", defaultScorers, baseLogger)),
+		CriticalScorerWeights:         parseWeights(envutil.GetEnvString("CRITICAL_SCORER_WEIGHTS", defaultScorerWeights, baseLogger), baseLogger),
+		SheddableScorerWeights:        parseWeights(envutil.GetEnvString("SHEDDABLE_SCORER_WEIGHTS", defaultScorerWeights, baseLogger), baseLogger),
+		InFlightWindowSeconds:         envutil.GetEnvFloat("IN_FLIGHT_WINDOW_SECONDS", defaultInFlightWindowSeconds, baseLogger),
+		InFlightCostUnit:              envutil.GetEnvFloat("IN_FLIGHT_COST_UNIT", defaultInFlightCostUnit, baseLogger),
+		PrefillBoundPromptTokens:      envutil.GetEnvInt("PREFILL_BOUND_PROMPT_TOKENS", defaultPrefillBoundTokens, baseLogger),
+		SaturatedStatusCode:           envutil.GetEnvInt("SATURATED_STATUS_CODE", defaultSaturatedStatusCode, baseLogger),
+		ScalingUpStatusCode:           envutil.GetEnvInt("SCALING_UP_STATUS_CODE", defaultScalingUpStatusCode, baseLogger),
+		ExpensiveRequestCost:          envutil.GetEnvFloat("EXPENSIVE_REQUEST_COST", defaultExpensiveRequestCost, baseLogger),
+		PickerStickinessMargin:        envutil.GetEnvFloat("PICKER_STICKINESS_MARGIN", defaultStickinessMargin, baseLogger),
+		ScoreCombination:              envutil.GetEnvString("SCORE_COMBINATION", defaultScoreCombination, baseLogger),
+		ScorerOverrideLimits:          parseWeights(envutil.GetEnvString("SCORER_OVERRIDE_LIMITS", defaultScorerWeights, baseLogger), baseLogger),
+		DecisionLogSampleRate:         envutil.GetEnvInt("DECISION_LOG_SAMPLE_RATE", defaultDecisionLogSampleRate, baseLogger),
+		Picker:                        envutil.GetEnvString("PICKER", defaultPicker, baseLogger),
+		PickerWeightLabel:             envutil.GetEnvString("PICKER_WEIGHT_LABEL", defaultPickerWeightLabel, baseLogger),
+		PoolSaturationThreshold:       envutil.GetEnvFloat("POOL_SATURATION_THRESHOLD", defaultPoolSaturation, baseLogger),
+		TenantFairnessThreshold:       envutil.GetEnvFloat("TENANT_FAIRNESS_THRESHOLD", defaultTenantFairness, baseLogger),
+		TenantFairnessWindowSeconds:   envutil.GetEnvFloat("TENANT_FAIRNESS_WINDOW_SECONDS", defaultTenantFairnessWindow, baseLogger),
+		TenantShares:                  parseWeights(envutil.GetEnvString("TENANT_SHARES", defaultTenantShares, baseLogger), baseLogger),
+		DecisionReportURL:             envutil.GetEnvString("DECISION_REPORT_URL", defaultDecisionReportURL, baseLogger),
+		DecisionReportBufferSize:      envutil.GetEnvInt("DECISION_REPORT_BUFFER_SIZE", defaultDecisionReportBuffer, baseLogger),
+		DecisionReportBatchSize:       envutil.GetEnvInt("DECISION_REPORT_BATCH_SIZE", defaultDecisionReportBatch, baseLogger),
+		DecisionReportIntervalSeconds: envutil.GetEnvFloat("DECISION_REPORT_FLUSH_INTERVAL_SECONDS", defaultDecisionReportFlush, baseLogger),
+		TieBreakScorer:                envutil.GetEnvString("TIE_BREAK_SCORER", defaultTieBreakScorer, baseLogger),
+		MetricsFreshnessMaxAgeSeconds: envutil.GetEnvFloat("METRICS_FRESHNESS_MAX_AGE_SECONDS", defaultFreshnessMaxAge, baseLogger),
+		MetricsFreshnessGraceSeconds:  envutil.GetEnvFloat("METRICS_FRESHNESS_GRACE_SECONDS", defaultFreshnessGrace, baseLogger),
+		MetricsFreshnessDecayCurve:    envutil.GetEnvString("METRICS_FRESHNESS_DECAY_CURVE", defaultFreshnessDecayCurve, baseLogger),
+		ExperimentPercentage:          envutil.GetEnvFloat("EXPERIMENT_PERCENTAGE", defaultExperimentPercentage, baseLogger),
+		ExperimentPodSelector:         envutil.GetEnvString("EXPERIMENT_POD_SELECTOR", defaultExperimentPodSelector, baseLogger),
+		ScorerTimeoutsSeconds:         parseWeights(envutil.GetEnvString("SCORER_TIMEOUTS_SECONDS", defaultScorerTimeouts, baseLogger), baseLogger),
+		RTTProbePort:                  envutil.GetEnvInt("RTT_PROBE_PORT", defaultRTTProbePort, baseLogger),
+		RTTRefreshIntervalSeconds:     envutil.GetEnvFloat("RTT_REFRESH_INTERVAL_SECONDS", defaultRTTRefreshInterval, baseLogger),
+		ThroughputWindowSeconds:       envutil.GetEnvFloat("THROUGHPUT_WINDOW_SECONDS", defaultThroughputWindow, baseLogger),
+		UptimeWarmUpSeconds:           envutil.GetEnvFloat("UPTIME_WARM_UP_SECONDS", defaultUptimeWarmUp, baseLogger),
+		UptimeCacheSensitiveTokens:    envutil.GetEnvInt("UPTIME_CACHE_SENSITIVE_PROMPT_TOKENS", defaultUptimeCacheTokens, baseLogger),
+		MaintenanceAnnotation:         envutil.GetEnvString("MAINTENANCE_ANNOTATION", defaultMaintenanceAnnotation, baseLogger),
+		ErrorLogIntervalSeconds:       envutil.GetEnvFloat("SCHEDULING_ERROR_LOG_INTERVAL_SECONDS", defaultErrorLogInterval, baseLogger),
+		PoolConcurrencyLimit:          envutil.GetEnvInt("POOL_CONCURRENCY_LIMIT", defaultPoolConcurrencyLimit, baseLogger),
+		PoolQueueTimeoutSeconds:       envutil.GetEnvFloat("POOL_QUEUE_TIMEOUT_SECONDS", defaultPoolQueueTimeout, baseLogger),
+		PoolSyncGraceSeconds:          envutil.GetEnvFloat("POOL_SYNC_GRACE_SECONDS", defaultPoolSyncGrace, baseLogger),
+		LoraSwapExpensiveSeconds:      envutil.GetEnvFloat("LORA_SWAP_EXPENSIVE_SECONDS", defaultLoraSwapExpensive, baseLogger),
+		LoraSwapCheapSeconds:          envutil.GetEnvFloat("LORA_SWAP_CHEAP_SECONDS", defaultLoraSwapCheap, baseLogger),
+		LoraAffinityMinThreshold:      envutil.GetEnvFloat("LORA_AFFINITY_MIN_THRESHOLD", defaultLoraAffinityMin, baseLogger),
+		LoraAffinityMaxThreshold:      envutil.GetEnvFloat("LORA_AFFINITY_MAX_THRESHOLD", defaultLoraAffinityMax, baseLogger),
+		LargePromptTokens:             envutil.GetEnvInt("LARGE_PROMPT_TOKENS", defaultLargePromptTokens, baseLogger),
+		MinCandidatePods:              envutil.GetEnvInt("MIN_CANDIDATE_PODS", defaultMinCandidatePods, baseLogger),
+		PinSingleReplicaModels:        parseBool(envutil.GetEnvString("PIN_SINGLE_REPLICA_MODELS", defaultPinSingleReplicaModels, baseLogger), baseLogger),
 	}
 
 	baseLogger.V(logutil.DEFAULT).Info("Scheduler configuration loaded", "config", config)
@@ -55,4 +286,49 @@ func LoadConfig() Config {
 	return config
 }
 
+// ParseList splits a comma separated list, dropping empty entries.
+func ParseList(val string) []string {
+	res := []string{}
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// parseWeights parses a comma separated list of name:weight pairs. Invalid pairs, including
+// weights that are not finite non-negative numbers, are skipped.
+func parseWeights(val string, logger logr.Logger) map[string]float64 {
+	res := map[string]float64{}
+	for _, item := range ParseList(val) {
+		name, weight, found := strings.Cut(item, ":")
+		if !found {
+			logger.V(logutil.DEFAULT).Info("Skipping weight without a value", "item", item)
+			continue
+		}
+		w, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil {
+			logger.V(logutil.DEFAULT).Info("Skipping weight that is not a number", "item", item, "error", err)
+			continue
+		}
+		if math.IsNaN(w) || math.IsInf(w, 0) || w < 0 {
+			logger.V(logutil.DEFAULT).Info("Skipping weight that is not a finite non-negative number", "item", item)
+			continue
+		}
+		res[strings.TrimSpace(name)] = w
+	}
+	return res
+}
+
+// parseBool parses a boolean, falling back to false if the value is invalid.
+func parseBool(val string, logger logr.Logger) bool {
+	b, err := strconv.ParseBool(strings.TrimSpace(val))
+	if err != nil {
+		logger.V(logutil.DEFAULT).Info("Invalid boolean, using false", "value", val, "error", err)
+		return false
+	}
+	return b
+}
+
 var Conf = LoadConfig()
@@ -0,0 +1,62 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseWeights(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]float64
+	}{
+		{
+			name:  "valid weights",
+			input: "kvcache:2, queue:0.5,lora:0",
+			want:  map[string]float64{"kvcache": 2, "queue": 0.5, "lora": 0},
+		},
+		{
+			name:  "malformed items are skipped",
+			input: "kvcache,queue:abc,lora:1",
+			want:  map[string]float64{"lora": 1},
+		},
+		{
+			name:  "non-finite and negative weights are skipped",
+			input: "a:NaN,b:Inf,c:-Inf,d:-1,e:+Inf,f:3",
+			want:  map[string]float64{"f": 3},
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  map[string]float64{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseWeights(test.input, testr.New(t))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
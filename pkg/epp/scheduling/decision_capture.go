@@ -0,0 +1,212 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// CapturedDecision holds the inputs of a scheduling decision, the request and the pods it was
+// scheduled among, along with its outcome, so that it can be replayed against another scheduler
+// build. The prompt of the request is redacted.
+type CapturedDecision struct {
+	Request   types.LLMRequest `json:"request"`
+	Pods      []CapturedPod    `json:"pods"`
+	ScalingUp bool             `json:"scalingUp,omitempty"`
+	// CapturedAt is the time the decision was made at, against which the age of the pod metrics
+	// is measured when it is replayed. The metrics of the decisions captured without it are
+	// considered fresh.
+	CapturedAt time.Time `json:"capturedAt"`
+	// TargetPod is the pod the request was scheduled on, unset if it failed.
+	TargetPod string `json:"targetPod,omitempty"`
+	// ErrorCode is the canonical code of the scheduling error, unset if it succeeded.
+	ErrorCode string `json:"errorCode,omitempty"`
+}
+
+type CapturedPod struct {
+	Pod       *backendmetrics.Pod     `json:"pod"`
+	Metrics   *backendmetrics.Metrics `json:"metrics"`
+	Unhealthy bool                    `json:"unhealthy,omitempty"`
+}
+
+// newCapturedDecision snapshots the request and the pods of the datastore at the given time. The
+// request is copied before it is preprocessed, so that replaying it runs the same preprocessing.
+func newCapturedDecision(datastore Datastore, req *types.LLMRequest, now time.Time) *CapturedDecision {
+	decision := &CapturedDecision{Request: *req, ScalingUp: datastore.PoolIsScalingUp(), CapturedAt: now}
+	decision.Request.Prompt = ""
+	for _, pm := range datastore.PodGetAll() {
+		pod := pm.GetPod().Clone()
+		decision.Pods = append(decision.Pods, CapturedPod{
+			Pod:       pod,
+			Metrics:   pm.GetMetrics().Clone(),
+			Unhealthy: datastore.PodIsUnhealthy(pod.NamespacedName),
+		})
+	}
+	return decision
+}
+
+func (d *CapturedDecision) setOutcome(res *types.Result, err error) {
+	if err != nil {
+		d.ErrorCode = errutil.CanonicalCode(err)
+		return
+	}
+	d.TargetPod = res.TargetPod.GetPod().NamespacedName.String()
+}
+
+// snapshotDatastore serves the pods of a captured decision to the scheduler, in place of the
+// datastore. The pool is always synced.
+type snapshotDatastore struct {
+	pods      []backendmetrics.PodMetrics
+	unhealthy map[k8stypes.NamespacedName]bool
+	scalingUp bool
+}
+
+func (d *snapshotDatastore) load(decision *CapturedDecision) {
+	d.pods = make([]backendmetrics.PodMetrics, 0, len(decision.Pods))
+	d.unhealthy = make(map[k8stypes.NamespacedName]bool)
+	for _, pod := range decision.Pods {
+		d.pods = append(d.pods, &backendmetrics.FakePodMetrics{Pod: pod.Pod, Metrics: pod.Metrics})
+		if pod.Unhealthy {
+			d.unhealthy[pod.Pod.NamespacedName] = true
+		}
+	}
+	d.scalingUp = decision.ScalingUp
+}
+
+func (d *snapshotDatastore) PoolHasSynced() bool {
+	return true
+}
+
+func (d *snapshotDatastore) PodGetAll() []backendmetrics.PodMetrics {
+	return d.pods
+}
+
+func (d *snapshotDatastore) PodIsUnhealthy(namespacedName k8stypes.NamespacedName) bool {
+	return d.unhealthy[namespacedName]
+}
+
+func (d *snapshotDatastore) PoolIsScalingUp() bool {
+	return d.scalingUp
+}
+
+// fixedClock is a clock stopped at the given time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func (c fixedClock) Since(t time.Time) time.Duration {
+	return time.Time(c).Sub(t)
+}
+
+// DecisionCapture writes the scheduling decisions to a writer, one JSON encoded CapturedDecision
+// per line, to reproduce routing issues with ReplayDecisions.
+type DecisionCapture struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewDecisionCapture(w io.Writer) *DecisionCapture {
+	return &DecisionCapture{encoder: json.NewEncoder(w)}
+}
+
+func (c *DecisionCapture) record(logger logr.Logger, decision *CapturedDecision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.encoder.Encode(decision); err != nil {
+		logger.V(logutil.DEFAULT).Error(err, "Failed to capture a scheduling decision")
+	}
+}
+
+// ReadCapturedDecisions reads the decisions written by a DecisionCapture.
+func ReadCapturedDecisions(r io.Reader) ([]CapturedDecision, error) {
+	var decisions []CapturedDecision
+	scanner := bufio.NewScanner(r)
+	// The pod snapshots of large pools don't fit in the default buffer.
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var decision CapturedDecision
+		if err := json.Unmarshal(scanner.Bytes(), &decision); err != nil {
+			return nil, fmt.Errorf("failed to decode the captured decision on line %d: %w", line, err)
+		}
+		decisions = append(decisions, decision)
+	}
+	return decisions, scanner.Err()
+}
+
+// DecisionChange is a captured decision whose outcome differs when it is replayed.
+type DecisionChange struct {
+	// Index is the index of the decision in the replayed sequence.
+	Index             int    `json:"index"`
+	Model             string `json:"model"`
+	CapturedTargetPod string `json:"capturedTargetPod,omitempty"`
+	ReplayedTargetPod string `json:"replayedTargetPod,omitempty"`
+	CapturedErrorCode string `json:"capturedErrorCode,omitempty"`
+	ReplayedErrorCode string `json:"replayedErrorCode,omitempty"`
+}
+
+// ReplayDecisions schedules the captured requests in order with the scheduler returned by
+// newScheduler, each on the pods it was captured with, and returns the decisions whose outcome
+// changed. The same scheduler replays the whole sequence, so that stateful plugins see the
+// requests in the captured order. The metrics freshness decay measures the age of the metrics at
+// the time each decision was captured, so old captures replay the same.
+func ReplayDecisions(ctx context.Context, decisions []CapturedDecision, newScheduler func(Datastore) *Scheduler) []DecisionChange {
+	datastore := &snapshotDatastore{}
+	scheduler := newScheduler(datastore)
+	var changes []DecisionChange
+	for i := range decisions {
+		captured := &decisions[i]
+		datastore.load(captured)
+		scheduler.clock = fixedClock(captured.CapturedAt)
+		req := captured.Request
+		res, err := scheduler.Schedule(ctx, &req)
+		replayed := &CapturedDecision{}
+		replayed.setOutcome(res, err)
+		if res != nil && res.Release != nil {
+			res.Release()
+		}
+		if replayed.TargetPod == captured.TargetPod && replayed.ErrorCode == captured.ErrorCode {
+			continue
+		}
+		changes = append(changes, DecisionChange{
+			Index:             i,
+			Model:             captured.Request.Model,
+			CapturedTargetPod: captured.TargetPod,
+			ReplayedTargetPod: replayed.TargetPod,
+			CapturedErrorCode: captured.ErrorCode,
+			ReplayedErrorCode: replayed.ErrorCode,
+		})
+	}
+	return changes
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
+)
+
+// queueScorer prefers the pods with the shortest waiting queue, or the longest one if inverted,
+// so that the decisions only depend on the captured metrics.
+type queueScorer struct {
+	inverted bool
+}
+
+func (s *queueScorer) Name() string {
+	return "queue"
+}
+
+func (s *queueScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	score := 1 / float64(1+pod.GetMetrics().WaitingQueueSize)
+	if s.inverted {
+		return 1 - score
+	}
+	return score
+}
+
+func TestCaptureAndReplayDecisions(t *testing.T) {
+	pod1 := &backendmetrics.FakePodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod1"}},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: 1},
+	}
+	pod2 := &backendmetrics.FakePodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod2"}},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: 3},
+	}
+	newScheduler := func(scorer *queueScorer) func(Datastore) *Scheduler {
+		return func(datastore Datastore) *Scheduler {
+			return NewSchedulerWithConfig(datastore, &SchedulerConfig{
+				scorers: []plugins.Scorer{scorer},
+				picker:  &picker.MaxScorePicker{},
+			})
+		}
+	}
+
+	var captured bytes.Buffer
+	datastore := &fakeDataStore{pods: []*backendmetrics.FakePodMetrics{pod1, pod2}}
+	scheduler := newScheduler(&queueScorer{})(datastore).WithDecisionCapture(NewDecisionCapture(&captured))
+	schedule := func() {
+		t.Helper()
+		req := &types.LLMRequest{Model: "test-model", ResolvedTargetModel: "test-model", Prompt: "secret prompt", PromptTokens: 3}
+		if _, err := scheduler.Schedule(context.Background(), req); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	schedule()
+	// The routing flips to pod2 as pod1 gets loaded.
+	pod1.Metrics = &backendmetrics.Metrics{WaitingQueueSize: 5}
+	schedule()
+	// No pod is left to schedule on.
+	datastore.pods = nil
+	if _, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"}); err == nil {
+		t.Fatalf("Expected an error scheduling without pods")
+	}
+
+	if strings.Contains(captured.String(), "secret prompt") {
+		t.Errorf("The capture exposes the prompt: %s", captured.String())
+	}
+	decisions, err := ReadCapturedDecisions(&captured)
+	if err != nil {
+		t.Fatalf("Failed to read the captured decisions: %v", err)
+	}
+	var gotOutcomes []string
+	for _, decision := range decisions {
+		gotOutcomes = append(gotOutcomes, decision.TargetPod+decision.ErrorCode)
+	}
+	if diff := cmp.Diff([]string{"default/pod1", "default/pod2", errutil.NoReadyEndpoints}, gotOutcomes); diff != "" {
+		t.Errorf("Unexpected captured outcomes (-want +got): %s", diff)
+	}
+	if got := decisions[0].Request.PromptTokens; got != 3 {
+		t.Errorf("Unexpected captured prompt tokens, got %d, want 3", got)
+	}
+
+	// The same scheduler makes the same decisions.
+	if changes := ReplayDecisions(context.Background(), decisions, newScheduler(&queueScorer{})); len(changes) != 0 {
+		t.Errorf("Unexpected changes replaying with the same scheduler: %+v", changes)
+	}
+
+	// A scheduler preferring loaded pods flips the successful decisions.
+	wantChanges := []DecisionChange{
+		{Index: 0, Model: "test-model", CapturedTargetPod: "default/pod1", ReplayedTargetPod: "default/pod2"},
+		{Index: 1, Model: "test-model", CapturedTargetPod: "default/pod2", ReplayedTargetPod: "default/pod1"},
+	}
+	changes := ReplayDecisions(context.Background(), decisions, newScheduler(&queueScorer{inverted: true}))
+	if diff := cmp.Diff(wantChanges, changes); diff != "" {
+		t.Errorf("Unexpected changes (-want +got): %s", diff)
+	}
+}
+
+func TestReplayDecisionsFreshnessDecay(t *testing.T) {
+	decay, err := newFreshnessDecay(time.Second, 10*time.Minute, FreshnessDecayLinear)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now := time.Now()
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "stale"}}, Metrics: &backendmetrics.Metrics{UpdateTime: now.Add(-5 * time.Minute)}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "fresh"}}, Metrics: &backendmetrics.Metrics{UpdateTime: now}},
+	}
+	newScheduler := func(datastore Datastore) *Scheduler {
+		return NewSchedulerWithConfig(datastore, &SchedulerConfig{
+			scorers:        []plugins.Scorer{&podScoresScorer{name: "test", scores: map[string]float64{"stale": 1, "fresh": 0.3}}},
+			picker:         &picker.MaxScorePicker{},
+			freshnessDecay: decay,
+		})
+	}
+
+	var captured bytes.Buffer
+	scheduler := newScheduler(&fakeDataStore{pods: pods}).WithDecisionCapture(NewDecisionCapture(&captured))
+	if _, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model", ResolvedTargetModel: "test-model"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	decisions, err := ReadCapturedDecisions(&captured)
+	if err != nil {
+		t.Fatalf("Failed to read the captured decisions: %v", err)
+	}
+	if got := decisions[0].TargetPod; got != "default/stale" {
+		t.Fatalf("Unexpected captured target pod, got %v, want default/stale", got)
+	}
+
+	// Replaying the capture 4 minutes later would decay the stale pod below the fresh one if the
+	// age of the metrics was measured at the time of the replay.
+	decision := &decisions[0]
+	decision.CapturedAt = decision.CapturedAt.Add(-4 * time.Minute)
+	for _, pod := range decision.Pods {
+		pod.Metrics.UpdateTime = pod.Metrics.UpdateTime.Add(-4 * time.Minute)
+	}
+	if changes := ReplayDecisions(context.Background(), decisions, newScheduler); len(changes) != 0 {
+		t.Errorf("Unexpected changes replaying an old capture: %+v", changes)
+	}
+}
+
+func TestReadCapturedDecisionsInvalid(t *testing.T) {
+	_, err := ReadCapturedDecisions(strings.NewReader("{\"targetPod\": \"default/pod1\"}\n\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected an error on line 3, got %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// DecisionRecord is a scheduling decision along with the metrics of the pods it was made on, for
+// debugging routing oscillations. It doesn't hold the prompt.
+type DecisionRecord struct {
+	// ID increases with every decision, so that two records can be compared.
+	ID        uint64        `json:"id"`
+	Time      time.Time     `json:"time"`
+	Model     string        `json:"model"`
+	TargetPod string        `json:"targetPod"`
+	Pods      []PodSnapshot `json:"pods"`
+}
+
+// PodSnapshot holds the metrics of a pod when a decision was made. Score is 0 for the pods that
+// were filtered out or not scored.
+type PodSnapshot struct {
+	Name                string   `json:"name"`
+	WaitingQueueSize    int      `json:"waitingQueueSize"`
+	RunningQueueSize    int      `json:"runningQueueSize"`
+	KVCacheUsagePercent float64  `json:"kvCacheUsagePercent"`
+	ActiveModels        []string `json:"activeModels,omitempty"`
+	Score               float64  `json:"score"`
+}
+
+func newPodSnapshot(pod types.Pod) PodSnapshot {
+	m := pod.GetMetrics()
+	snapshot := PodSnapshot{
+		Name:                pod.GetPod().NamespacedName.String(),
+		WaitingQueueSize:    m.WaitingQueueSize,
+		RunningQueueSize:    m.RunningQueueSize,
+		KVCacheUsagePercent: m.KVCacheUsagePercent,
+		Score:               pod.Score(),
+	}
+	for model := range m.ActiveModels {
+		snapshot.ActiveModels = append(snapshot.ActiveModels, model)
+	}
+	sort.Strings(snapshot.ActiveModels)
+	return snapshot
+}
+
+func (p PodSnapshot) equal(other PodSnapshot) bool {
+	if p.WaitingQueueSize != other.WaitingQueueSize || p.RunningQueueSize != other.RunningQueueSize ||
+		p.KVCacheUsagePercent != other.KVCacheUsagePercent || p.Score != other.Score ||
+		len(p.ActiveModels) != len(other.ActiveModels) {
+		return false
+	}
+	for i := range p.ActiveModels {
+		if p.ActiveModels[i] != other.ActiveModels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DecisionDiff is the difference between two decisions. Pods lists the pods whose snapshot
+// differs, with From or To unset for a pod that was only present in one of the decisions.
+type DecisionDiff struct {
+	From          uint64     `json:"from"`
+	To            uint64     `json:"to"`
+	FromTargetPod string     `json:"fromTargetPod"`
+	ToTargetPod   string     `json:"toTargetPod"`
+	Pods          []PodDelta `json:"pods"`
+}
+
+type PodDelta struct {
+	Name string       `json:"name"`
+	From *PodSnapshot `json:"from,omitempty"`
+	To   *PodSnapshot `json:"to,omitempty"`
+}
+
+// DiffDecisions returns the pods whose metrics or score changed between two decisions.
+func DiffDecisions(from, to DecisionRecord) DecisionDiff {
+	diff := DecisionDiff{From: from.ID, To: to.ID, FromTargetPod: from.TargetPod, ToTargetPod: to.TargetPod, Pods: []PodDelta{}}
+	before := make(map[string]PodSnapshot, len(from.Pods))
+	for _, pod := range from.Pods {
+		before[pod.Name] = pod
+	}
+	for _, pod := range to.Pods {
+		prev, ok := before[pod.Name]
+		delete(before, pod.Name)
+		if ok && prev.equal(pod) {
+			continue
+		}
+		delta := PodDelta{Name: pod.Name, To: &pod}
+		if ok {
+			delta.From = &prev
+		}
+		diff.Pods = append(diff.Pods, delta)
+	}
+	for _, pod := range before {
+		diff.Pods = append(diff.Pods, PodDelta{Name: pod.Name, From: &pod})
+	}
+	sort.Slice(diff.Pods, func(i, j int) bool { return diff.Pods[i].Name < diff.Pods[j].Name })
+	return diff
+}
+
+// DecisionHistory keeps the last decisions of the scheduler in a ring buffer, bounding the memory
+// used. It is a PostSchedule plugin recording the decisions, and an http.Handler serving them:
+// without parameters, it returns the recorded decisions, oldest first; with the "from" and "to"
+// parameters set to decision IDs, it returns the diff between the two decisions.
+type DecisionHistory struct {
+	mu      sync.Mutex
+	records []DecisionRecord
+	// next is the index in records where the next decision is stored.
+	next   int
+	nextID uint64
+}
+
+// NewDecisionHistory returns a history keeping the last size decisions.
+func NewDecisionHistory(size int) *DecisionHistory {
+	return &DecisionHistory{records: make([]DecisionRecord, 0, size), nextID: 1}
+}
+
+func (h *DecisionHistory) Name() string {
+	return "decision-history"
+}
+
+func (h *DecisionHistory) PostSchedule(ctx *types.SchedulingContext, res *types.Result) {
+	record := DecisionRecord{
+		Time:  time.Now(),
+		Model: ctx.Req.ResolvedTargetModel,
+		Pods:  make([]PodSnapshot, 0, len(ctx.PodsSnapshot)),
+	}
+	if res.TargetPod != nil {
+		record.TargetPod = res.TargetPod.GetPod().NamespacedName.String()
+	}
+	for _, pod := range ctx.PodsSnapshot {
+		record.Pods = append(record.Pods, newPodSnapshot(pod))
+	}
+	h.add(record)
+}
+
+func (h *DecisionHistory) add(record DecisionRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if cap(h.records) == 0 {
+		return
+	}
+	record.ID = h.nextID
+	h.nextID++
+	if len(h.records) < cap(h.records) {
+		h.records = append(h.records, record)
+		return
+	}
+	h.records[h.next] = record
+	h.next = (h.next + 1) % len(h.records)
+}
+
+// Records returns the recorded decisions, oldest first.
+func (h *DecisionHistory) Records() []DecisionRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	records := make([]DecisionRecord, 0, len(h.records))
+	records = append(records, h.records[h.next:]...)
+	return append(records, h.records[:h.next]...)
+}
+
+// Diff returns the diff between the decisions with the given IDs, and false if any of them is
+// not recorded anymore.
+func (h *DecisionHistory) Diff(from, to uint64) (DecisionDiff, bool) {
+	byID := map[uint64]DecisionRecord{}
+	for _, record := range h.Records() {
+		byID[record.ID] = record
+	}
+	fromRecord, fromOK := byID[from]
+	toRecord, toOK := byID[to]
+	if !fromOK || !toOK {
+		return DecisionDiff{}, false
+	}
+	return DiffDecisions(fromRecord, toRecord), true
+}
+
+func (h *DecisionHistory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !query.Has("from") && !query.Has("to") {
+		writeJSON(w, h.Records())
+		return
+	}
+	from, err := strconv.ParseUint(query.Get("from"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid from decision ID", http.StatusBadRequest)
+		return
+	}
+	to, err := strconv.ParseUint(query.Get("to"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid to decision ID", http.StatusBadRequest)
+		return
+	}
+	diff, ok := h.Diff(from, to)
+	if !ok {
+		http.Error(w, "decision not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, diff)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
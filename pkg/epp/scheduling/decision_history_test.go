@@ -0,0 +1,145 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestDecisionHistory(t *testing.T) {
+	pod1 := &backendmetrics.FakePodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod1"}},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: 1, KVCacheUsagePercent: 0.2},
+	}
+	pod2 := &backendmetrics.FakePodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Namespace: "default", Name: "pod2"}},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: 3, KVCacheUsagePercent: 0.5},
+	}
+	scores := map[string]float64{"pod1": 1, "pod2": 0.5}
+	history := NewDecisionHistory(2)
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: []*backendmetrics.FakePodMetrics{pod1, pod2}}, &SchedulerConfig{
+		scorers: []plugins.Scorer{&podScoresScorer{name: "test", scores: scores}},
+		picker:  &picker.MaxScorePicker{},
+	}).WithDecisionHistory(history)
+
+	schedule := func() {
+		t.Helper()
+		req := &types.LLMRequest{Model: "test-model", ResolvedTargetModel: "test-model", Prompt: "secret prompt"}
+		if _, err := scheduler.Schedule(context.Background(), req); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	schedule()
+	schedule()
+	// The routing flips to pod2 as pod1 gets loaded.
+	pod1.Metrics = &backendmetrics.Metrics{WaitingQueueSize: 5, KVCacheUsagePercent: 0.9}
+	scores["pod1"] = 0.2
+	schedule()
+
+	// The oldest decision was evicted from the history.
+	records := history.Records()
+	var gotIDs []uint64
+	for _, record := range records {
+		gotIDs = append(gotIDs, record.ID)
+	}
+	if diff := cmp.Diff([]uint64{2, 3}, gotIDs); diff != "" {
+		t.Errorf("Unexpected recorded decisions (-want +got): %s", diff)
+	}
+
+	rec := httptest.NewRecorder()
+	history.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/decisions", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "secret prompt") {
+		t.Errorf("The decision history exposes the prompt: %s", rec.Body.String())
+	}
+	var gotRecords []DecisionRecord
+	if err := json.NewDecoder(rec.Body).Decode(&gotRecords); err != nil {
+		t.Fatalf("Failed to decode the decisions: %v", err)
+	}
+	if diff := cmp.Diff(records, gotRecords); diff != "" {
+		t.Errorf("Unexpected served decisions (-want +got): %s", diff)
+	}
+
+	rec = httptest.NewRecorder()
+	history.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/decisions?from=2&to=3", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %d", rec.Code)
+	}
+	var gotDiff DecisionDiff
+	if err := json.NewDecoder(rec.Body).Decode(&gotDiff); err != nil {
+		t.Fatalf("Failed to decode the diff: %v", err)
+	}
+	wantDiff := DecisionDiff{
+		From:          2,
+		To:            3,
+		FromTargetPod: "default/pod1",
+		ToTargetPod:   "default/pod2",
+		Pods: []PodDelta{{
+			Name: "default/pod1",
+			From: &PodSnapshot{Name: "default/pod1", WaitingQueueSize: 1, KVCacheUsagePercent: 0.2, Score: 1},
+			To:   &PodSnapshot{Name: "default/pod1", WaitingQueueSize: 5, KVCacheUsagePercent: 0.9, Score: 0.2},
+		}},
+	}
+	if diff := cmp.Diff(wantDiff, gotDiff); diff != "" {
+		t.Errorf("Unexpected diff (-want +got): %s", diff)
+	}
+
+	for _, query := range []struct {
+		query      string
+		wantStatus int
+	}{
+		{query: "from=1&to=3", wantStatus: http.StatusNotFound},
+		{query: "from=2", wantStatus: http.StatusBadRequest},
+		{query: "from=a&to=3", wantStatus: http.StatusBadRequest},
+	} {
+		rec = httptest.NewRecorder()
+		history.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/decisions?"+query.query, nil))
+		if rec.Code != query.wantStatus {
+			t.Errorf("Unexpected status code for %q, got %d, want %d", query.query, rec.Code, query.wantStatus)
+		}
+	}
+}
+
+func TestDiffDecisionsAddedAndRemovedPods(t *testing.T) {
+	from := DecisionRecord{ID: 1, Pods: []PodSnapshot{{Name: "pod1"}, {Name: "pod2", RunningQueueSize: 1}}}
+	to := DecisionRecord{ID: 2, Pods: []PodSnapshot{{Name: "pod2", RunningQueueSize: 1}, {Name: "pod3"}}}
+	want := DecisionDiff{
+		From: 1,
+		To:   2,
+		Pods: []PodDelta{
+			{Name: "pod1", From: &PodSnapshot{Name: "pod1"}},
+			{Name: "pod3", To: &PodSnapshot{Name: "pod3"}},
+		},
+	}
+	if diff := cmp.Diff(want, DiffDecisions(from, to)); diff != "" {
+		t.Errorf("Unexpected diff (-want +got): %s", diff)
+	}
+}
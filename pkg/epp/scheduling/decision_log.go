@@ -0,0 +1,110 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// decisionSampler selects the scheduling decisions to log, so operators get representative
+// visibility at high QPS without flooding the logs. Failed decisions, including dropped requests,
+// are always selected.
+type decisionSampler struct {
+	rate  uint64
+	count atomic.Uint64
+}
+
+// newDecisionSampler returns a sampler selecting 1 in every rate successful decisions. A rate of
+// 0 or less selects none of them.
+func newDecisionSampler(rate int) *decisionSampler {
+	if rate < 0 {
+		rate = 0
+	}
+	return &decisionSampler{rate: uint64(rate)}
+}
+
+func (d *decisionSampler) sample(err error) bool {
+	if err != nil {
+		return true
+	}
+	if d.rate == 0 {
+		return false
+	}
+	return (d.count.Add(1)-1)%d.rate == 0
+}
+
+// errorThrottler rate-limits the logging of identical scheduling errors, e.g. when the pool is
+// empty and every request fails the same way. An error is logged at most once every interval as
+// long as it repeats; an error that differs from the previous one is logged immediately.
+type errorThrottler struct {
+	interval time.Duration
+	clock    clock.PassiveClock
+
+	mu sync.Mutex
+	// last is the message of the last error, logged at lastLogged.
+	last       string
+	lastLogged time.Time
+	// suppressed is the number of occurrences of the last error not logged since lastLogged.
+	suppressed int
+}
+
+// newErrorThrottler returns a throttler logging identical errors at most once every interval. An
+// interval of 0 or less logs every error.
+func newErrorThrottler(interval time.Duration, clock clock.PassiveClock) *errorThrottler {
+	return &errorThrottler{interval: interval, clock: clock}
+}
+
+// allow reports whether the error should be logged. Before an error is logged, the number of
+// suppressed occurrences of the previous error, if any, is logged as a summary.
+func (t *errorThrottler) allow(logger logr.Logger, err error) bool {
+	if err == nil || t.interval <= 0 {
+		return true
+	}
+	msg := err.Error()
+	now := t.clock.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if msg == t.last && now.Sub(t.lastLogged) < t.interval {
+		t.suppressed++
+		return false
+	}
+	if t.suppressed > 0 {
+		logger.V(logutil.DEFAULT).Info("Suppressed repeated scheduling errors", "error", t.last,
+			"count", t.suppressed, "since", t.lastLogged)
+	}
+	t.last = msg
+	t.lastLogged = now
+	t.suppressed = 0
+	return true
+}
+
+func logDecision(logger logr.Logger, req *types.LLMRequest, res *types.Result, err error) {
+	if err != nil {
+		logger.V(logutil.DEFAULT).Info("Failed to schedule request", "request", req, "error", err)
+		return
+	}
+	logger.V(logutil.DEFAULT).Info("Scheduled request", "request", req,
+		"targetPod", res.TargetPod.GetPod().NamespacedName, "score", res.TargetPod.Score())
+}
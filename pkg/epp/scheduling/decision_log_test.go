@@ -0,0 +1,121 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	clocktesting "k8s.io/utils/clock/testing"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+func TestDecisionSampler(t *testing.T) {
+	tests := []struct {
+		name        string
+		rate        int
+		decisions   int
+		wantSampled int
+	}{
+		{name: "disabled", rate: 0, decisions: 10, wantSampled: 0},
+		{name: "every decision", rate: 1, decisions: 10, wantSampled: 10},
+		{name: "1 in 3 decisions", rate: 3, decisions: 9, wantSampled: 3},
+		{name: "1 in 3 decisions, partial window", rate: 3, decisions: 10, wantSampled: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sampler := newDecisionSampler(test.rate)
+			sampled := 0
+			for i := 0; i < test.decisions; i++ {
+				if sampler.sample(nil) {
+					sampled++
+				}
+			}
+			if sampled != test.wantSampled {
+				t.Errorf("Unexpected number of sampled decisions, got %d, want %d", sampled, test.wantSampled)
+			}
+		})
+	}
+}
+
+func TestDecisionSamplerAlwaysSamplesErrors(t *testing.T) {
+	for _, rate := range []int{0, 1, 100} {
+		sampler := newDecisionSampler(rate)
+		for i := 0; i < 5; i++ {
+			if !sampler.sample(errors.New("failed to find a target pod")) {
+				t.Errorf("Expected failed decisions to always be sampled with rate %d", rate)
+			}
+		}
+	}
+}
+
+func TestErrorThrottler(t *testing.T) {
+	var logged []string
+	logger := funcr.New(func(prefix, args string) { logged = append(logged, args) }, funcr.Options{Verbosity: logutil.DEFAULT})
+	clock := clocktesting.NewFakePassiveClock(time.Now())
+	throttler := newErrorThrottler(10*time.Second, clock)
+	noPods := errors.New("no pods available")
+	redisDown := errors.New("redis is down")
+
+	steps := []struct {
+		err       error
+		advance   time.Duration
+		wantAllow bool
+	}{
+		{err: noPods, wantAllow: true},
+		{err: noPods, wantAllow: false},
+		{err: noPods, advance: 5 * time.Second, wantAllow: false},
+		// A distinct error is logged immediately, after the summary of the suppressed ones.
+		{err: redisDown, wantAllow: true},
+		{err: noPods, wantAllow: true},
+		{err: noPods, wantAllow: false},
+		// The repeated error is logged again once the interval elapsed.
+		{err: noPods, advance: 10 * time.Second, wantAllow: true},
+		{err: nil, wantAllow: true},
+	}
+	for i, step := range steps {
+		clock.SetTime(clock.Now().Add(step.advance))
+		if got := throttler.allow(logger, step.err); got != step.wantAllow {
+			t.Errorf("Step %d: unexpected allow for %v, got %v, want %v", i, step.err, got, step.wantAllow)
+		}
+	}
+
+	// Summaries are logged for the 2 errors suppressed before redisDown, and the one suppressed
+	// before the interval elapsed.
+	wantCounts := []string{`"count"=2`, `"count"=1`}
+	if len(logged) != len(wantCounts) {
+		t.Fatalf("Unexpected summaries, got %v, want %d", logged, len(wantCounts))
+	}
+	for i, want := range wantCounts {
+		if !strings.Contains(logged[i], `"error"="no pods available"`) || !strings.Contains(logged[i], want) {
+			t.Errorf("Unexpected summary %q, want the no pods error with %s", logged[i], want)
+		}
+	}
+}
+
+func TestErrorThrottlerDisabled(t *testing.T) {
+	throttler := newErrorThrottler(0, clocktesting.NewFakePassiveClock(time.Now()))
+	for i := 0; i < 5; i++ {
+		if !throttler.allow(logr.Discard(), errors.New("no pods available")) {
+			t.Errorf("Expected every error to be logged when the throttling is disabled")
+		}
+	}
+}
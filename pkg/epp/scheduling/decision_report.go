@@ -0,0 +1,191 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// Decision is the anonymized outcome of a scheduling decision, for capacity planning. It doesn't
+// hold the prompt or any client identifier.
+type Decision struct {
+	Time                time.Time `json:"time"`
+	Model               string    `json:"model"`
+	ResolvedTargetModel string    `json:"resolvedTargetModel"`
+	Critical            bool      `json:"critical"`
+	PromptTokens        int       `json:"promptTokens"`
+	// TargetPod and Score are empty if the request could not be scheduled.
+	TargetPod string  `json:"targetPod,omitempty"`
+	Score     float64 `json:"score,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+func newDecision(req *types.LLMRequest, res *types.Result, err error) Decision {
+	d := Decision{
+		Time:                time.Now(),
+		Model:               req.Model,
+		ResolvedTargetModel: req.ResolvedTargetModel,
+		Critical:            req.Critical,
+		PromptTokens:        req.PromptTokens,
+	}
+	if err != nil {
+		d.Error = err.Error()
+	} else if res != nil && res.TargetPod != nil {
+		d.TargetPod = res.TargetPod.GetPod().NamespacedName.String()
+		d.Score = res.TargetPod.Score()
+	}
+	return d
+}
+
+// DecisionReporter receives every scheduling decision, e.g. to stream them to an external sink.
+// Report is called on the request path, so it must never block.
+type DecisionReporter interface {
+	Report(decision Decision)
+	// Close reports the buffered decisions and stops the reporter.
+	Close()
+}
+
+// noopDecisionReporter is the default reporter, discarding the decisions.
+type noopDecisionReporter struct{}
+
+func (noopDecisionReporter) Report(Decision) {}
+
+func (noopDecisionReporter) Close() {}
+
+// NewHTTPDecisionReporter returns a reporter posting the decisions as JSON arrays of up to
+// batchSize decisions to the given URL. A batch is sent when it is full or after flushInterval.
+// Up to bufferSize decisions are buffered while a batch is being sent, and the decisions reported
+// while the buffer is full are dropped.
+func NewHTTPDecisionReporter(logger logr.Logger, url string, bufferSize, batchSize int, flushInterval time.Duration) *HTTPDecisionReporter {
+	r := &HTTPDecisionReporter{
+		logger:        logger,
+		url:           url,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		batchSize:     max(batchSize, 1),
+		flushInterval: flushInterval,
+		decisions:     make(chan Decision, bufferSize),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// HTTPDecisionReporter is a batching DecisionReporter. See NewHTTPDecisionReporter.
+type HTTPDecisionReporter struct {
+	logger        logr.Logger
+	url           string
+	client        *http.Client
+	batchSize     int
+	flushInterval time.Duration
+
+	decisions chan Decision
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func (r *HTTPDecisionReporter) Report(decision Decision) {
+	select {
+	case r.decisions <- decision:
+	default:
+		metrics.RecordDecisionReportDropped()
+	}
+}
+
+// Close sends the buffered decisions and waits for them to be sent. The decisions reported after
+// Close are dropped.
+func (r *HTTPDecisionReporter) Close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+	<-r.done
+}
+
+func (r *HTTPDecisionReporter) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Decision, 0, r.batchSize)
+	add := func(decision Decision) {
+		batch = append(batch, decision)
+		if len(batch) >= r.batchSize {
+			r.send(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case decision := <-r.decisions:
+			add(decision)
+		case <-ticker.C:
+			if len(batch) > 0 {
+				r.send(batch)
+				batch = batch[:0]
+			}
+		case <-r.stop:
+			for {
+				select {
+				case decision := <-r.decisions:
+					add(decision)
+				default:
+					if len(batch) > 0 {
+						r.send(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *HTTPDecisionReporter) send(batch []Decision) {
+	if err := r.post(batch); err != nil {
+		r.logger.V(logutil.DEFAULT).Error(err, "Failed to report scheduling decisions", "decisions", len(batch))
+	}
+}
+
+func (r *HTTPDecisionReporter) post(batch []Decision) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	metricstestutil "k8s.io/component-base/metrics/testutil"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// decisionSink is an HTTP server recording the batches of decisions posted to it.
+type decisionSink struct {
+	*httptest.Server
+	mu      sync.Mutex
+	batches [][]Decision
+}
+
+func newDecisionSink(t *testing.T, handle func()) *decisionSink {
+	sink := &decisionSink{}
+	sink.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []Decision
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("Failed to decode the decisions: %v", err)
+		}
+		sink.mu.Lock()
+		sink.batches = append(sink.batches, batch)
+		sink.mu.Unlock()
+		if handle != nil {
+			handle()
+		}
+	}))
+	t.Cleanup(sink.Close)
+	return sink
+}
+
+func (s *decisionSink) batchSizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := []int{}
+	for _, batch := range s.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func TestHTTPDecisionReporterBatches(t *testing.T) {
+	sink := newDecisionSink(t, nil)
+	reporter := NewHTTPDecisionReporter(logr.Discard(), sink.URL, 10, 3, time.Hour)
+	for i := 0; i < 7; i++ {
+		reporter.Report(Decision{Model: "model"})
+	}
+	// The last partial batch is sent on close.
+	reporter.Close()
+	if diff := cmp.Diff([]int{3, 3, 1}, sink.batchSizes()); diff != "" {
+		t.Errorf("Unexpected batch sizes (-want +got): %s", diff)
+	}
+}
+
+func TestHTTPDecisionReporterFlushesPeriodically(t *testing.T) {
+	sent := make(chan struct{}, 1)
+	sink := newDecisionSink(t, func() { sent <- struct{}{} })
+	reporter := NewHTTPDecisionReporter(logr.Discard(), sink.URL, 10, 100, 10*time.Millisecond)
+	defer reporter.Close()
+	reporter.Report(Decision{Model: "model"})
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the partial batch to be sent")
+	}
+	if diff := cmp.Diff([]int{1}, sink.batchSizes()); diff != "" {
+		t.Errorf("Unexpected batch sizes (-want +got): %s", diff)
+	}
+}
+
+func TestHTTPDecisionReporterDropsWhenFull(t *testing.T) {
+	metrics.Register()
+	received := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	// The sink blocks on the first batch, so the reporter can't drain its buffer.
+	sink := newDecisionSink(t, func() {
+		once.Do(func() {
+			close(received)
+			<-release
+		})
+	})
+	reporter := NewHTTPDecisionReporter(logr.Discard(), sink.URL, 2, 1, time.Hour)
+	reporter.Report(Decision{Model: "first"})
+	<-received
+
+	before, err := metricstestutil.GetCounterMetricValue(metrics.SchedulerDecisionReportsDropped)
+	if err != nil {
+		t.Fatalf("Failed to get the dropped decisions: %v", err)
+	}
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		reporter.Report(Decision{Model: "buffered or dropped"})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Reporting blocked for %v", elapsed)
+	}
+	after, err := metricstestutil.GetCounterMetricValue(metrics.SchedulerDecisionReportsDropped)
+	if err != nil {
+		t.Fatalf("Failed to get the dropped decisions: %v", err)
+	}
+	if dropped := after - before; dropped != 3 {
+		t.Errorf("Unexpected number of dropped decisions, got %v, want 3", dropped)
+	}
+
+	close(release)
+	reporter.Close()
+	if diff := cmp.Diff([]int{1, 1, 1}, sink.batchSizes()); diff != "" {
+		t.Errorf("Unexpected batch sizes (-want +got): %s", diff)
+	}
+}
+
+func TestNewDecision(t *testing.T) {
+	req := &types.LLMRequest{Model: "model", ResolvedTargetModel: "adapter", Critical: true, PromptTokens: 10, Prompt: "secret", TenantID: "tenant"}
+	pod := &types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1", Namespace: "default"}}, Metrics: &backendmetrics.Metrics{}}
+	pod.SetScore(0.5)
+
+	got := newDecision(req, &types.Result{TargetPod: pod}, nil)
+	got.Time = time.Time{}
+	want := Decision{Model: "model", ResolvedTargetModel: "adapter", Critical: true, PromptTokens: 10, TargetPod: "default/pod1", Score: 0.5}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected decision (-want +got): %s", diff)
+	}
+
+	got = newDecision(req, nil, errors.New("failed to find a target pod"))
+	got.Time = time.Time{}
+	want = Decision{Model: "model", ResolvedTargetModel: "adapter", Critical: true, PromptTokens: 10, Error: "failed to find a target pod"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected decision (-want +got): %s", diff)
+	}
+}
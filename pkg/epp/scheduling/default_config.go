@@ -17,15 +17,182 @@ limitations under the License.
 package scheduling
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/filter"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
 )
 
-var defPlugin = &defaultPlugin{}
+var defPlugin = newDefaultPlugin(filter.LoRAAffinityFilter)
 
 var defaultConfig = &SchedulerConfig{
-	preSchedulePlugins:  []plugins.PreSchedule{},
-	scorers:             []plugins.Scorer{},
-	filters:             []plugins.Filter{defPlugin},
-	postSchedulePlugins: []plugins.PostSchedule{},
-	picker:              defPlugin,
+	requestPreprocessors: []plugins.RequestPreprocessor{},
+	preSchedulePlugins:   []plugins.PreSchedule{},
+	scorers:              []plugins.Scorer{},
+	filters:              []plugins.Filter{filter.TerminatingPodFilter, filter.NodeDiversityFilter, defPlugin},
+	postSchedulePlugins:  []plugins.PostSchedule{},
+	picker:               defPlugin,
+}
+
+// PickerCapacity is the name of the picker selecting pods proportionally to their remaining
+// capacity.
+const PickerCapacity = "capacity"
+
+// PickerWeightedRoundRobin is the name of the picker distributing requests in proportion to static
+// pod weights.
+const PickerWeightedRoundRobin = "weighted-round-robin"
+
+// loraAffinityTuningInterval is the interval at which the LoRA affinity threshold is tuned.
+const loraAffinityTuningInterval = 5 * time.Second
+
+// newSchedulerConfig returns the default config extended with the datastore backed filters and
+// the registered scorers listed in the given config. When scorers are configured, the pod with the
+// highest score is picked instead of a random one, unless another picker is configured.
+func newSchedulerConfig(ctx context.Context, datastore Datastore, conf config.Config) (*SchedulerConfig, error) {
+	cfg := &SchedulerConfig{
+		requestPreprocessors:   defaultConfig.requestPreprocessors,
+		preSchedulePlugins:     defaultConfig.preSchedulePlugins,
+		scorers:                defaultConfig.scorers,
+		filters:                append([]plugins.Filter{filter.UnhealthyPodFilter}, defaultConfig.filters...),
+		postSchedulePlugins:    defaultConfig.postSchedulePlugins,
+		picker:                 defaultConfig.picker,
+		criticalScorerWeights:  conf.CriticalScorerWeights,
+		sheddableScorerWeights: conf.SheddableScorerWeights,
+		decisionLogSampleRate:  conf.DecisionLogSampleRate,
+		errorLogInterval:       time.Duration(conf.ErrorLogIntervalSeconds * float64(time.Second)),
+		poolConcurrencyLimit:   conf.PoolConcurrencyLimit,
+		poolQueueTimeout:       time.Duration(conf.PoolQueueTimeoutSeconds * float64(time.Second)),
+		poolSyncGrace:          time.Duration(conf.PoolSyncGraceSeconds * float64(time.Second)),
+		minCandidatePods:       conf.MinCandidatePods,
+		pinSingleReplicaModels: conf.PinSingleReplicaModels,
+	}
+	if len(conf.Scorers) > 0 {
+		scorers, err := newScorers(ctx, datastore, conf, conf.Scorers)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := scoreCombiners[conf.ScoreCombination]; !ok && conf.ScoreCombination != "" {
+			return nil, fmt.Errorf("unknown score combination %q", conf.ScoreCombination)
+		}
+		cfg.scoreCombination = conf.ScoreCombination
+		cfg.scorers = scorers
+		// Scorers that keep state about previous scheduling decisions are notified of them.
+		cfg.postSchedulePlugins = append([]plugins.PostSchedule{}, defaultConfig.postSchedulePlugins...)
+		for _, scorer := range scorers {
+			if plugin, ok := scorer.(plugins.PostSchedule); ok {
+				cfg.postSchedulePlugins = append(cfg.postSchedulePlugins, plugin)
+			}
+		}
+		cfg.picker = &picker.MaxScorePicker{}
+		if conf.PickerStickinessMargin > 0 {
+			cfg.picker = picker.NewStickyPicker(conf.PickerStickinessMargin)
+		}
+	}
+
+	decay, err := newFreshnessDecay(
+		time.Duration(conf.MetricsFreshnessGraceSeconds*float64(time.Second)),
+		time.Duration(conf.MetricsFreshnessMaxAgeSeconds*float64(time.Second)),
+		conf.MetricsFreshnessDecayCurve)
+	if err != nil {
+		return nil, err
+	}
+	cfg.freshnessDecay = decay
+
+	if len(conf.ScorerTimeoutsSeconds) > 0 {
+		cfg.scorerTimeouts = make(map[string]time.Duration, len(conf.ScorerTimeoutsSeconds))
+		for name, seconds := range conf.ScorerTimeoutsSeconds {
+			if seconds <= 0 {
+				return nil, fmt.Errorf("invalid timeout %v for scorer %q, must be positive", seconds, name)
+			}
+			cfg.scorerTimeouts[name] = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	if conf.TieBreakScorer != "" {
+		tieBreakers, err := newScorers(ctx, datastore, conf, []string{conf.TieBreakScorer})
+		if err != nil {
+			return nil, fmt.Errorf("invalid tie-break scorer: %w", err)
+		}
+		cfg.tieBreaker = tieBreakers[0]
+	}
+
+	if conf.MaintenanceAnnotation != "" {
+		cfg.filters = append([]plugins.Filter{filter.NewMaintenanceFilter(conf.MaintenanceAnnotation)}, cfg.filters...)
+	}
+
+	if conf.LargePromptTokens > 0 {
+		cfg.filters = append([]plugins.Filter{filter.NewKVCacheHeadroomFilter(conf.LargePromptTokens, conf.KVCacheThreshold)}, cfg.filters...)
+	}
+
+	if conf.ExperimentPercentage > 0 {
+		key, value, found := strings.Cut(conf.ExperimentPodSelector, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid experiment pod selector %q, expected key=value", conf.ExperimentPodSelector)
+		}
+		cfg.filters = append([]plugins.Filter{filter.NewExperimentFilter(conf.ExperimentPercentage, strings.TrimSpace(key), strings.TrimSpace(value))}, cfg.filters...)
+	}
+
+	if conf.TenantFairnessThreshold > 0 {
+		if conf.TenantFairnessWindowSeconds <= 0 {
+			return nil, fmt.Errorf("invalid tenant fairness window %v, must be positive", conf.TenantFairnessWindowSeconds)
+		}
+		window := time.Duration(conf.TenantFairnessWindowSeconds * float64(time.Second))
+		fairness := filter.NewTenantFairnessFilter(conf.TenantFairnessThreshold, window, conf.TenantShares)
+		cfg.filters = append([]plugins.Filter{fairness}, cfg.filters...)
+		cfg.postSchedulePlugins = append(append([]plugins.PostSchedule{}, cfg.postSchedulePlugins...), fairness)
+	}
+
+	if conf.PoolSaturationThreshold > 0 {
+		cfg.filters = append([]plugins.Filter{filter.NewPoolSaturationFilter(conf.PoolSaturationThreshold)}, cfg.filters...)
+	}
+
+	if conf.LoraSwapExpensiveSeconds > 0 {
+		if conf.LoraSwapCheapSeconds < 0 || conf.LoraSwapCheapSeconds > conf.LoraSwapExpensiveSeconds ||
+			conf.LoraAffinityMinThreshold < 0 || conf.LoraAffinityMinThreshold > conf.LoraAffinityMaxThreshold || conf.LoraAffinityMaxThreshold > 1 {
+			return nil, fmt.Errorf("invalid LoRA affinity tuning, swap latencies [%v, %v] and thresholds [%v, %v] must be ordered ranges",
+				conf.LoraSwapCheapSeconds, conf.LoraSwapExpensiveSeconds, conf.LoraAffinityMinThreshold, conf.LoraAffinityMaxThreshold)
+		}
+		tuner := filter.NewLoRAAffinityTuner(conf.LoraAffinityThreshold, conf.LoraAffinityMinThreshold, conf.LoraAffinityMaxThreshold,
+			time.Duration(conf.LoraSwapCheapSeconds*float64(time.Second)), time.Duration(conf.LoraSwapExpensiveSeconds*float64(time.Second)),
+			datastore.PodGetAll)
+		go tuner.Run(ctx, loraAffinityTuningInterval)
+		// The default plugin is rebuilt for this scheduler, so that the tuned threshold doesn't
+		// leak into the other schedulers.
+		plugin := newDefaultPlugin(filter.NewLoRAAffinityFilter(tuner))
+		for i, f := range cfg.filters {
+			if f == plugins.Filter(defPlugin) {
+				cfg.filters[i] = plugin
+			}
+		}
+		if cfg.picker == plugins.Picker(defPlugin) {
+			cfg.picker = plugin
+		}
+	}
+
+	if conf.DecisionReportURL != "" {
+		if conf.DecisionReportIntervalSeconds <= 0 || conf.DecisionReportBufferSize < 0 {
+			return nil, fmt.Errorf("invalid decision report flush interval %v or buffer size %d",
+				conf.DecisionReportIntervalSeconds, conf.DecisionReportBufferSize)
+		}
+		cfg.decisionReporter = NewHTTPDecisionReporter(log.FromContext(ctx).WithName("decision-reporter"), conf.DecisionReportURL,
+			conf.DecisionReportBufferSize, conf.DecisionReportBatchSize, time.Duration(conf.DecisionReportIntervalSeconds*float64(time.Second)))
+	}
+
+	switch conf.Picker {
+	case "":
+		// Keep the picker matching the scorers.
+	case PickerCapacity:
+		cfg.picker = &picker.CapacityPicker{}
+	case PickerWeightedRoundRobin:
+		cfg.picker = picker.NewWeightedRoundRobinPicker(conf.PickerWeightLabel)
+	default:
+		return nil, fmt.Errorf("unknown picker %q", conf.Picker)
+	}
+	return cfg, nil
 }
@@ -0,0 +1,77 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// FreshnessDecayLinear discounts the scores linearly with the metrics age.
+	FreshnessDecayLinear = "linear"
+	// FreshnessDecayQuadratic discounts the scores slowly at first, then faster as the metrics age
+	// approaches the max age, keeping slightly stale pods closer to fresh ones.
+	FreshnessDecayQuadratic = "quadratic"
+)
+
+var freshnessDecayCurves = map[string]func(fraction float64) float64{
+	FreshnessDecayLinear: func(fraction float64) float64 {
+		return 1 - fraction
+	},
+	FreshnessDecayQuadratic: func(fraction float64) float64 {
+		return 1 - fraction*fraction
+	},
+}
+
+// freshnessDecay discounts the scores of the pods as their metrics age instead of excluding the
+// pods with stale metrics, so they remain usable when the alternatives are worse.
+type freshnessDecay struct {
+	// grace is the metrics age up to which scores are not discounted.
+	grace time.Duration
+	// maxAge is the metrics age from which scores are fully discounted.
+	maxAge time.Duration
+	curve  func(fraction float64) float64
+}
+
+// newFreshnessDecay returns a freshness decay following the named curve between the grace and the
+// max age, or nil if maxAge is not positive.
+func newFreshnessDecay(grace, maxAge time.Duration, curve string) (*freshnessDecay, error) {
+	if maxAge <= 0 {
+		return nil, nil
+	}
+	if grace >= maxAge {
+		return nil, fmt.Errorf("metrics freshness grace period %v must be shorter than the max age %v", grace, maxAge)
+	}
+	f, ok := freshnessDecayCurves[curve]
+	if !ok {
+		return nil, fmt.Errorf("unknown freshness decay curve %q", curve)
+	}
+	return &freshnessDecay{grace: grace, maxAge: maxAge, curve: f}, nil
+}
+
+// multiplier returns the factor in the range [0, 1] applied to the score of a pod whose metrics
+// are the given age.
+func (d *freshnessDecay) multiplier(age time.Duration) float64 {
+	switch {
+	case age <= d.grace:
+		return 1
+	case age >= d.maxAge:
+		return 0
+	}
+	return d.curve(float64(age-d.grace) / float64(d.maxAge-d.grace))
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestFreshnessDecayMultiplier(t *testing.T) {
+	tests := []struct {
+		name  string
+		curve string
+		age   time.Duration
+		want  float64
+	}{
+		{name: "fresh", curve: FreshnessDecayLinear, age: 0, want: 1},
+		{name: "within grace period", curve: FreshnessDecayLinear, age: time.Second, want: 1},
+		{name: "linear, a quarter of the way", curve: FreshnessDecayLinear, age: 2 * time.Second, want: 0.75},
+		{name: "linear, halfway", curve: FreshnessDecayLinear, age: 3 * time.Second, want: 0.5},
+		{name: "quadratic, a quarter of the way", curve: FreshnessDecayQuadratic, age: 2 * time.Second, want: 0.9375},
+		{name: "quadratic, halfway", curve: FreshnessDecayQuadratic, age: 3 * time.Second, want: 0.75},
+		{name: "at max age", curve: FreshnessDecayLinear, age: 5 * time.Second, want: 0},
+		{name: "past max age", curve: FreshnessDecayQuadratic, age: time.Minute, want: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decay, err := newFreshnessDecay(time.Second, 5*time.Second, test.curve)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := decay.multiplier(test.age); got != test.want {
+				t.Errorf("Unexpected multiplier, got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewFreshnessDecay(t *testing.T) {
+	if decay, err := newFreshnessDecay(0, 0, ""); decay != nil || err != nil {
+		t.Errorf("Expected the decay to be disabled without a max age, got %v, %v", decay, err)
+	}
+	if _, err := newFreshnessDecay(5*time.Second, time.Second, FreshnessDecayLinear); err == nil {
+		t.Error("Expected an error for a grace period longer than the max age")
+	}
+	if _, err := newFreshnessDecay(0, time.Second, "cubic"); err == nil {
+		t.Error("Expected an error for an unknown curve")
+	}
+}
+
+func TestScheduleFreshnessDecay(t *testing.T) {
+	decay, err := newFreshnessDecay(time.Second, time.Minute, FreshnessDecayLinear)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now := time.Now()
+	newScheduler := func(staleScore, freshScore float64) *Scheduler {
+		pods := []*backendmetrics.FakePodMetrics{
+			{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "stale"}}, Metrics: &backendmetrics.Metrics{UpdateTime: now.Add(-30 * time.Second)}},
+			{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "fresh"}}, Metrics: &backendmetrics.Metrics{UpdateTime: now}},
+		}
+		return NewSchedulerWithConfig(&fakeDataStore{pods: pods}, &SchedulerConfig{
+			scorers:        []plugins.Scorer{&podScoresScorer{name: "test", scores: map[string]float64{"stale": staleScore, "fresh": freshScore}}},
+			picker:         &picker.MaxScorePicker{},
+			freshnessDecay: decay,
+		})
+	}
+
+	tests := []struct {
+		name       string
+		staleScore float64
+		freshScore float64
+		wantPod    string
+	}{
+		{
+			// The stale score is roughly halved, which is not enough to make up for the gap.
+			name:       "slightly stale pod is still used when the alternative is worse",
+			staleScore: 1,
+			freshScore: 0.2,
+			wantPod:    "stale",
+		},
+		{
+			name:       "stale pod loses to a comparable fresh pod",
+			staleScore: 1,
+			freshScore: 0.8,
+			wantPod:    "fresh",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := newScheduler(test.staleScore, test.freshScore).Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.TargetPod.GetPod().NamespacedName.Name != test.wantPod {
+				t.Errorf("Unexpected target pod, got %v, want %v", got.TargetPod.GetPod().NamespacedName, test.wantPod)
+			}
+		})
+	}
+}
@@ -17,10 +17,12 @@ limitations under the License.
 package filter
 
 import (
+	"hash/fnv"
 	"math"
 	"math/rand"
 	"time"
 
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
@@ -43,7 +45,11 @@ func (f *baseFilter) Filter(ctx *types.SchedulingContext, pods []types.Pod) []ty
 	loggerTrace := ctx.Logger.V(logutil.TRACE)
 	loggerTrace.Info("Running a filter", "name", f.Name(), "podCount", len(pods))
 
-	return f.filter(ctx, pods)
+	filtered := f.filter(ctx, pods)
+	if !ctx.DryRun {
+		metrics.RecordSchedulerFilterPods(f.Name(), len(pods), len(filtered))
+	}
+	return filtered
 }
 
 // DecisionTreeFilter applies current filterFunc, and then recursively applies next filters
@@ -192,9 +198,22 @@ func leastKVCacheFilterFunc(ctx *types.SchedulingContext, pods []types.Pod) []ty
 	return filtered
 }
 
-var LoRAAffinityFilter = &baseFilter{
-	name:   "affinity LoRA",
-	filter: loRASoftAffinityFilterFunc,
+// LoRAAffinityFilter is the LoRA affinity filter using the static LoraAffinityThreshold.
+var LoRAAffinityFilter = NewLoRAAffinityFilter(nil)
+
+// NewLoRAAffinityFilter returns a LoRA affinity filter using the threshold of the given tuner, or
+// the static LoraAffinityThreshold if the tuner is nil.
+func NewLoRAAffinityFilter(tuner *LoRAAffinityTuner) plugins.Filter {
+	threshold := func() float64 { return config.Conf.LoraAffinityThreshold }
+	if tuner != nil {
+		threshold = tuner.Threshold
+	}
+	return &baseFilter{
+		name: "affinity LoRA",
+		filter: func(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+			return loRASoftAffinityFilterFunc(ctx, pods, threshold())
+		},
+	}
 }
 
 // loRASoftAffinityPredicate implements a pod selection strategy that prioritizes pods
@@ -209,11 +228,12 @@ var LoRAAffinityFilter = &baseFilter{
 //   - logger: Logger interface for diagnostic output
 //   - req: LLM request containing the resolved target model
 //   - pods: Slice of pod metrics to filter
+//   - threshold: Probability of selecting the pods with affinity when both groups have pods
 //
 // Returns:
 //   - Filtered slice of pod metrics based on affinity and availability
 //   - Error if any issues occur during filtering
-func loRASoftAffinityFilterFunc(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+func loRASoftAffinityFilterFunc(ctx *types.SchedulingContext, pods []types.Pod, threshold float64) []types.Pod {
 
 	// Pre-allocate slices with estimated capacity
 	filtered_affinity := make([]types.Pod, 0, len(pods))
@@ -237,7 +257,7 @@ func loRASoftAffinityFilterFunc(ctx *types.SchedulingContext, pods []types.Pod)
 
 	// If both groups have pods, use probability to select which group to return
 	if len(filtered_affinity) > 0 && len(filtered_available) > 0 {
-		if randGen.Float64() < config.Conf.LoraAffinityThreshold {
+		if randGen.Float64() < threshold {
 			return filtered_affinity
 		}
 		return filtered_available
@@ -256,6 +276,179 @@ var HasCapacityFilter = &baseFilter{
 	filter: toFilterFunc(queueThresholdPredicate(config.Conf.QueueThresholdCritical).and(kvCacheThresholdPredicate(config.Conf.KVCacheThreshold))),
 }
 
+// TerminatingPodFilter excludes pods that are being terminated. Such pods may still be in the
+// datastore until they are removed, but new requests routed to them are likely to fail.
+var TerminatingPodFilter = &baseFilter{
+	name:   "exclude terminating pods",
+	filter: toFilterFunc(notTerminatingPredicate),
+}
+
+// NodeDiversityFilter excludes the pods on the nodes previous attempts of the request were sent
+// to, so retries land on different nodes. If all pods are on such nodes, all pods are kept.
+var NodeDiversityFilter = &baseFilter{
+	name:   "prefer untried nodes",
+	filter: nodeDiversityFilterFunc,
+}
+
+func nodeDiversityFilterFunc(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	if len(ctx.Req.PreviousNodes) == 0 {
+		return pods
+	}
+	tried := make(map[string]bool, len(ctx.Req.PreviousNodes))
+	for _, node := range ctx.Req.PreviousNodes {
+		tried[node] = true
+	}
+	filtered := []types.Pod{}
+	for _, pod := range pods {
+		if !tried[pod.GetPod().NodeName] {
+			filtered = append(filtered, pod)
+		}
+	}
+	if len(filtered) == 0 {
+		ctx.Logger.V(logutil.DEBUG).Info("All pods are on previously tried nodes, keeping all of them", "nodes", ctx.Req.PreviousNodes)
+		return pods
+	}
+	return filtered
+}
+
+// UnhealthyPodFilter excludes the pods reported as unhealthy by the scheduling context, e.g. pods
+// that recently failed at the transport level.
+var UnhealthyPodFilter = &baseFilter{
+	name: "exclude unhealthy pods",
+	filter: func(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+		if ctx.PodIsUnhealthy == nil {
+			return pods
+		}
+		filtered := []types.Pod{}
+		for _, pod := range pods {
+			if !ctx.PodIsUnhealthy(pod.GetPod().NamespacedName) {
+				filtered = append(filtered, pod)
+			}
+		}
+		return filtered
+	},
+}
+
+// NewMaintenanceFilter returns a filter that excludes the pods carrying the given annotation, which
+// operators set to stop sending new requests to a pod during maintenance. The pods stay in the
+// datastore. If all pods are in maintenance, all pods are kept rather than failing the request.
+func NewMaintenanceFilter(annotation string) plugins.Filter {
+	return &baseFilter{
+		name: "exclude pods in maintenance",
+		filter: func(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+			filtered := []types.Pod{}
+			for _, pod := range pods {
+				if _, ok := pod.GetPod().Annotations[annotation]; !ok {
+					filtered = append(filtered, pod)
+				}
+			}
+			if len(filtered) == 0 && len(pods) > 0 {
+				ctx.Logger.V(logutil.DEBUG).Info("All pods are in maintenance, keeping all of them", "annotation", annotation)
+				return pods
+			}
+			return filtered
+		},
+	}
+}
+
+// NewPoolSaturationFilter returns a filter that drops sheddable requests when the average KV cache
+// utilization across the whole pool exceeds the threshold, even if some pods still look free. The
+// pool snapshot is used rather than the pods passed in, so it must run before the per-pod filters
+// to admit or shed the request up front.
+func NewPoolSaturationFilter(threshold float64) plugins.Filter {
+	return &baseFilter{
+		name: "pool saturation admission",
+		filter: func(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+			if ctx.Req.Critical {
+				return pods
+			}
+			saturation := poolSaturation(ctx.PodsSnapshot)
+			if saturation <= threshold {
+				return pods
+			}
+			ctx.Logger.V(logutil.DEBUG).Info("Pool is saturated, shedding request", "saturation", saturation, "threshold", threshold)
+			if !ctx.DryRun {
+				metrics.RecordPoolSaturationShed()
+			}
+			return []types.Pod{}
+		},
+	}
+}
+
+// maxKVCacheHeadroom caps the free KV cache required by NewKVCacheHeadroomFilter, so that very
+// large prompts don't require idle pods.
+const maxKVCacheHeadroom = 0.5
+
+// NewKVCacheHeadroomFilter returns a filter requiring more free KV cache for large prompts, so that
+// they aren't routed to pods just below the KV cache threshold, where they would cause eviction
+// churn. Sheddable requests with at least largePromptTokens prompt tokens are only kept on the pods
+// whose free KV cache is at least the standard margin of 1-kvCacheThreshold, increased in
+// proportion to the size of the prompt relative to largePromptTokens, so that a prompt at the
+// threshold requires twice the standard margin. The increase is capped at maxKVCacheHeadroom, but
+// the headroom is never below the standard margin. Smaller and critical requests are left to the
+// standard threshold, so that critical requests aren't rejected for a large prompt.
+func NewKVCacheHeadroomFilter(largePromptTokens int, kvCacheThreshold float64) plugins.Filter {
+	standard := 1 - kvCacheThreshold
+	return &baseFilter{
+		name: "kv cache headroom for large prompts",
+		filter: toFilterFunc(func(req *types.LLMRequest, pod types.Pod) bool {
+			if req.Critical || req.PromptTokens < largePromptTokens {
+				return true
+			}
+			scaled := standard * (1 + float64(req.PromptTokens)/float64(largePromptTokens))
+			headroom := math.Max(standard, math.Min(scaled, maxKVCacheHeadroom))
+			return 1-pod.GetMetrics().KVCacheUsagePercent >= headroom
+		}),
+	}
+}
+
+// poolSaturation returns the average KV cache utilization of the given pods, or 0 if there are none.
+func poolSaturation(pods []types.Pod) float64 {
+	if len(pods) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, pod := range pods {
+		total += pod.GetMetrics().KVCacheUsagePercent
+	}
+	return total / float64(len(pods))
+}
+
+// NewExperimentFilter returns a filter routing the given percentage of the experiment keys to the
+// experiment pods, which have the given label value, and the other requests to the other pods. The
+// group of a request is decided by a stable hash of its experiment key, so all the requests with
+// the same key land in the same group. If no pod of the group is available, all pods are kept.
+func NewExperimentFilter(percentage float64, labelKey, labelValue string) plugins.Filter {
+	return &baseFilter{
+		name: "experiment routing",
+		filter: func(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+			inExperiment := InExperiment(ctx.Req.ExperimentKey, percentage)
+			filtered := []types.Pod{}
+			for _, pod := range pods {
+				if (pod.GetPod().Labels[labelKey] == labelValue) == inExperiment {
+					filtered = append(filtered, pod)
+				}
+			}
+			if len(filtered) == 0 {
+				ctx.Logger.V(logutil.DEBUG).Info("No pod in the experiment group, keeping all pods", "inExperiment", inExperiment)
+				return pods
+			}
+			return filtered
+		},
+	}
+}
+
+// InExperiment returns whether the requests with the given experiment key belong to the
+// experiment group receiving the given percentage of the keys. Empty keys never do.
+func InExperiment(key string, percentage float64) bool {
+	if key == "" {
+		return false
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return float64(h.Sum64()%10000) < percentage*100
+}
+
 // podPredicate is a filter function to check whether a pod is desired.
 type podPredicate func(req *types.LLMRequest, pod types.Pod) bool
 
@@ -271,6 +464,10 @@ func kvCacheThresholdPredicate(kvCacheThreshold float64) podPredicate {
 	}
 }
 
+func notTerminatingPredicate(req *types.LLMRequest, pod types.Pod) bool {
+	return pod.GetPod().DeletionTimestamp.IsZero()
+}
+
 func (pp podPredicate) and(another podPredicate) podPredicate {
 	return func(req *types.LLMRequest, pod types.Pod) bool {
 		return pp(req, pod) && another(req, pod)
@@ -0,0 +1,188 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+)
+
+// NamedFilters maps the names a filter chain config refers to filters by to the filters.
+var NamedFilters = map[string]plugins.Filter{
+	"least-queue":         LeastQueueFilter,
+	"low-queue":           LowQueueFilter,
+	"least-kv-cache":      LeastKVCacheFilter,
+	"lora-affinity":       LoRAAffinityFilter,
+	"has-capacity":        HasCapacityFilter,
+	"exclude-terminating": TerminatingPodFilter,
+	"node-diversity":      NodeDiversityFilter,
+}
+
+// FilterChainConfig describes a DecisionTreeFilter as a set of nodes referring to each other by ID,
+// starting from the Root node.
+type FilterChainConfig struct {
+	Root  string                      `json:"root"`
+	Nodes map[string]FilterNodeConfig `json:"nodes"`
+}
+
+// FilterNodeConfig is a node of a filter chain. Filter is one of the NamedFilters, and the Next
+// fields are the IDs of the nodes applied next, with the semantics of the DecisionTreeFilter
+// fields of the same names. Empty fields end the chain.
+type FilterNodeConfig struct {
+	Filter                 string `json:"filter"`
+	NextOnSuccess          string `json:"nextOnSuccess,omitempty"`
+	NextOnFailure          string `json:"nextOnFailure,omitempty"`
+	NextOnSuccessOrFailure string `json:"nextOnSuccessOrFailure,omitempty"`
+}
+
+// next returns the IDs of the nodes the node leads to.
+func (n FilterNodeConfig) next() []string {
+	var next []string
+	for _, id := range []string{n.NextOnSuccess, n.NextOnFailure, n.NextOnSuccessOrFailure} {
+		if id != "" {
+			next = append(next, id)
+		}
+	}
+	return next
+}
+
+// ValidateFilterConfig checks a filter chain config before it is deployed. It reports all the
+// unknown filter names, references to missing nodes, unreachable nodes and branches, and cycles.
+func ValidateFilterConfig(cfg FilterChainConfig) error {
+	var errs []error
+	ids := make([]string, 0, len(cfg.Nodes))
+	for id := range cfg.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		node := cfg.Nodes[id]
+		if _, ok := NamedFilters[node.Filter]; !ok {
+			errs = append(errs, fmt.Errorf("node %q: unknown filter %q", id, node.Filter))
+		}
+		for _, next := range node.next() {
+			if _, ok := cfg.Nodes[next]; !ok {
+				errs = append(errs, fmt.Errorf("node %q: next node %q does not exist", id, next))
+			}
+		}
+		if node.NextOnSuccessOrFailure != "" && node.NextOnSuccess != "" && node.NextOnFailure != "" {
+			errs = append(errs, fmt.Errorf("node %q: next node %q is unreachable, as both the success and failure branches are set",
+				id, node.NextOnSuccessOrFailure))
+		}
+	}
+
+	if _, ok := cfg.Nodes[cfg.Root]; !ok {
+		return errors.Join(append(errs, fmt.Errorf("root node %q does not exist", cfg.Root))...)
+	}
+	reachable := map[string]bool{}
+	var visit func(id string)
+	visit = func(id string) {
+		if _, ok := cfg.Nodes[id]; !ok || reachable[id] {
+			return
+		}
+		reachable[id] = true
+		for _, next := range cfg.Nodes[id].next() {
+			visit(next)
+		}
+	}
+	visit(cfg.Root)
+	for _, id := range ids {
+		if !reachable[id] {
+			errs = append(errs, fmt.Errorf("node %q is unreachable from the root node %q", id, cfg.Root))
+		}
+	}
+
+	if cycle := findCycle(cfg, ids); cycle != nil {
+		errs = append(errs, fmt.Errorf("cycle between the nodes %v", cycle))
+	}
+	return errors.Join(errs...)
+}
+
+// findCycle returns the IDs of the nodes forming a cycle, or nil if there is none.
+func findCycle(cfg FilterChainConfig, ids []string) []string {
+	const (
+		unvisited = iota
+		inPath
+		done
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		if _, ok := cfg.Nodes[id]; !ok {
+			return nil
+		}
+		switch state[id] {
+		case inPath:
+			for i, pathID := range path {
+				if pathID == id {
+					return append(append([]string{}, path[i:]...), id)
+				}
+			}
+		case done:
+			return nil
+		}
+		state[id] = inPath
+		path = append(path, id)
+		for _, next := range cfg.Nodes[id].next() {
+			if cycle := visit(next); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+		return nil
+	}
+	for _, id := range ids {
+		if cycle := visit(id); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// NewFilterFromConfig validates a filter chain config and builds the DecisionTreeFilter it
+// describes.
+func NewFilterFromConfig(cfg FilterChainConfig) (*DecisionTreeFilter, error) {
+	if err := ValidateFilterConfig(cfg); err != nil {
+		return nil, err
+	}
+	built := map[string]*DecisionTreeFilter{}
+	var build func(id string) plugins.Filter
+	build = func(id string) plugins.Filter {
+		if id == "" {
+			return nil
+		}
+		if f, ok := built[id]; ok {
+			return f
+		}
+		node := cfg.Nodes[id]
+		f := &DecisionTreeFilter{
+			Current:                NamedFilters[node.Filter],
+			NextOnSuccess:          build(node.NextOnSuccess),
+			NextOnFailure:          build(node.NextOnFailure),
+			NextOnSuccessOrFailure: build(node.NextOnSuccessOrFailure),
+		}
+		built[id] = f
+		return f
+	}
+	return build(cfg.Root).(*DecisionTreeFilter), nil
+}
@@ -18,9 +18,14 @@ package filter
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
@@ -183,6 +188,65 @@ func TestFilterFunc(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "exclude terminating pods",
+			f:    toFilterFunc(notTerminatingPredicate),
+			input: []types.Pod{
+				&types.PodMetrics{
+					Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "running"}},
+				},
+				&types.PodMetrics{
+					Pod: &backendmetrics.Pod{
+						NamespacedName:    k8stypes.NamespacedName{Name: "terminating"},
+						DeletionTimestamp: &metav1.Time{Time: time.Unix(1000, 0)},
+					},
+				},
+			},
+			output: []types.Pod{
+				&types.PodMetrics{
+					Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "running"}},
+				},
+			},
+		},
+		{
+			name: "retry prefers pods on untried nodes",
+			f:    nodeDiversityFilterFunc,
+			req:  &types.LLMRequest{PreviousNodes: []string{"node1"}},
+			input: []types.Pod{
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}, NodeName: "node1"}},
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}, NodeName: "node1"}},
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}, NodeName: "node2"}},
+			},
+			output: []types.Pod{
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}, NodeName: "node2"}},
+			},
+		},
+		{
+			name: "retry keeps all pods when only tried nodes are available",
+			f:    nodeDiversityFilterFunc,
+			req:  &types.LLMRequest{PreviousNodes: []string{"node1"}},
+			input: []types.Pod{
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}, NodeName: "node1"}},
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}, NodeName: "node1"}},
+			},
+			output: []types.Pod{
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}, NodeName: "node1"}},
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}, NodeName: "node1"}},
+			},
+		},
+		{
+			name: "first attempt keeps all pods",
+			f:    nodeDiversityFilterFunc,
+			req:  &types.LLMRequest{},
+			input: []types.Pod{
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}, NodeName: "node1"}},
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}, NodeName: "node2"}},
+			},
+			output: []types.Pod{
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}, NodeName: "node1"}},
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}, NodeName: "node2"}},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -256,7 +320,7 @@ func TestLoRASoftAffinityDistribution(t *testing.T) {
 	expectedAvailabilityPercent := 100 - expectedAffinityPercent
 
 	for i := 0; i < numIterations; i++ {
-		result := loRASoftAffinityFilterFunc(ctx, pods)
+		result := loRASoftAffinityFilterFunc(ctx, pods, config.Conf.LoraAffinityThreshold)
 
 		// Check which type of pod was returned
 		if len(result) != 1 {
@@ -297,3 +361,590 @@ func TestLoRASoftAffinityDistribution(t *testing.T) {
 			actualAvailablePercent, availableLowerBound, availableUpperBound)
 	}
 }
+
+func TestInExperiment(t *testing.T) {
+	const keys = 20000
+	for _, percentage := range []float64{0, 5, 50, 100} {
+		inExperiment := 0
+		for i := 0; i < keys; i++ {
+			if InExperiment(fmt.Sprintf("session-%d", i), percentage) {
+				inExperiment++
+			}
+		}
+		got := float64(inExperiment) * 100 / keys
+		if math.Abs(got-percentage) > 1 {
+			t.Errorf("Unexpected share of keys in the experiment, got %.2f%%, want %v%%", got, percentage)
+		}
+	}
+
+	if InExperiment("", 100) {
+		t.Error("Expected requests without a key to never be in the experiment")
+	}
+}
+
+func TestExperimentFilter(t *testing.T) {
+	experimentPod := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "experiment"}, Labels: map[string]string{"track": "canary"}},
+		Metrics: &backendmetrics.Metrics{},
+	}
+	controlPod := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "control"}, Labels: map[string]string{"track": "stable"}},
+		Metrics: &backendmetrics.Metrics{},
+	}
+	filter := NewExperimentFilter(5, "track", "canary")
+	schedule := func(key string, pods []types.Pod) []types.Pod {
+		ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{ExperimentKey: key}, pods)
+		return filter.Filter(ctx, pods)
+	}
+
+	// The requests of a session consistently land in the same group.
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("session-%d", i)
+		want := controlPod
+		if InExperiment(key, 5) {
+			want = experimentPod
+		}
+		for j := 0; j < 3; j++ {
+			got := schedule(key, []types.Pod{experimentPod, controlPod})
+			if len(got) != 1 || got[0] != want {
+				t.Fatalf("Unexpected pods for key %q, got %v, want %v", key, got, want)
+			}
+		}
+	}
+
+	// Requests without a key are not part of the experiment.
+	if got := schedule("", []types.Pod{experimentPod, controlPod}); len(got) != 1 || got[0] != controlPod {
+		t.Errorf("Unexpected pods for a request without a key, got %v", got)
+	}
+	// If no pod of the group is available, all pods are kept.
+	if got := schedule("", []types.Pod{experimentPod}); len(got) != 1 || got[0] != experimentPod {
+		t.Errorf("Unexpected pods when only experiment pods are available, got %v", got)
+	}
+}
+
+func TestMaintenanceFilter(t *testing.T) {
+	const annotation = "example.com/maintenance"
+	inMaintenance := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "maintenance"}, Annotations: map[string]string{annotation: "true"}},
+		Metrics: &backendmetrics.Metrics{},
+	}
+	serving := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "serving"}, Annotations: map[string]string{"other": "true"}},
+		Metrics: &backendmetrics.Metrics{},
+	}
+	tests := []struct {
+		name string
+		pods []types.Pod
+		want []types.Pod
+	}{
+		{
+			name: "pod in maintenance is excluded",
+			pods: []types.Pod{inMaintenance, serving},
+			want: []types.Pod{serving},
+		},
+		{
+			name: "all pods are kept if all are in maintenance",
+			pods: []types.Pod{inMaintenance},
+			want: []types.Pod{inMaintenance},
+		},
+		{
+			name: "no pods",
+			pods: []types.Pod{},
+			want: []types.Pod{},
+		},
+	}
+	filter := NewMaintenanceFilter(annotation)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, test.pods)
+			got := filter.Filter(ctx, test.pods)
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(types.PodMetrics{})); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestTenantFairnessFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		// kvCacheUsage is the KV cache utilization of the single pod of the pool.
+		kvCacheUsage   float64
+		shares         map[string]float64
+		heavyPerRound  int
+		heavyCritical  bool
+		wantHeavyRatio float64
+	}{
+		{
+			name:           "heavy tenant throttled to an equal share",
+			kvCacheUsage:   0.9,
+			heavyPerRound:  3,
+			wantHeavyRatio: 1,
+		},
+		{
+			name:           "heavy tenant throttled to its weighted share",
+			kvCacheUsage:   0.9,
+			shares:         map[string]float64{"heavy": 3},
+			heavyPerRound:  5,
+			wantHeavyRatio: 3,
+		},
+		{
+			name:           "heavy tenant within its weighted share",
+			kvCacheUsage:   0.9,
+			shares:         map[string]float64{"heavy": 3},
+			heavyPerRound:  3,
+			wantHeavyRatio: 3,
+		},
+		{
+			name:           "pool not saturated",
+			kvCacheUsage:   0.5,
+			heavyPerRound:  3,
+			wantHeavyRatio: 3,
+		},
+		{
+			name:           "critical requests bypass fairness",
+			kvCacheUsage:   0.9,
+			heavyPerRound:  3,
+			heavyCritical:  true,
+			wantHeavyRatio: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pods := []types.Pod{&types.PodMetrics{
+				Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod"}},
+				Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: test.kvCacheUsage},
+			}}
+			// The clock is frozen so the usage doesn't decay.
+			now := time.Now()
+			filter := newTenantFairnessFilter(0.8, time.Minute, test.shares, func() time.Time { return now })
+			admitted := map[string]int{}
+			schedule := func(tenant string, critical bool) {
+				ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{TenantID: tenant, Critical: critical}, pods)
+				if len(filter.Filter(ctx, pods)) > 0 {
+					filter.PostSchedule(ctx, &types.Result{TargetPod: pods[0]})
+					admitted[tenant]++
+				}
+			}
+
+			const rounds = 100
+			for i := 0; i < rounds; i++ {
+				for j := 0; j < test.heavyPerRound; j++ {
+					schedule("heavy", test.heavyCritical)
+				}
+				schedule("light", false)
+			}
+
+			// The light tenant uses less than its share, so it is never throttled.
+			if admitted["light"] != rounds {
+				t.Errorf("Unexpected admitted requests for the light tenant, got %d, want %d", admitted["light"], rounds)
+			}
+			if want := test.wantHeavyRatio * rounds; math.Abs(float64(admitted["heavy"])-want) > 3 {
+				t.Errorf("Unexpected admitted requests for the heavy tenant, got %d, want %v", admitted["heavy"], want)
+			}
+		})
+	}
+}
+
+func TestTenantFairnessFilterForgetsIdleTenants(t *testing.T) {
+	pods := []types.Pod{&types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod"}},
+		Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.9},
+	}}
+	now := time.Now()
+	filter := newTenantFairnessFilter(0.8, time.Minute, nil, func() time.Time { return now })
+	admit := func(tenant string) bool {
+		ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{TenantID: tenant}, pods)
+		if len(filter.Filter(ctx, pods)) == 0 {
+			return false
+		}
+		filter.PostSchedule(ctx, &types.Result{TargetPod: pods[0]})
+		return true
+	}
+	for i := 0; i < 10; i++ {
+		admit("other")
+		admit("heavy")
+	}
+	throttled := false
+	for i := 0; i < 3; i++ {
+		throttled = throttled || !admit("heavy")
+	}
+	if !throttled {
+		t.Fatal("Expected the heavy tenant to be throttled while the other tenant is active")
+	}
+	// Once the other tenant has been idle for long enough, the heavy tenant has the pool to itself.
+	now = now.Add(10 * time.Minute)
+	for i := 0; i < 10; i++ {
+		if !admit("heavy") {
+			t.Fatalf("Expected the heavy tenant to be admitted once alone, request %d", i)
+		}
+	}
+}
+
+func TestTenantFairnessFilterDryRun(t *testing.T) {
+	pods := []types.Pod{&types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod"}},
+		Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.9},
+	}}
+	now := time.Now()
+	admit := func(filter *TenantFairnessFilter, tenant string, dryRun bool) bool {
+		ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{TenantID: tenant}, pods)
+		ctx.DryRun = dryRun
+		if len(filter.Filter(ctx, pods)) == 0 {
+			return false
+		}
+		filter.PostSchedule(ctx, &types.Result{TargetPod: pods[0]})
+		return true
+	}
+	// Both filters see the same requests, but the dry runs must not affect the second one.
+	filter := newTenantFairnessFilter(0.8, time.Minute, nil, func() time.Time { return now })
+	dryRunFilter := newTenantFairnessFilter(0.8, time.Minute, nil, func() time.Time { return now })
+	for i := 0; i < 20; i++ {
+		tenant := "heavy"
+		if i%4 == 0 {
+			tenant = "other"
+		}
+		want := admit(filter, tenant, false)
+		for j := 0; j < 10; j++ {
+			if got := admit(dryRunFilter, "heavy", true); got != admit(dryRunFilter, "heavy", true) {
+				t.Fatalf("Expected repeated dry runs to agree, request %d", i)
+			}
+		}
+		if got := admit(dryRunFilter, tenant, false); got != want {
+			t.Fatalf("Dry runs changed the admission of request %d, got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestTenantFairnessFilterCountsPickedRequests(t *testing.T) {
+	pods := []types.Pod{&types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod"}},
+		Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.9},
+	}}
+	now := time.Now()
+	filter := newTenantFairnessFilter(0.8, time.Minute, nil, func() time.Time { return now })
+	// admit filters a request of the tenant, and picks a pod for it if picked is set.
+	admit := func(tenant string, picked bool) bool {
+		ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{TenantID: tenant}, pods)
+		if len(filter.Filter(ctx, pods)) == 0 {
+			return false
+		}
+		if picked {
+			filter.PostSchedule(ctx, &types.Result{TargetPod: pods[0]})
+		}
+		return true
+	}
+	for i := 0; i < 10; i++ {
+		admit("other", true)
+	}
+	// The requests of heavy pass the filter, but are rejected later on, so they don't count
+	// against its share.
+	for i := 0; i < 20; i++ {
+		admit("heavy", false)
+	}
+	for i := 0; i < 5; i++ {
+		if !admit("heavy", true) {
+			t.Fatalf("Expected the heavy tenant to be admitted within its share, request %d", i)
+		}
+	}
+}
+
+func TestTenantFairnessFilterKeepsTenantsWithFewRequests(t *testing.T) {
+	pods := []types.Pod{&types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod"}},
+		Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.9},
+	}}
+	now := time.Now()
+	filter := newTenantFairnessFilter(0.8, time.Minute, nil, func() time.Time { return now })
+	admit := func(tenant string) bool {
+		ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{TenantID: tenant}, pods)
+		if len(filter.Filter(ctx, pods)) == 0 {
+			return false
+		}
+		filter.PostSchedule(ctx, &types.Result{TargetPod: pods[0]})
+		return true
+	}
+	admit("light")
+	// A tenant that sent a single request still counts as active a moment later, so the heavy
+	// tenant doesn't get the pool to itself.
+	now = now.Add(time.Second)
+	throttled := false
+	for i := 0; i < 10; i++ {
+		throttled = throttled || !admit("heavy")
+	}
+	if !throttled {
+		t.Error("Expected the heavy tenant to be throttled while the light tenant is active")
+	}
+}
+
+func TestValidateFilterConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      FilterChainConfig
+		wantErrs []string
+	}{
+		{
+			name: "valid chain",
+			cfg: FilterChainConfig{
+				Root: "queue",
+				Nodes: map[string]FilterNodeConfig{
+					"queue":    {Filter: "low-queue", NextOnSuccess: "lora", NextOnFailure: "fallback"},
+					"lora":     {Filter: "lora-affinity", NextOnSuccessOrFailure: "kv-cache"},
+					"fallback": {Filter: "least-queue", NextOnSuccessOrFailure: "kv-cache"},
+					"kv-cache": {Filter: "least-kv-cache"},
+				},
+			},
+		},
+		{
+			name: "unknown filter",
+			cfg: FilterChainConfig{
+				Root:  "queue",
+				Nodes: map[string]FilterNodeConfig{"queue": {Filter: "lowest-queue"}},
+			},
+			wantErrs: []string{`node "queue": unknown filter "lowest-queue"`},
+		},
+		{
+			name: "missing root and next nodes",
+			cfg: FilterChainConfig{
+				Root:  "start",
+				Nodes: map[string]FilterNodeConfig{"queue": {Filter: "low-queue", NextOnSuccess: "lora"}},
+			},
+			wantErrs: []string{`node "queue": next node "lora" does not exist`, `root node "start" does not exist`},
+		},
+		{
+			name: "unreachable node and branch",
+			cfg: FilterChainConfig{
+				Root: "queue",
+				Nodes: map[string]FilterNodeConfig{
+					"queue":  {Filter: "low-queue", NextOnSuccess: "lora", NextOnFailure: "lora", NextOnSuccessOrFailure: "kv"},
+					"lora":   {Filter: "lora-affinity"},
+					"kv":     {Filter: "least-kv-cache"},
+					"orphan": {Filter: "least-queue"},
+				},
+			},
+			wantErrs: []string{
+				`node "queue": next node "kv" is unreachable, as both the success and failure branches are set`,
+				`node "orphan" is unreachable from the root node "queue"`,
+			},
+		},
+		{
+			name: "cycle",
+			cfg: FilterChainConfig{
+				Root: "queue",
+				Nodes: map[string]FilterNodeConfig{
+					"queue": {Filter: "low-queue", NextOnSuccess: "lora"},
+					"lora":  {Filter: "lora-affinity", NextOnFailure: "queue"},
+				},
+			},
+			wantErrs: []string{"cycle between the nodes [lora queue lora]"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateFilterConfig(test.cfg)
+			if len(test.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected errors %v, got none", test.wantErrs)
+			}
+			if diff := cmp.Diff(strings.Join(test.wantErrs, "\n"), err.Error()); diff != "" {
+				t.Errorf("Unexpected errors (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestNewFilterFromConfig(t *testing.T) {
+	f, err := NewFilterFromConfig(FilterChainConfig{
+		Root: "queue",
+		Nodes: map[string]FilterNodeConfig{
+			"queue":    {Filter: "least-queue", NextOnSuccessOrFailure: "kv-cache"},
+			"kv-cache": {Filter: "least-kv-cache"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	pods := []types.Pod{
+		&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 0, KVCacheUsagePercent: 0.8}},
+		&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 0, KVCacheUsagePercent: 0.1}},
+		&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 10, KVCacheUsagePercent: 0}},
+	}
+	ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, pods)
+	got := f.Filter(ctx, pods)
+	if len(got) != 1 || got[0].GetPod().NamespacedName.Name != "pod2" {
+		t.Errorf("Expected the chain to keep pod2, got %v", got)
+	}
+
+	if _, err := NewFilterFromConfig(FilterChainConfig{Root: "missing"}); err == nil {
+		t.Errorf("Expected an error building an invalid chain")
+	}
+}
+
+func TestLoRAAffinityTuner(t *testing.T) {
+	pod1 := &backendmetrics.FakePodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}},
+		Metrics: &backendmetrics.Metrics{},
+	}
+	pod2 := &backendmetrics.FakePodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}},
+		Metrics: &backendmetrics.Metrics{},
+	}
+	tuner := NewLoRAAffinityTuner(0.99, 0.9, 1, 100*time.Millisecond, time.Second,
+		func() []backendmetrics.PodMetrics { return []backendmetrics.PodMetrics{pod1, pod2} })
+	// swap adds count swaps of the given latency to the totals of the pod.
+	swap := func(pod *backendmetrics.FakePodMetrics, count uint64, latency time.Duration) {
+		pod.Metrics = &backendmetrics.Metrics{
+			LoraSwapSecondsTotal: pod.Metrics.LoraSwapSecondsTotal + float64(count)*latency.Seconds(),
+			LoraSwapCount:        pod.Metrics.LoraSwapCount + count,
+		}
+	}
+
+	steps := []struct {
+		name          string
+		swap          func()
+		wantThreshold float64
+	}{
+		{
+			// The first refresh records the totals of the pods the swaps are measured from.
+			name:          "initial totals",
+			swap:          func() { swap(pod1, 10, 5*time.Second) },
+			wantThreshold: 0.99,
+		},
+		{
+			name:          "expensive swaps",
+			swap:          func() { swap(pod1, 2, 2*time.Second); swap(pod2, 1, 3*time.Second) },
+			wantThreshold: 1,
+		},
+		{
+			name:          "no swaps",
+			swap:          func() {},
+			wantThreshold: 1,
+		},
+		{
+			name:          "cheap swaps",
+			swap:          func() { swap(pod2, 4, 50*time.Millisecond) },
+			wantThreshold: 0.9,
+		},
+		{
+			// The average latency is halfway between the cheap and the expensive latencies.
+			name:          "moderate swaps",
+			swap:          func() { swap(pod1, 1, 300*time.Millisecond); swap(pod2, 1, 800*time.Millisecond) },
+			wantThreshold: 0.95,
+		},
+		{
+			// The restarted pod is skipped, as its totals went down.
+			name: "pod restart",
+			swap: func() {
+				pod1.Metrics = &backendmetrics.Metrics{LoraSwapSecondsTotal: 10, LoraSwapCount: 1}
+				swap(pod2, 1, 50*time.Millisecond)
+			},
+			wantThreshold: 0.9,
+		},
+	}
+	for _, step := range steps {
+		step.swap()
+		tuner.refresh(context.Background())
+		if got := tuner.Threshold(); math.Abs(got-step.wantThreshold) > 1e-9 {
+			t.Errorf("Unexpected threshold after %s, got %v, want %v", step.name, got, step.wantThreshold)
+		}
+	}
+}
+
+func TestLoRAAffinityFilterWithTuner(t *testing.T) {
+	pods := []types.Pod{
+		&types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "affinity"}},
+			Metrics: &backendmetrics.Metrics{ActiveModels: map[string]int{"adapter": 1}, MaxActiveModels: 2},
+		},
+		&types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "available"}},
+			Metrics: &backendmetrics.Metrics{ActiveModels: map[string]int{}, MaxActiveModels: 2},
+		},
+	}
+	ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{ResolvedTargetModel: "adapter"}, pods)
+	noPods := func() []backendmetrics.PodMetrics { return nil }
+	// Each filter only follows the threshold of its own tuner.
+	sticky := NewLoRAAffinityFilter(NewLoRAAffinityTuner(1, 0, 1, time.Millisecond, time.Second, noPods))
+	spread := NewLoRAAffinityFilter(NewLoRAAffinityTuner(0, 0, 1, time.Millisecond, time.Second, noPods))
+	for i := 0; i < 100; i++ {
+		if got := sticky.Filter(ctx, pods); len(got) != 1 || got[0].GetPod().NamespacedName.Name != "affinity" {
+			t.Fatalf("Expected the pod with the adapter at a threshold of 1, got %v", got)
+		}
+		if got := spread.Filter(ctx, pods); len(got) != 1 || got[0].GetPod().NamespacedName.Name != "available" {
+			t.Fatalf("Expected the pod without the adapter at a threshold of 0, got %v", got)
+		}
+	}
+}
+
+func TestKVCacheHeadroomFilter(t *testing.T) {
+	newPod := func(name string, kvCacheUsage float64) types.Pod {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}},
+			Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: kvCacheUsage},
+		}
+	}
+	free := newPod("free", 0)
+	half := newPod("half", 0.5)
+	marginal := newPod("marginal", 0.75)
+	pods := []types.Pod{free, half, marginal}
+	tests := []struct {
+		name string
+		// kvCacheThreshold defaults to 0.8, i.e. a standard margin of 0.2.
+		kvCacheThreshold float64
+		promptTokens     int
+		critical         bool
+		want             []types.Pod
+	}{
+		{
+			name:         "small request accepts marginally free pods",
+			promptTokens: 100,
+			want:         []types.Pod{free, half, marginal},
+		},
+		{
+			name:         "request at the size threshold needs more than the standard margin",
+			promptTokens: 1000,
+			want:         []types.Pod{free, half},
+		},
+		{
+			name:         "large request excludes marginally free pods",
+			promptTokens: 2000,
+			want:         []types.Pod{free, half},
+		},
+		{
+			name:         "headroom is capped",
+			promptTokens: 100000,
+			want:         []types.Pod{free, half},
+		},
+		{
+			name:         "critical request is left to the standard threshold",
+			promptTokens: 100000,
+			critical:     true,
+			want:         []types.Pod{free, half, marginal},
+		},
+		{
+			name:             "capped headroom doesn't go below a standard margin above the cap",
+			kvCacheThreshold: 0.3,
+			promptTokens:     100000,
+			want:             []types.Pod{free},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			threshold := test.kvCacheThreshold
+			if threshold == 0 {
+				threshold = 0.8
+			}
+			filter := NewKVCacheHeadroomFilter(1000, threshold)
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{PromptTokens: test.promptTokens, Critical: test.critical}, pods)
+			got := filter.Filter(ctx, pods)
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(types.PodMetrics{})); diff != "" {
+				t.Errorf("Unexpected output (-want +got): %v", diff)
+			}
+		})
+	}
+}
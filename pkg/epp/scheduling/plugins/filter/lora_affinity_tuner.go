@@ -0,0 +1,133 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// NewLoRAAffinityTuner returns a tuner adjusting the LoRA affinity threshold to the adapter swap
+// latency observed on the pods returned by pods, starting from the initial threshold. When swaps
+// are cheap, at most cheapSwap on average, the threshold is relaxed to minThreshold to spread the
+// requests of an adapter. When they are expensive, at least expensiveSwap, it is raised to
+// maxThreshold so requests stick to the pods that already have the adapter. In between, the
+// threshold is interpolated linearly.
+func NewLoRAAffinityTuner(initial, minThreshold, maxThreshold float64, cheapSwap, expensiveSwap time.Duration,
+	pods func() []backendmetrics.PodMetrics) *LoRAAffinityTuner {
+	t := &LoRAAffinityTuner{
+		minThreshold:  minThreshold,
+		maxThreshold:  maxThreshold,
+		cheapSwap:     cheapSwap,
+		expensiveSwap: expensiveSwap,
+		pods:          pods,
+		swaps:         make(map[k8stypes.NamespacedName]swapTotals),
+	}
+	t.threshold.Store(math.Float64bits(initial))
+	return t
+}
+
+// LoRAAffinityTuner tunes the LoRA affinity threshold from the LoraSwapSecondsTotal and
+// LoraSwapCount metrics of the pods, for the filters built with NewLoRAAffinityFilter. The threshold only changes when swaps were observed since
+// the previous refresh.
+type LoRAAffinityTuner struct {
+	minThreshold  float64
+	maxThreshold  float64
+	cheapSwap     time.Duration
+	expensiveSwap time.Duration
+	pods          func() []backendmetrics.PodMetrics
+
+	// threshold holds the bits of the current float64 threshold.
+	threshold atomic.Uint64
+	// key: pod NamespacedName, value: the swap totals of the pod at the previous refresh. Only
+	// accessed by refresh.
+	swaps map[k8stypes.NamespacedName]swapTotals
+}
+
+type swapTotals struct {
+	seconds float64
+	count   uint64
+}
+
+// Threshold returns the current LoRA affinity threshold.
+func (t *LoRAAffinityTuner) Threshold() float64 {
+	return math.Float64frombits(t.threshold.Load())
+}
+
+// Run refreshes the threshold every interval until the context is done.
+func (t *LoRAAffinityTuner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		t.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh sets the threshold from the average latency of the swaps observed across the pods since
+// the previous refresh. Pods whose totals went down, e.g. because they restarted, are skipped
+// until the next refresh.
+func (t *LoRAAffinityTuner) refresh(ctx context.Context) {
+	pods := t.pods()
+	swaps := make(map[k8stypes.NamespacedName]swapTotals, len(pods))
+	var seconds float64
+	var count uint64
+	for _, pod := range pods {
+		m := pod.GetMetrics()
+		if m == nil {
+			continue
+		}
+		name := pod.GetPod().NamespacedName
+		current := swapTotals{seconds: m.LoraSwapSecondsTotal, count: m.LoraSwapCount}
+		swaps[name] = current
+		prev, ok := t.swaps[name]
+		if !ok || current.count < prev.count || current.seconds < prev.seconds {
+			continue
+		}
+		seconds += current.seconds - prev.seconds
+		count += current.count - prev.count
+	}
+	t.swaps = swaps
+	if count == 0 {
+		return
+	}
+
+	latency := time.Duration(seconds / float64(count) * float64(time.Second))
+	threshold := t.minThreshold
+	switch {
+	case latency >= t.expensiveSwap:
+		threshold = t.maxThreshold
+	case latency > t.cheapSwap:
+		threshold += (t.maxThreshold - t.minThreshold) * float64(latency-t.cheapSwap) / float64(t.expensiveSwap-t.cheapSwap)
+	}
+	if threshold != t.Threshold() {
+		log.FromContext(ctx).V(logutil.DEBUG).Info("Tuned the LoRA affinity threshold",
+			"swapLatency", latency, "swaps", count, "threshold", threshold)
+	}
+	t.threshold.Store(math.Float64bits(threshold))
+}
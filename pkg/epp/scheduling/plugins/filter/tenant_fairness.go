@@ -0,0 +1,167 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// minTenantDemand is the decayed demand under which a tenant is forgotten and no longer counts as
+// active. A single request decays below it after about 2.3 windows.
+const minTenantDemand = 0.1
+
+// NewTenantFairnessFilter returns a filter that shares the pool fairly between tenants when the
+// average KV cache utilization across the pool exceeds the threshold. It tracks the sheddable
+// requests of each tenant, exponentially decayed over the window, and drops the sheddable requests
+// of tenants that were admitted more than their share of the recent requests among the active
+// tenants, so the tenants using the most beyond their share are shed first and the tenants using
+// less than their share are never throttled. Unlisted tenants, including requests without a
+// tenant, have a share of 1. Critical requests bypass the filter. Like the pool saturation filter,
+// it must run before the per-pod filters. The filter must also run as a post-schedule plugin, since
+// a request only counts as admitted once a pod is picked for it.
+func NewTenantFairnessFilter(threshold float64, window time.Duration, shares map[string]float64) *TenantFairnessFilter {
+	return newTenantFairnessFilter(threshold, window, shares, time.Now)
+}
+
+func newTenantFairnessFilter(threshold float64, window time.Duration, shares map[string]float64, now func() time.Time) *TenantFairnessFilter {
+	f := &TenantFairnessFilter{
+		threshold: threshold,
+		window:    window,
+		shares:    shares,
+		now:       now,
+		tenants:   make(map[string]*tenantUsage),
+	}
+	f.baseFilter = &baseFilter{
+		name:   "tenant fairness admission",
+		filter: f.filter,
+	}
+	return f
+}
+
+// TenantFairnessFilter is the filter returned by NewTenantFairnessFilter.
+type TenantFairnessFilter struct {
+	*baseFilter
+	threshold float64
+	window    time.Duration
+	shares    map[string]float64
+	now       func() time.Time
+
+	mu sync.Mutex
+	// key: the tenant ID, value: the recent usage of the tenant
+	tenants map[string]*tenantUsage
+}
+
+// tenantUsage holds the exponentially decayed number of sheddable requests of a tenant.
+type tenantUsage struct {
+	demand   float64
+	admitted float64
+	updated  time.Time
+}
+
+func (f *TenantFairnessFilter) filter(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	if ctx.Req.Critical {
+		return pods
+	}
+	tenant := ctx.Req.TenantID
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tenants := f.tenants
+	if ctx.DryRun {
+		// The decision is made on a copy of the usage, so that it isn't recorded.
+		tenants = make(map[string]*tenantUsage, len(f.tenants))
+		for name, usage := range f.tenants {
+			copied := *usage
+			tenants[name] = &copied
+		}
+	}
+	now := f.now()
+	f.decay(tenants, now)
+	usage, ok := tenants[tenant]
+	if !ok {
+		usage = &tenantUsage{updated: now}
+		tenants[tenant] = usage
+	}
+	usage.demand++
+
+	if saturation := poolSaturation(ctx.PodsSnapshot); saturation > f.threshold {
+		// One request of slack keeps tenants with equal shares from shedding each other in turns.
+		if allocation := f.allocation(tenants, tenant); usage.admitted > allocation {
+			ctx.Logger.V(logutil.DEBUG).Info("Tenant is above its fair share, shedding request",
+				"tenant", tenant, "admitted", usage.admitted, "allocation", allocation, "saturation", saturation)
+			if !ctx.DryRun {
+				metrics.RecordTenantFairnessShed()
+			}
+			return []types.Pod{}
+		}
+	}
+	return pods
+}
+
+// PostSchedule counts the request as admitted for its tenant once a pod is picked for it.
+func (f *TenantFairnessFilter) PostSchedule(ctx *types.SchedulingContext, res *types.Result) {
+	if ctx.Req.Critical || ctx.DryRun || res == nil || res.TargetPod == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	f.decay(f.tenants, now)
+	usage, ok := f.tenants[ctx.Req.TenantID]
+	if !ok {
+		usage = &tenantUsage{demand: 1, updated: now}
+		f.tenants[ctx.Req.TenantID] = usage
+	}
+	usage.admitted++
+}
+
+// decay decays the usage of the given tenants to the given time, forgetting the idle ones.
+func (f *TenantFairnessFilter) decay(tenants map[string]*tenantUsage, now time.Time) {
+	for tenant, usage := range tenants {
+		factor := math.Exp(-float64(now.Sub(usage.updated)) / float64(f.window))
+		usage.demand *= factor
+		usage.admitted *= factor
+		usage.updated = now
+		if usage.demand < minTenantDemand {
+			delete(tenants, tenant)
+		}
+	}
+}
+
+// allocation returns the number of recently admitted requests, including the one being admitted,
+// that the given tenant is entitled to, in proportion to its share among the active tenants.
+func (f *TenantFairnessFilter) allocation(tenants map[string]*tenantUsage, tenant string) float64 {
+	capacity, totalShare := 1.0, 0.0
+	for name, usage := range tenants {
+		capacity += usage.admitted
+		totalShare += f.share(name)
+	}
+	return capacity * f.share(tenant) / totalShare
+}
+
+func (f *TenantFairnessFilter) share(tenant string) float64 {
+	if share, ok := f.shares[tenant]; ok && share > 0 {
+		return share
+	}
+	return 1
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker
+
+import (
+	"fmt"
+	"math/rand"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// CapacityPicker picks pods randomly, proportionally to their remaining KV cache capacity, so
+// bigger pods take more traffic in heterogeneous pools. Pods with an unknown capacity are assumed
+// to have the average remaining capacity of the other pods. If no pod reports a capacity, or no
+// capacity is left, the pod is picked uniformly.
+type CapacityPicker struct{}
+
+func (p *CapacityPicker) Name() string {
+	return "capacity"
+}
+
+func (p *CapacityPicker) Pick(ctx *types.SchedulingContext, pods []types.Pod) *types.Result {
+	ctx.Logger.V(logutil.DEBUG).Info(fmt.Sprintf("Selecting a pod by remaining capacity from %d candidates: %+v", len(pods), pods))
+	if len(pods) == 0 {
+		return nil
+	}
+
+	remaining := make([]float64, len(pods))
+	known, knownTotal := 0, 0.0
+	for i, pod := range pods {
+		metrics := pod.GetMetrics()
+		if metrics.KvCacheMaxTokenCapacity <= 0 {
+			remaining[i] = -1
+			continue
+		}
+		remaining[i] = max(float64(metrics.KvCacheMaxTokenCapacity)*(1-metrics.KVCacheUsagePercent), 0)
+		known++
+		knownTotal += remaining[i]
+	}
+
+	total := 0.0
+	for i := range remaining {
+		if remaining[i] < 0 {
+			if known > 0 {
+				remaining[i] = knownTotal / float64(known)
+			} else {
+				remaining[i] = 0
+			}
+		}
+		total += remaining[i]
+	}
+	if total == 0 {
+		return &types.Result{TargetPod: pods[rand.Intn(len(pods))]}
+	}
+
+	target := rand.Float64() * total
+	for i, pod := range pods {
+		target -= remaining[i]
+		if target < 0 {
+			return &types.Result{TargetPod: pod}
+		}
+	}
+	return &types.Result{TargetPod: pods[len(pods)-1]}
+}
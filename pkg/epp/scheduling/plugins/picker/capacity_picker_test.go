@@ -0,0 +1,84 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestCapacityPicker(t *testing.T) {
+	pod := func(name string, capacity int, usage float64) types.Pod {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}},
+			Metrics: &backendmetrics.Metrics{KvCacheMaxTokenCapacity: capacity, KVCacheUsagePercent: usage},
+		}
+	}
+	tests := []struct {
+		name string
+		pods []types.Pod
+		// wantShares are the expected fractions of the picks per pod.
+		wantShares map[string]float64
+	}{
+		{
+			name:       "proportional to remaining capacity",
+			pods:       []types.Pod{pod("big", 4000, 0.25), pod("small", 1000, 0)},
+			wantShares: map[string]float64{"big": 0.75, "small": 0.25},
+		},
+		{
+			name:       "unknown capacity is assumed to be the average",
+			pods:       []types.Pod{pod("big", 3000, 0), pod("small", 1000, 0), pod("unknown", 0, 0)},
+			wantShares: map[string]float64{"big": 0.5, "small": 1.0 / 6, "unknown": 1.0 / 3},
+		},
+		{
+			name:       "no known capacity picks uniformly",
+			pods:       []types.Pod{pod("pod1", 0, 0), pod("pod2", 0, 0)},
+			wantShares: map[string]float64{"pod1": 0.5, "pod2": 0.5},
+		},
+		{
+			name:       "no remaining capacity picks uniformly",
+			pods:       []types.Pod{pod("pod1", 1000, 1), pod("pod2", 1000, 1)},
+			wantShares: map[string]float64{"pod1": 0.5, "pod2": 0.5},
+		},
+	}
+
+	const picks = 20000
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, test.pods)
+			picker := &CapacityPicker{}
+			counts := map[string]int{}
+			for i := 0; i < picks; i++ {
+				counts[picker.Pick(ctx, test.pods).TargetPod.GetPod().NamespacedName.Name]++
+			}
+			for name, want := range test.wantShares {
+				if got := float64(counts[name]) / picks; math.Abs(got-want) > 0.03 {
+					t.Errorf("Unexpected share for %s, got %.3f, want %.3f", name, got, want)
+				}
+			}
+		})
+	}
+
+	if res := (&CapacityPicker{}).Pick(types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, nil), nil); res != nil {
+		t.Errorf("Expected no result without pods, got %v", res)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestPickersWithoutCandidates(t *testing.T) {
+	pickers := []plugins.Picker{
+		&RandomPicker{},
+		&MaxScorePicker{},
+		NewStickyPicker(0.1),
+		&CapacityPicker{},
+		NewWeightedRoundRobinPicker("capacity"),
+	}
+	for _, picker := range pickers {
+		t.Run(picker.Name(), func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, nil)
+			for _, pods := range [][]types.Pod{nil, {}} {
+				if res := picker.Pick(ctx, pods); res != nil {
+					t.Errorf("Expected no result without candidates, got %v", res)
+				}
+			}
+		})
+	}
+}
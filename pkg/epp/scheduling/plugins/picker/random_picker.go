@@ -32,6 +32,9 @@ func (rp *RandomPicker) Name() string {
 
 func (rp *RandomPicker) Pick(ctx *types.SchedulingContext, pods []types.Pod) *types.Result {
 	ctx.Logger.V(logutil.DEBUG).Info(fmt.Sprintf("Selecting a random pod from %d candidates: %+v", len(pods), pods))
+	if len(pods) == 0 {
+		return nil
+	}
 	i := rand.Intn(len(pods))
 	return &types.Result{TargetPod: pods[i]}
 }
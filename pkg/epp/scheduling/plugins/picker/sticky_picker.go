@@ -0,0 +1,71 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker
+
+import (
+	"sync"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// NewStickyPicker returns a picker that keeps picking the pod most recently chosen for a model,
+// unless another pod scores higher by more than the given margin. This avoids flipping between
+// pods with nearly equal scores, which thrashes their KV caches.
+func NewStickyPicker(margin float64) *StickyPicker {
+	return &StickyPicker{
+		margin:     margin,
+		lastChosen: make(map[string]k8stypes.NamespacedName),
+	}
+}
+
+// StickyPicker picks the highest scored pod with hysteresis. See NewStickyPicker.
+type StickyPicker struct {
+	MaxScorePicker
+	margin float64
+
+	mu sync.Mutex
+	// key: the resolved target model, value: the pod most recently chosen for the model
+	lastChosen map[string]k8stypes.NamespacedName
+}
+
+func (p *StickyPicker) Name() string {
+	return "sticky max score"
+}
+
+func (p *StickyPicker) Pick(ctx *types.SchedulingContext, pods []types.Pod) *types.Result {
+	res := p.MaxScorePicker.Pick(ctx, pods)
+	if res == nil {
+		return nil
+	}
+	model := ctx.Req.ResolvedTargetModel
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if last, ok := p.lastChosen[model]; ok {
+		for _, pod := range pods {
+			if pod.GetPod().NamespacedName == last && res.TargetPod.Score() <= pod.Score()+p.margin {
+				ctx.Logger.V(logutil.DEBUG).Info("Sticking to the previously chosen pod", "pod", last, "model", model)
+				res = &types.Result{TargetPod: pod}
+				break
+			}
+		}
+	}
+	p.lastChosen[model] = res.TargetPod.GetPod().NamespacedName
+	return res
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker
+
+import (
+	"context"
+	"testing"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestStickyPickerReducesFlipping(t *testing.T) {
+	req := &types.LLMRequest{Model: "model", ResolvedTargetModel: "model"}
+	// The scores of both pods fluctuate within 0.05 of each other, alternating the best pod.
+	rounds := [][2]float64{{0.80, 0.78}, {0.78, 0.80}, {0.80, 0.77}, {0.76, 0.80}, {0.80, 0.79}, {0.79, 0.81}}
+	flips := func(picker plugins.Picker) int {
+		flips := 0
+		last := ""
+		for _, scores := range rounds {
+			pods := []types.Pod{
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+				&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+			}
+			pods[0].SetScore(scores[0])
+			pods[1].SetScore(scores[1])
+			ctx := types.NewSchedulingContext(context.Background(), req, pods)
+			got := picker.Pick(ctx, pods).TargetPod.GetPod().NamespacedName.Name
+			if last != "" && got != last {
+				flips++
+			}
+			last = got
+		}
+		return flips
+	}
+
+	if got := flips(&MaxScorePicker{}); got != len(rounds)-1 {
+		t.Errorf("Unexpected flips without stickiness, got %d, want %d", got, len(rounds)-1)
+	}
+	if got := flips(NewStickyPicker(0.05)); got != 0 {
+		t.Errorf("Unexpected flips with stickiness, got %d, want 0", got)
+	}
+	// A pod exceeding the margin replaces the sticky pod.
+	if got := flips(NewStickyPicker(0.01)); got == 0 {
+		t.Errorf("Expected flips when the score difference exceeds the margin")
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker
+
+import (
+	"math"
+	"strconv"
+	"sync"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// NewWeightedRoundRobinPicker returns a picker that distributes the requests among the pods in
+// proportion to the static weight set in the given pod label, without relying on live metrics.
+// Pods without the label, or with a label that isn't a positive number, have a weight of 1. The
+// label key must be captured in the pod metadata for the weights to be visible.
+func NewWeightedRoundRobinPicker(labelKey string) *WeightedRoundRobinPicker {
+	return &WeightedRoundRobinPicker{
+		labelKey: labelKey,
+		current:  make(map[k8stypes.NamespacedName]float64),
+	}
+}
+
+// WeightedRoundRobinPicker implements smooth weighted round-robin, which interleaves the pods
+// instead of sending bursts of consecutive requests to the heaviest ones. See
+// NewWeightedRoundRobinPicker.
+type WeightedRoundRobinPicker struct {
+	labelKey string
+
+	mu sync.Mutex
+	// key: the pod, value: the current weight of the pod in the round-robin. It only holds the
+	// candidates of the last pick, so that it doesn't grow with the pods that left the pool.
+	current map[k8stypes.NamespacedName]float64
+}
+
+func (p *WeightedRoundRobinPicker) Name() string {
+	return "weighted round-robin"
+}
+
+func (p *WeightedRoundRobinPicker) Pick(ctx *types.SchedulingContext, pods []types.Pod) *types.Result {
+	if len(pods) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var target types.Pod
+	total, best := 0.0, 0.0
+	current := make(map[k8stypes.NamespacedName]float64, len(pods))
+	for _, pod := range pods {
+		weight := p.weight(ctx, pod)
+		total += weight
+		name := pod.GetPod().NamespacedName
+		current[name] = p.current[name] + weight
+		if target == nil || current[name] > best {
+			target, best = pod, current[name]
+		}
+	}
+	current[target.GetPod().NamespacedName] -= total
+	p.current = current
+	return &types.Result{TargetPod: target}
+}
+
+// weight returns the weight of the pod from its label, defaulting to 1.
+func (p *WeightedRoundRobinPicker) weight(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	value, ok := pod.GetPod().Labels[p.labelKey]
+	if !ok {
+		return 1
+	}
+	weight, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(weight > 0) || math.IsInf(weight, 1) {
+		ctx.Logger.V(logutil.DEBUG).Info("Invalid pod weight label, using a weight of 1",
+			"pod", pod.GetPod().NamespacedName, "label", p.labelKey, "value", value)
+		return 1
+	}
+	return weight
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package picker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestWeightedRoundRobinPicker(t *testing.T) {
+	pod := func(name string, labels map[string]string) types.Pod {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}, Labels: labels},
+			Metrics: &backendmetrics.Metrics{},
+		}
+	}
+	tests := []struct {
+		name  string
+		pods  []types.Pod
+		picks int
+		// wantCounts are the expected number of picks per pod, which are exact since the
+		// round-robin is deterministic.
+		wantCounts map[string]int
+	}{
+		{
+			name:       "proportional to the weights",
+			pods:       []types.Pod{pod("big", map[string]string{"capacity": "3"}), pod("small", map[string]string{"capacity": "1"})},
+			picks:      400,
+			wantCounts: map[string]int{"big": 300, "small": 100},
+		},
+		{
+			name:       "missing label defaults to 1",
+			pods:       []types.Pod{pod("big", map[string]string{"capacity": "2"}), pod("unlabeled", nil)},
+			picks:      300,
+			wantCounts: map[string]int{"big": 200, "unlabeled": 100},
+		},
+		{
+			name:       "invalid label defaults to 1",
+			pods:       []types.Pod{pod("invalid", map[string]string{"capacity": "large"}), pod("negative", map[string]string{"capacity": "-2"}), pod("nan", map[string]string{"capacity": "NaN"})},
+			picks:      300,
+			wantCounts: map[string]int{"invalid": 100, "negative": 100, "nan": 100},
+		},
+		{
+			name:       "fractional weights",
+			pods:       []types.Pod{pod("pod1", map[string]string{"capacity": "0.5"}), pod("pod2", map[string]string{"capacity": "1.5"})},
+			picks:      400,
+			wantCounts: map[string]int{"pod1": 100, "pod2": 300},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, test.pods)
+			picker := NewWeightedRoundRobinPicker("capacity")
+			counts := map[string]int{}
+			for i := 0; i < test.picks; i++ {
+				counts[picker.Pick(ctx, test.pods).TargetPod.GetPod().NamespacedName.Name]++
+			}
+			if diff := cmp.Diff(test.wantCounts, counts); diff != "" {
+				t.Errorf("Unexpected picks (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestWeightedRoundRobinPickerInterleaves(t *testing.T) {
+	pods := []types.Pod{
+		&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "a"}, Labels: map[string]string{"capacity": "2"}}, Metrics: &backendmetrics.Metrics{}},
+		&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "b"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, pods)
+	picker := NewWeightedRoundRobinPicker("capacity")
+	got := []string{}
+	for i := 0; i < 6; i++ {
+		got = append(got, picker.Pick(ctx, pods).TargetPod.GetPod().NamespacedName.Name)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "a", "a", "b", "a"}, got); diff != "" {
+		t.Errorf("Unexpected pick sequence (-want +got): %s", diff)
+	}
+	if res := picker.Pick(ctx, nil); res != nil {
+		t.Errorf("Expected no pod without candidates, got %v", res)
+	}
+}
+
+func TestWeightedRoundRobinPickerConcurrentPicks(t *testing.T) {
+	pods := []types.Pod{
+		&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "a"}, Labels: map[string]string{"capacity": "3"}}, Metrics: &backendmetrics.Metrics{}},
+		&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "b"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, pods)
+	picker := NewWeightedRoundRobinPicker("capacity")
+
+	// The picks are serialized, so the shares are exact even when picking concurrently.
+	var mu sync.Mutex
+	counts := map[string]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				name := picker.Pick(ctx, pods).TargetPod.GetPod().NamespacedName.Name
+				mu.Lock()
+				counts[name]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if diff := cmp.Diff(map[string]int{"a": 600, "b": 200}, counts); diff != "" {
+		t.Errorf("Unexpected pick counts (-want +got): %s", diff)
+	}
+}
+
+func TestWeightedRoundRobinPickerForgetsRemovedPods(t *testing.T) {
+	pod := func(name string) types.Pod {
+		return &types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}}, Metrics: &backendmetrics.Metrics{}}
+	}
+	picker := NewWeightedRoundRobinPicker("capacity")
+	// The pods of the pool are replaced over time, e.g. by rolling updates.
+	for i := 0; i < 100; i++ {
+		pods := []types.Pod{pod(fmt.Sprintf("pod%d", i)), pod(fmt.Sprintf("pod%d", i+1))}
+		ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, pods)
+		picker.Pick(ctx, pods)
+	}
+	if len(picker.current) != 2 {
+		t.Errorf("Expected the round-robin state of the last candidates only, got %d pods", len(picker.current))
+	}
+}
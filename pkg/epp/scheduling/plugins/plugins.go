@@ -17,16 +17,19 @@ limitations under the License.
 package plugins
 
 import (
+	"context"
+
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
 )
 
 const (
-	PreSchedulerPluginType = "PreSchedule"
-	FilterPluginType       = "Filter"
-	ScorerPluginType       = "Scorer"
-	PostSchedulePluginType = "PostSchedule"
-	PickerPluginType       = "Picker"
-	PostResponsePluginType = "PostResponse"
+	RequestPreprocessorPluginType = "RequestPreprocessor"
+	PreSchedulerPluginType        = "PreSchedule"
+	FilterPluginType              = "Filter"
+	ScorerPluginType              = "Scorer"
+	PostSchedulePluginType        = "PostSchedule"
+	PickerPluginType              = "Picker"
+	PostResponsePluginType        = "PostResponse"
 )
 
 // Plugin defines the interface for scheduler plugins, combining scoring, filtering,
@@ -36,6 +39,14 @@ type Plugin interface {
 	Name() string
 }
 
+// RequestPreprocessor is called before any other plugin, when the scheduler receives a new
+// request. It can mutate the request, e.g. to set its criticality or rewrite its model, or reject
+// it by returning an error.
+type RequestPreprocessor interface {
+	Plugin
+	Preprocess(ctx context.Context, req *types.LLMRequest) error
+}
+
 // PreSchedule is called when the scheduler receives a new request. It can be used for various
 // initialization work.
 type PreSchedule interface {
@@ -49,7 +60,9 @@ type Filter interface {
 	Filter(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod
 }
 
-// Scorer defines the interface for scoring pods based on context.
+// Scorer defines the interface for scoring pods based on context. Scorers must honor the
+// cancellation of ctx and return promptly once it is done: a scorer with a timeout runs in its own
+// goroutine, which is only released when the scorer returns.
 type Scorer interface {
 	Plugin
 	Score(ctx *types.SchedulingContext, pod types.Pod) float64
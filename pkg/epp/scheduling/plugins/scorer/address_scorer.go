@@ -0,0 +1,44 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"encoding/binary"
+	"math"
+	"net"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// LowestAddressScorer scores pods higher the lower their address is, which makes it a
+// deterministic tie-breaker, e.g. to get predictable routing while debugging. Only the lowest 32
+// bits of IPv6 addresses are compared. Pods without a valid address score 0. The score is in the
+// range [0, 1].
+type LowestAddressScorer struct{}
+
+func (s *LowestAddressScorer) Name() string {
+	return "lowest-address"
+}
+
+func (s *LowestAddressScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	ip := net.ParseIP(pod.GetPod().Address)
+	if ip == nil {
+		return 0
+	}
+	n := binary.BigEndian.Uint32(ip.To16()[12:])
+	return 1 - float64(n)/math.MaxUint32
+}
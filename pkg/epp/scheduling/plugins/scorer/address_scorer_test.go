@@ -0,0 +1,43 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"testing"
+
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestLowestAddressScorer(t *testing.T) {
+	ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, nil)
+	score := func(address string) float64 {
+		pod := &types.PodMetrics{Pod: &backendmetrics.Pod{Address: address}, Metrics: &backendmetrics.Metrics{}}
+		return (&LowestAddressScorer{}).Score(ctx, pod)
+	}
+
+	if low, high := score("10.0.0.2"), score("10.0.0.10"); low <= high {
+		t.Errorf("Expected the lower address to score higher, got %v and %v", low, high)
+	}
+	if low, high := score("fd00::2"), score("fd00::a"); low <= high {
+		t.Errorf("Expected the lower IPv6 address to score higher, got %v and %v", low, high)
+	}
+	if got := score("not-an-address"); got != 0 {
+		t.Errorf("Unexpected score for an invalid address, got %v, want 0", got)
+	}
+}
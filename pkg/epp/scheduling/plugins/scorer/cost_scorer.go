@@ -0,0 +1,41 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// CostScorer scores pods by their KV cache utilization according to the cost of the request.
+// Cheap requests are packed onto the busier pods, keeping the other pods free for expensive
+// requests, which are spread onto the least loaded pods. The score is in the range [0, 1].
+type CostScorer struct {
+	// ExpensiveRequestCost is the request cost from which a request is spread rather than packed.
+	ExpensiveRequestCost float64
+}
+
+func (s *CostScorer) Name() string {
+	return "cost"
+}
+
+func (s *CostScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	usage := pod.GetMetrics().KVCacheUsagePercent
+	if ctx.Req.Cost() >= s.ExpensiveRequestCost {
+		return 1 - usage
+	}
+	return usage
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"testing"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestRequestCost(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *types.LLMRequest
+		want float64
+	}{
+		{
+			name: "no multiplier",
+			req:  &types.LLMRequest{PromptTokens: 100},
+			want: 100,
+		},
+		{
+			name: "with multiplier",
+			req:  &types.LLMRequest{PromptTokens: 100, CostMultiplier: 2.5},
+			want: 250,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.req.Cost(); got != test.want {
+				t.Errorf("Unexpected cost, got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCostScorer(t *testing.T) {
+	busy := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "busy"}},
+		Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.6},
+	}
+	idle := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "idle"}},
+		Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0},
+	}
+
+	tests := []struct {
+		name    string
+		req     *types.LLMRequest
+		wantPod string
+	}{
+		{
+			name:    "cheap request is packed onto the busy pod",
+			req:     &types.LLMRequest{PromptTokens: 100},
+			wantPod: "busy",
+		},
+		{
+			name:    "expensive request is spread onto the idle pod",
+			req:     &types.LLMRequest{PromptTokens: 100, CostMultiplier: 20},
+			wantPod: "idle",
+		},
+	}
+
+	scorer := &CostScorer{ExpensiveRequestCost: 1000}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pods := []types.Pod{busy, idle}
+			ctx := types.NewSchedulingContext(context.Background(), test.req, pods)
+			var best types.Pod
+			bestScore := -1.0
+			for _, pod := range pods {
+				if score := scorer.Score(ctx, pod); score > bestScore {
+					best, bestScore = pod, score
+				}
+			}
+			if got := best.GetPod().NamespacedName.Name; got != test.wantPod {
+				t.Errorf("Unexpected best pod, got %v, want %v", got, test.wantPod)
+			}
+		})
+	}
+}
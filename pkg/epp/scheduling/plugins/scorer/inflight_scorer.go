@@ -0,0 +1,124 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// NewInFlightScorer returns a scorer that penalizes pods that were recently selected. Each
+// selection reserves the pod, and the reservations decay exponentially over the given window.
+// If costUnit is positive, a selection reserves the pod in proportion to the estimated cost of the
+// request in units of costUnit, so that a pod assigned large requests is penalized more than one
+// assigned small requests. Otherwise, and for requests without an estimated cost, a selection
+// counts as one.
+func NewInFlightScorer(window time.Duration, costUnit float64) *InFlightScorer {
+	return &InFlightScorer{
+		window:       window,
+		costUnit:     costUnit,
+		reservations: make(map[k8stypes.NamespacedName]*reservation),
+	}
+}
+
+// InFlightScorer spreads bursts of requests across pods. Between a pod being selected and its
+// metrics being refreshed, all concurrent requests see the same "best" pod. The scorer keeps a
+// short-lived reservation count per pod, incremented after a pod is selected, and scores pods
+// with fewer reservations higher. The score is in the range (0, 1], where 1 means no reservations.
+type InFlightScorer struct {
+	window   time.Duration
+	costUnit float64
+
+	mu sync.Mutex
+	// key: pod NamespacedName, value: the decaying reservation count of the pod
+	reservations map[k8stypes.NamespacedName]*reservation
+}
+
+type reservation struct {
+	count      float64
+	updateTime time.Time
+}
+
+// decayed returns the reservation count at the given time.
+func (r *reservation) decayed(now time.Time, window time.Duration) float64 {
+	if window <= 0 {
+		return 0
+	}
+	return r.count * math.Exp(-float64(now.Sub(r.updateTime))/float64(window))
+}
+
+func (s *InFlightScorer) Name() string {
+	return "in-flight"
+}
+
+func (s *InFlightScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.reservations[pod.GetPod().NamespacedName]
+	if !ok {
+		return 1
+	}
+	return 1 / (1 + r.decayed(time.Now(), s.window))
+}
+
+// PostSchedule reserves the selected pod, and the backup pod if any, since the request may be sent
+// to both.
+func (s *InFlightScorer) PostSchedule(ctx *types.SchedulingContext, res *types.Result) {
+	if res == nil || res.TargetPod == nil {
+		return
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(now)
+	weight := s.weight(ctx.Req)
+	s.reserveLocked(res.TargetPod.GetPod().NamespacedName, weight, now)
+	if res.BackupPod != nil {
+		s.reserveLocked(res.BackupPod.GetPod().NamespacedName, weight, now)
+	}
+}
+
+func (s *InFlightScorer) reserveLocked(name k8stypes.NamespacedName, weight float64, now time.Time) {
+	r, ok := s.reservations[name]
+	if !ok {
+		s.reservations[name] = &reservation{count: weight, updateTime: now}
+		return
+	}
+	r.count = r.decayed(now, s.window) + weight
+	r.updateTime = now
+}
+
+// weight returns the reservation a selection for the request makes.
+func (s *InFlightScorer) weight(req *types.LLMRequest) float64 {
+	if s.costUnit <= 0 || req == nil || req.Cost() <= 0 {
+		return 1
+	}
+	return req.Cost() / s.costUnit
+}
+
+// pruneLocked drops reservations that have fully decayed, so that deleted pods don't accumulate.
+func (s *InFlightScorer) pruneLocked(now time.Time) {
+	for name, r := range s.reservations {
+		if r.decayed(now, s.window) < 0.01 {
+			delete(s.reservations, name)
+		}
+	}
+}
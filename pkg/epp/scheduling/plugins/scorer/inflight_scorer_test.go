@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestInFlightScorerCostWeighting(t *testing.T) {
+	heavy := &types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "heavy"}}, Metrics: &backendmetrics.Metrics{}}
+	light := &types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "light"}}, Metrics: &backendmetrics.Metrics{}}
+
+	tests := []struct {
+		name         string
+		costUnit     float64
+		wantWeighted bool
+	}{
+		{name: "weighted by cost", costUnit: 100, wantWeighted: true},
+		{name: "counting requests", costUnit: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewInFlightScorer(time.Hour, test.costUnit)
+			// Both pods are assigned the same number of requests, but heavy gets the large ones.
+			for i := 0; i < 10; i++ {
+				for pod, tokens := range map[*types.PodMetrics]int{heavy: 4096, light: 16} {
+					ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{Model: "model", PromptTokens: tokens}, nil)
+					s.PostSchedule(ctx, &types.Result{TargetPod: pod})
+				}
+			}
+
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{Model: "model"}, nil)
+			heavyScore, lightScore := s.Score(ctx, heavy), s.Score(ctx, light)
+			if test.wantWeighted {
+				if heavyScore >= lightScore {
+					t.Errorf("Expected the pod assigned heavy requests to be deprioritized, got %v for heavy and %v for light", heavyScore, lightScore)
+				}
+			} else if math.Abs(heavyScore-lightScore) > 1e-3 {
+				t.Errorf("Expected the pods to score the same, got %v for heavy and %v for light", heavyScore, lightScore)
+			}
+		})
+	}
+}
+
+func TestInFlightScorerReservesBackupPod(t *testing.T) {
+	newPod := func(name string) *types.PodMetrics {
+		return &types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}}, Metrics: &backendmetrics.Metrics{}}
+	}
+	primary, standby, idle := newPod("primary"), newPod("standby"), newPod("idle")
+	s := NewInFlightScorer(time.Hour, 0)
+	ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{Model: "model", Redundant: true}, nil)
+	s.PostSchedule(ctx, &types.Result{TargetPod: primary, BackupPod: standby})
+
+	// The standby gets the request too, so it is as loaded as the primary for the next requests.
+	primaryScore, standbyScore, idleScore := s.Score(ctx, primary), s.Score(ctx, standby), s.Score(ctx, idle)
+	if math.Abs(primaryScore-standbyScore) > 1e-3 || standbyScore >= idleScore {
+		t.Errorf("Expected the standby to be reserved like the primary, got %v for primary, %v for standby and %v for idle",
+			primaryScore, standbyScore, idleScore)
+	}
+}
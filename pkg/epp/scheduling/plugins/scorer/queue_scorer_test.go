@@ -0,0 +1,74 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"testing"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestQueueScorer(t *testing.T) {
+	tests := []struct {
+		name    string
+		waiting []int
+		want    []float64
+	}{
+		{
+			name: "no pods",
+		},
+		{
+			name:    "empty queue scores 1",
+			waiting: []int{0},
+			want:    []float64{1},
+		},
+		{
+			name:    "score decreases with the waiting queue",
+			waiting: []int{0, 1, 3, 9},
+			want:    []float64{1, 0.5, 0.25, 0.1},
+		},
+	}
+
+	scorer := &QueueScorer{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pods := []types.Pod{}
+			for i, waiting := range test.waiting {
+				pods = append(pods, &types.PodMetrics{
+					Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: string(rune('a' + i))}},
+					Metrics: &backendmetrics.Metrics{WaitingQueueSize: waiting},
+				})
+			}
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, pods)
+			got := []float64{}
+			for _, pod := range ctx.PodsSnapshot {
+				got = append(got, scorer.Score(ctx, pod))
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("Unexpected number of scores, got %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("Unexpected score for a waiting queue of %d, got %v, want %v", test.waiting[i], got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
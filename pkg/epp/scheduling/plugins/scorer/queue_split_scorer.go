@@ -0,0 +1,56 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// QueueSplitScorer scores pods by their running and waiting requests, weighing them by the phase
+// the incoming request is expected to dominate. Waiting requests haven't been prefilled yet, while
+// running requests are mostly decoding.
+//
+// Requests with at least PrefillBoundPromptTokens prompt tokens are prefill-bound. For them, a pod
+// with an empty waiting queue keeps up with its load, even with many running requests, so it always
+// scores higher than a pod that is queuing requests. Pods with empty waiting queues are then ranked
+// by their running requests, and queuing pods by their waiting requests. The score is in the range
+// (0, 1], where scores above 0.5 mean an empty waiting queue.
+//
+// Other requests are decode-bound, and are ranked the other way around: pods with few running
+// requests score higher, as a short prompt is prefilled cheaply even on a pod with a waiting queue.
+// The score is in the range (0, 1], where scores above 0.5 mean no running request.
+//
+// A PrefillBoundPromptTokens of 0 considers all requests prefill-bound.
+type QueueSplitScorer struct {
+	PrefillBoundPromptTokens int
+}
+
+func (s *QueueSplitScorer) Name() string {
+	return "queue-split"
+}
+
+func (s *QueueSplitScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	metrics := pod.GetMetrics()
+	primary, secondary := metrics.WaitingQueueSize, metrics.RunningQueueSize
+	if ctx.Req.PromptTokens < s.PrefillBoundPromptTokens {
+		primary, secondary = secondary, primary
+	}
+	if primary > 0 {
+		return 0.5 / float64(1+primary)
+	}
+	return 0.5 + 0.5/float64(1+secondary)
+}
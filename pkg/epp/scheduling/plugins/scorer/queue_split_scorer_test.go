@@ -0,0 +1,116 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"testing"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestQueueSplitScorer(t *testing.T) {
+	pod := func(name string, running, waiting int) types.Pod {
+		return &types.PodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}},
+			Metrics: &backendmetrics.Metrics{RunningQueueSize: running, WaitingQueueSize: waiting},
+		}
+	}
+	ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, nil)
+	scorer := &QueueSplitScorer{}
+
+	tests := []struct {
+		name   string
+		better types.Pod
+		worse  types.Pod
+	}{
+		{
+			name:   "busy pod keeping up beats a pod with a backed-up queue",
+			better: pod("throughput", 50, 0),
+			worse:  pod("backed-up", 2, 1),
+		},
+		{
+			name:   "fewer running requests among pods keeping up",
+			better: pod("light", 2, 0),
+			worse:  pod("heavy", 20, 0),
+		},
+		{
+			name:   "shorter waiting queue among queuing pods",
+			better: pod("short", 20, 1),
+			worse:  pod("long", 2, 5),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			better, worse := scorer.Score(ctx, test.better), scorer.Score(ctx, test.worse)
+			if better <= worse {
+				t.Errorf("Expected %s to score higher than %s, got %v and %v",
+					test.better.GetPod().NamespacedName.Name, test.worse.GetPod().NamespacedName.Name, better, worse)
+			}
+		})
+	}
+}
+
+func TestQueueSplitScorerPhases(t *testing.T) {
+	// prefillLoaded has many requests waiting for prefill but few requests decoding.
+	prefillLoaded := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "prefill-loaded"}},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: 10, RunningQueueSize: 2},
+	}
+	// decodeLoaded has no request waiting for prefill but many requests decoding.
+	decodeLoaded := &types.PodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "decode-loaded"}},
+		Metrics: &backendmetrics.Metrics{WaitingQueueSize: 0, RunningQueueSize: 40},
+	}
+
+	tests := []struct {
+		name    string
+		req     *types.LLMRequest
+		wantPod string
+	}{
+		{
+			name:    "prefill-bound request prefers the pod with fewer requests waiting for prefill",
+			req:     &types.LLMRequest{Model: "model", PromptTokens: 4096},
+			wantPod: "decode-loaded",
+		},
+		{
+			name:    "decode-bound request prefers the pod with fewer requests decoding",
+			req:     &types.LLMRequest{Model: "model", PromptTokens: 16},
+			wantPod: "prefill-loaded",
+		},
+	}
+
+	scorer := &QueueSplitScorer{PrefillBoundPromptTokens: 512}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pods := []types.Pod{prefillLoaded, decodeLoaded}
+			ctx := types.NewSchedulingContext(context.Background(), test.req, pods)
+			var best types.Pod
+			bestScore := -1.0
+			for _, pod := range pods {
+				if score := scorer.Score(ctx, pod); score > bestScore {
+					best, bestScore = pod, score
+				}
+			}
+			if got := best.GetPod().NamespacedName.Name; got != test.wantPod {
+				t.Errorf("Unexpected best pod, got %v, want %v", got, test.wantPod)
+			}
+		})
+	}
+}
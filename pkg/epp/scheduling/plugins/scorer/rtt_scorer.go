@@ -0,0 +1,152 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// neutralRTTScore is the score of the pods whose RTT isn't measured, halfway between the nearest
+// and the farthest pods.
+const neutralRTTScore = 0.5
+
+// RTTProbe measures the round-trip time to a pod.
+type RTTProbe func(ctx context.Context, pod *backendmetrics.Pod) (time.Duration, error)
+
+// NewTCPProbe returns a probe measuring the time to open a TCP connection to the given port of the
+// pods, giving up after the timeout.
+func NewTCPProbe(port int, timeout time.Duration) RTTProbe {
+	return func(ctx context.Context, pod *backendmetrics.Pod) (time.Duration, error) {
+		dialer := net.Dialer{Timeout: timeout}
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(pod.Address, strconv.Itoa(port)))
+		if err != nil {
+			return 0, err
+		}
+		rtt := time.Since(start)
+		_ = conn.Close()
+		return rtt, nil
+	}
+}
+
+// NewRTTScorer returns a scorer preferring the pods with the lowest round-trip time from the EPP,
+// e.g. in geo-distributed pools. The RTTs are measured with the probe on the pods returned by
+// pods, every time Run refreshes them.
+func NewRTTScorer(probe RTTProbe, pods func() []*backendmetrics.Pod) *RTTScorer {
+	return &RTTScorer{
+		probe:  probe,
+		pods:   pods,
+		scores: make(map[k8stypes.NamespacedName]float64),
+	}
+}
+
+// RTTScorer scores the pods by their last measured RTT. The score is in the range [0, 1], where 1
+// is the nearest pod and 0 the farthest one. Pods whose RTT isn't measured, e.g. because they were
+// just added or the probe failed, get a neutral score of 0.5.
+type RTTScorer struct {
+	probe RTTProbe
+	pods  func() []*backendmetrics.Pod
+
+	mu sync.RWMutex
+	// key: pod NamespacedName, value: the score derived from the last measured RTT of the pod
+	scores map[k8stypes.NamespacedName]float64
+}
+
+func (s *RTTScorer) Name() string {
+	return "rtt"
+}
+
+func (s *RTTScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if score, ok := s.scores[pod.GetPod().NamespacedName]; ok {
+		return score
+	}
+	return neutralRTTScore
+}
+
+// Run measures the RTTs of the pods every interval until the context is done.
+func (s *RTTScorer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh measures the RTTs of all the pods concurrently and replaces the scores, so that the
+// deleted pods are forgotten.
+func (s *RTTScorer) refresh(ctx context.Context) {
+	logger := log.FromContext(ctx).WithName("rtt-scorer").V(logutil.DEBUG)
+	pods := s.pods()
+	rtts := make(map[k8stypes.NamespacedName]time.Duration, len(pods))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, pod := range pods {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rtt, err := s.probe(ctx, pod)
+			if err != nil {
+				logger.Info("Failed to measure the RTT of the pod", "pod", pod.NamespacedName, "error", err)
+				return
+			}
+			mu.Lock()
+			rtts[pod.NamespacedName] = rtt
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	scores := make(map[k8stypes.NamespacedName]float64, len(rtts))
+	var nearest, farthest time.Duration
+	first := true
+	for _, rtt := range rtts {
+		if first || rtt < nearest {
+			nearest = rtt
+		}
+		if first || rtt > farthest {
+			farthest = rtt
+		}
+		first = false
+	}
+	for name, rtt := range rtts {
+		scores[name] = 1
+		if farthest > nearest {
+			scores[name] = 1 - float64(rtt-nearest)/float64(farthest-nearest)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scores = scores
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestRTTScorer(t *testing.T) {
+	near := &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "near"}}
+	mid := &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "mid"}}
+	far := &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "far"}}
+	unreachable := &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "unreachable"}}
+	added := &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "added"}}
+	rtts := map[string]time.Duration{"near": time.Millisecond, "mid": 21 * time.Millisecond, "far": 81 * time.Millisecond}
+	probe := func(ctx context.Context, pod *backendmetrics.Pod) (time.Duration, error) {
+		rtt, ok := rtts[pod.NamespacedName.Name]
+		if !ok {
+			return 0, errors.New("unreachable")
+		}
+		return rtt, nil
+	}
+	pods := []*backendmetrics.Pod{near, mid, far, unreachable}
+	s := NewRTTScorer(probe, func() []*backendmetrics.Pod { return pods })
+
+	ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{}, nil)
+	score := func(pod *backendmetrics.Pod) float64 {
+		return s.Score(ctx, &types.PodMetrics{Pod: pod, Metrics: &backendmetrics.Metrics{}})
+	}
+	if got := score(near); got != neutralRTTScore {
+		t.Errorf("Expected a neutral score before the first measurement, got %v", got)
+	}
+
+	s.refresh(context.Background())
+	want := map[*backendmetrics.Pod]float64{near: 1, mid: 0.75, far: 0, unreachable: neutralRTTScore, added: neutralRTTScore}
+	for pod, wantScore := range want {
+		if got := score(pod); got != wantScore {
+			t.Errorf("Unexpected score for %v, got %v, want %v", pod.NamespacedName, got, wantScore)
+		}
+	}
+
+	// The measurements of the removed pods are forgotten, and the scores span the remaining pods.
+	pods = []*backendmetrics.Pod{mid, far}
+	s.refresh(context.Background())
+	want = map[*backendmetrics.Pod]float64{near: neutralRTTScore, mid: 1, far: 0}
+	for pod, wantScore := range want {
+		if got := score(pod); got != wantScore {
+			t.Errorf("Unexpected score for %v after the refresh, got %v, want %v", pod.NamespacedName, got, wantScore)
+		}
+	}
+}
+
+func TestTCPProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	pod := &backendmetrics.Pod{Address: "127.0.0.1"}
+
+	if _, err := NewTCPProbe(port, time.Second)(context.Background(), pod); err != nil {
+		t.Errorf("Unexpected error probing a listening port: %v", err)
+	}
+	_ = listener.Close()
+	if _, err := NewTCPProbe(port, time.Second)(context.Background(), pod); err == nil {
+		t.Errorf("Expected an error probing the closed port %d", port)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// neutralThroughputScore is the score of all the pods for the requests that aren't latency
+// sensitive, and of the pods whose throughput isn't measured.
+const neutralThroughputScore = 0.5
+
+// NewThroughputScorer returns a scorer preferring the pods generating tokens the fastest for the
+// latency sensitive requests. The throughput of the pods returned by pods is derived from their
+// generated tokens counter every time Run refreshes it, and averaged with a weight decaying
+// exponentially over the given window, so that the scores follow the changes in throughput.
+func NewThroughputScorer(window time.Duration, pods func() []backendmetrics.PodMetrics) *ThroughputScorer {
+	return &ThroughputScorer{
+		window:      window,
+		pods:        pods,
+		throughputs: make(map[k8stypes.NamespacedName]*throughput),
+		scores:      make(map[k8stypes.NamespacedName]float64),
+	}
+}
+
+// ThroughputScorer scores the pods by their throughput per running request, i.e. the speed at
+// which each request gets its tokens, which differs between pods due to hardware or contention.
+// Critical requests, which are the latency sensitive ones, get a score in the range [0, 1] that is
+// proportional to the throughput of the pod, where 1 is the fastest pod. Pods whose throughput
+// isn't measured yet, and all pods for the other requests, get a neutral score of 0.5.
+type ThroughputScorer struct {
+	window time.Duration
+	pods   func() []backendmetrics.PodMetrics
+
+	// throughputs is only accessed by refresh.
+	// key: pod NamespacedName, value: the throughput measurement of the pod
+	throughputs map[k8stypes.NamespacedName]*throughput
+
+	mu sync.RWMutex
+	// key: pod NamespacedName, value: the score derived from the throughput of the pod
+	scores map[k8stypes.NamespacedName]float64
+}
+
+// throughput is the decaying average of the throughput per running request of a pod.
+type throughput struct {
+	// tokens is the generated tokens counter of the pod at updateTime.
+	tokens     float64
+	updateTime time.Time
+	// perRequest is the average tokens per second per running request, valid if measured is set.
+	perRequest float64
+	measured   bool
+}
+
+// observe updates the throughput with the metrics scraped since the last observation.
+func (t *throughput) observe(m *backendmetrics.Metrics, window time.Duration) {
+	if !m.UpdateTime.After(t.updateTime) {
+		return
+	}
+	elapsed := m.UpdateTime.Sub(t.updateTime)
+	generated := m.GenerationTokensTotal - t.tokens
+	t.tokens, t.updateTime = m.GenerationTokensTotal, m.UpdateTime
+	if generated < 0 {
+		// The counter was reset, e.g. because the model server restarted.
+		t.measured = false
+		return
+	}
+	if m.RunningQueueSize == 0 && generated == 0 {
+		// An idle pod tells nothing about its speed.
+		return
+	}
+	sample := generated / elapsed.Seconds() / float64(max(m.RunningQueueSize, 1))
+	if !t.measured || window <= 0 {
+		t.perRequest, t.measured = sample, true
+		return
+	}
+	weight := 1 - math.Exp(-float64(elapsed)/float64(window))
+	t.perRequest += weight * (sample - t.perRequest)
+}
+
+func (s *ThroughputScorer) Name() string {
+	return "throughput"
+}
+
+func (s *ThroughputScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	if !ctx.Req.Critical {
+		return neutralThroughputScore
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if score, ok := s.scores[pod.GetPod().NamespacedName]; ok {
+		return score
+	}
+	return neutralThroughputScore
+}
+
+// Run refreshes the throughputs of the pods every interval until the context is done. The
+// interval should be longer than the metrics refresh interval of the pods.
+func (s *ThroughputScorer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.refresh()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh observes the latest metrics of the pods and replaces the scores, so that the deleted
+// pods are forgotten.
+func (s *ThroughputScorer) refresh() {
+	throughputs := make(map[k8stypes.NamespacedName]*throughput)
+	fastest := 0.0
+	for _, pm := range s.pods() {
+		name := pm.GetPod().NamespacedName
+		m := pm.GetMetrics()
+		if m.UpdateTime.IsZero() {
+			// The pod wasn't scraped yet, its counter can't serve as a baseline.
+			continue
+		}
+		t, ok := s.throughputs[name]
+		if !ok {
+			t = &throughput{tokens: m.GenerationTokensTotal, updateTime: m.UpdateTime}
+		} else {
+			t.observe(m, s.window)
+		}
+		throughputs[name] = t
+		if t.measured {
+			fastest = max(fastest, t.perRequest)
+		}
+	}
+	s.throughputs = throughputs
+
+	scores := make(map[k8stypes.NamespacedName]float64, len(throughputs))
+	if fastest > 0 {
+		for name, t := range throughputs {
+			if t.measured {
+				scores[name] = t.perRequest / fastest
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scores = scores
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestThroughputScorer(t *testing.T) {
+	start := time.Now()
+	newPod := func(name string) *backendmetrics.FakePodMetrics {
+		return &backendmetrics.FakePodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}},
+			Metrics: &backendmetrics.Metrics{UpdateTime: start},
+		}
+	}
+	fast, slow, idle := newPod("fast"), newPod("slow"), newPod("idle")
+	// generate scrapes the metrics of a pod after it generated tokens with running requests.
+	generate := func(pod *backendmetrics.FakePodMetrics, tokens float64, running int, at time.Duration) {
+		pod.Metrics = &backendmetrics.Metrics{
+			GenerationTokensTotal: pod.Metrics.GenerationTokensTotal + tokens,
+			RunningQueueSize:      running,
+			UpdateTime:            start.Add(at),
+		}
+	}
+	s := NewThroughputScorer(time.Second, func() []backendmetrics.PodMetrics {
+		return []backendmetrics.PodMetrics{fast, slow, idle}
+	})
+	critical := types.NewSchedulingContext(context.Background(), &types.LLMRequest{Model: "model", Critical: true}, nil)
+	sheddable := types.NewSchedulingContext(context.Background(), &types.LLMRequest{Model: "model"}, nil)
+	score := func(ctx *types.SchedulingContext, pod *backendmetrics.FakePodMetrics) float64 {
+		return s.Score(ctx, &types.PodMetrics{Pod: pod.Pod, Metrics: pod.Metrics})
+	}
+
+	s.refresh()
+	if got := score(critical, fast); got != neutralThroughputScore {
+		t.Errorf("Expected a neutral score before the throughput is measured, got %v", got)
+	}
+
+	// fast gets 50 tokens per second per request, slow 10.
+	generate(fast, 100, 2, time.Second)
+	generate(slow, 20, 2, time.Second)
+	generate(idle, 0, 0, time.Second)
+	s.refresh()
+	want := map[*backendmetrics.FakePodMetrics]float64{fast: 1, slow: 0.2, idle: neutralThroughputScore}
+	for pod, wantScore := range want {
+		if got := score(critical, pod); got != wantScore {
+			t.Errorf("Unexpected score for %v, got %v, want %v", pod.Pod.NamespacedName.Name, got, wantScore)
+		}
+		if got := score(sheddable, pod); got != neutralThroughputScore {
+			t.Errorf("Expected a neutral score for a sheddable request on %v, got %v", pod.Pod.NamespacedName.Name, got)
+		}
+	}
+
+	// fast slows down to the speed of slow. The measurement decays, so fast is still preferred
+	// right after the change, and the scores converge as the change persists.
+	generate(fast, 20, 2, 2*time.Second)
+	generate(slow, 20, 2, 2*time.Second)
+	s.refresh()
+	if got := score(critical, slow); got <= 0.2 || got >= 1 {
+		t.Errorf("Expected the score of slow to increase gradually, got %v", got)
+	}
+	if got := score(critical, fast); got != 1 {
+		t.Errorf("Expected fast to remain the fastest pod right after slowing down, got %v", got)
+	}
+	for i := 3; i < 15; i++ {
+		generate(fast, 20, 2, time.Duration(i)*time.Second)
+		generate(slow, 20, 2, time.Duration(i)*time.Second)
+		s.refresh()
+	}
+	if got := score(critical, slow); got < 0.99 {
+		t.Errorf("Expected the scores to converge, got %v for slow", got)
+	}
+
+	// A counter reset, e.g. on restart, discards the measurement.
+	fast.Metrics = &backendmetrics.Metrics{GenerationTokensTotal: 5, RunningQueueSize: 1, UpdateTime: start.Add(15 * time.Second)}
+	s.refresh()
+	if got := score(critical, fast); got != neutralThroughputScore {
+		t.Errorf("Expected a neutral score after a counter reset, got %v", got)
+	}
+}
+
+func TestThroughputScorerUnscrapedPod(t *testing.T) {
+	start := time.Now()
+	pod := &backendmetrics.FakePodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "new"}},
+		Metrics: &backendmetrics.Metrics{},
+	}
+	other := &backendmetrics.FakePodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "other"}},
+		Metrics: &backendmetrics.Metrics{UpdateTime: start},
+	}
+	s := NewThroughputScorer(time.Second, func() []backendmetrics.PodMetrics {
+		return []backendmetrics.PodMetrics{pod, other}
+	})
+	critical := types.NewSchedulingContext(context.Background(), &types.LLMRequest{Model: "model", Critical: true}, nil)
+	score := func(pod *backendmetrics.FakePodMetrics) float64 {
+		return s.Score(critical, &types.PodMetrics{Pod: pod.Pod, Metrics: pod.Metrics})
+	}
+
+	// The pod is seen before its first scrape.
+	s.refresh()
+
+	// Its first scrape becomes the baseline rather than a measurement over the time since the
+	// zero time, which would make it look like the slowest pod.
+	pod.Metrics = &backendmetrics.Metrics{GenerationTokensTotal: 100, RunningQueueSize: 2, UpdateTime: start}
+	other.Metrics = &backendmetrics.Metrics{GenerationTokensTotal: 20, RunningQueueSize: 2, UpdateTime: start.Add(time.Second)}
+	s.refresh()
+	if got := score(pod); got != neutralThroughputScore {
+		t.Errorf("Expected a neutral score on the first scrape, got %v", got)
+	}
+
+	// It's measured from its second scrape, at 50 tokens per second per request against 10.
+	pod.Metrics = &backendmetrics.Metrics{GenerationTokensTotal: 200, RunningQueueSize: 2, UpdateTime: start.Add(time.Second)}
+	s.refresh()
+	if got := score(pod); got != 1 {
+		t.Errorf("Expected the pod to be the fastest, got %v", got)
+	}
+	if got := score(other); got != 0.2 {
+		t.Errorf("Unexpected score for the other pod, got %v, want 0.2", got)
+	}
+}
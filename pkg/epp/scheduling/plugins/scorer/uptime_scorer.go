@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"time"
+
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+// neutralUptimeScore is the score of all the pods for the requests that aren't cache-sensitive,
+// and of the pods whose start time isn't known.
+const neutralUptimeScore = 0.5
+
+// NewUptimeScorer returns a scorer preferring the pods that have been running for longer for the
+// requests with at least cacheSensitivePromptTokens prompt tokens, which benefit the most from a
+// warm prefix cache. The score of a pod grows linearly with its uptime over the warm-up period.
+func NewUptimeScorer(cacheSensitivePromptTokens int, warmUp time.Duration, clock clock.PassiveClock) *UptimeScorer {
+	return &UptimeScorer{
+		cacheSensitivePromptTokens: cacheSensitivePromptTokens,
+		warmUp:                     warmUp,
+		clock:                      clock,
+	}
+}
+
+// UptimeScorer avoids sending cache-sensitive requests to recently restarted pods, whose cache is
+// cold. The score is in the range [0, 1], where 0 is a pod that just started and 1 a pod that has
+// been running for at least the warm-up period. Other requests get a neutral score on all pods.
+type UptimeScorer struct {
+	cacheSensitivePromptTokens int
+	warmUp                     time.Duration
+	clock                      clock.PassiveClock
+}
+
+func (s *UptimeScorer) Name() string {
+	return "uptime"
+}
+
+func (s *UptimeScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	startTime := pod.GetPod().StartTime
+	if ctx.Req.PromptTokens < s.cacheSensitivePromptTokens || startTime == nil {
+		return neutralUptimeScore
+	}
+	uptime := s.clock.Since(startTime.Time)
+	if uptime >= s.warmUp {
+		return 1
+	}
+	if uptime <= 0 {
+		return 0
+	}
+	return float64(uptime) / float64(s.warmUp)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scorer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestUptimeScorer(t *testing.T) {
+	now := time.Now()
+	newPod := func(name string, uptime time.Duration) *types.PodMetrics {
+		pod := &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}}
+		if uptime >= 0 {
+			pod.StartTime = &metav1.Time{Time: now.Add(-uptime)}
+		}
+		return &types.PodMetrics{Pod: pod, Metrics: &backendmetrics.Metrics{}}
+	}
+	restarted := newPod("restarted", 0)
+	warmingUp := newPod("warming-up", time.Minute)
+	warm := newPod("warm", time.Hour)
+	// pending hasn't been started by the kubelet yet.
+	pending := newPod("pending", -1)
+
+	tests := []struct {
+		name       string
+		req        *types.LLMRequest
+		wantScores map[*types.PodMetrics]float64
+	}{
+		{
+			name:       "cache-sensitive request prefers the longer-running pods",
+			req:        &types.LLMRequest{Model: "model", PromptTokens: 4096},
+			wantScores: map[*types.PodMetrics]float64{restarted: 0, warmingUp: 0.25, warm: 1, pending: neutralUptimeScore},
+		},
+		{
+			name: "other requests are neutral",
+			req:  &types.LLMRequest{Model: "model", PromptTokens: 16},
+			wantScores: map[*types.PodMetrics]float64{
+				restarted: neutralUptimeScore, warmingUp: neutralUptimeScore, warm: neutralUptimeScore, pending: neutralUptimeScore,
+			},
+		},
+	}
+
+	scorer := NewUptimeScorer(512, 4*time.Minute, clocktesting.NewFakePassiveClock(now))
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), test.req, nil)
+			for pod, want := range test.wantScores {
+				if got := scorer.Score(ctx, pod); got != want {
+					t.Errorf("Unexpected score for %v, got %v, want %v", pod.GetPod().NamespacedName.Name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// poolConcurrencyLimiter caps the number of requests in flight across the whole pool, to protect
+// the resources the pods share. A request holds a slot from the time it is scheduled until it
+// completes. When all slots are taken, sheddable requests are dropped right away while critical
+// requests wait for a slot for up to queueTimeout.
+type poolConcurrencyLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+// newPoolConcurrencyLimiter returns a limiter allowing limit requests in flight, or nil if limit is
+// 0 or less, in which case the requests aren't limited.
+func newPoolConcurrencyLimiter(limit int, queueTimeout time.Duration) *poolConcurrencyLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &poolConcurrencyLimiter{slots: make(chan struct{}, limit), queueTimeout: queueTimeout}
+}
+
+// acquire takes a slot for a request, and returns the function releasing it, which may be called
+// more than once. It returns false if no slot becomes available in time, and an error if the
+// context is done while the request waits for a slot.
+func (l *poolConcurrencyLimiter) acquire(ctx context.Context, critical bool) (func(), bool, error) {
+	select {
+	case l.slots <- struct{}{}:
+		return l.releaseFunc(), true, nil
+	default:
+	}
+	if !critical || l.queueTimeout <= 0 {
+		return nil, false, nil
+	}
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return l.releaseFunc(), true, nil
+	case <-timer.C:
+		return nil, false, nil
+	case <-ctx.Done():
+		return nil, false, checkAborted(ctx)
+	}
+}
+
+func (l *poolConcurrencyLimiter) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-l.slots })
+	}
+}
+
+// full reports whether all the slots are taken.
+func (l *poolConcurrencyLimiter) full() bool {
+	return l != nil && len(l.slots) == cap(l.slots)
+}
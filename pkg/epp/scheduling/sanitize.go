@@ -0,0 +1,57 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"math"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// isInvalidValue returns whether the value is NaN or infinite, which doesn't compare
+// meaningfully with other values and would make the filters and the combined scores undefined.
+func isInvalidValue(v float64) bool {
+	return math.IsNaN(v) || math.IsInf(v, 0)
+}
+
+// sanitizeMetrics replaces the invalid KV cache utilization reported by some pods with the worst
+// case, a full KV cache. The pods are the scheduling snapshot, so the datastore is not modified.
+func sanitizeMetrics(logger logr.Logger, pods []types.Pod) {
+	for _, pod := range pods {
+		m := pod.GetMetrics()
+		if m == nil {
+			continue
+		}
+		if isInvalidValue(m.KVCacheUsagePercent) {
+			logger.V(logutil.DEFAULT).Info("Pod reported an invalid KV cache utilization, treating its KV cache as full",
+				"pod", pod.GetPod().NamespacedName, "kvCacheUsagePercent", m.KVCacheUsagePercent)
+			m.KVCacheUsagePercent = 1
+		}
+	}
+}
+
+// sanitizeScore replaces an invalid score with the lowest score.
+func sanitizeScore(logger logr.Logger, scorer string, pod types.Pod, score float64) float64 {
+	if !isInvalidValue(score) {
+		return score
+	}
+	logger.V(logutil.DEFAULT).Info("Scorer returned an invalid score, treating it as the lowest score",
+		"scorer", scorer, "pod", pod.GetPod().NamespacedName, "score", score)
+	return 0
+}
@@ -19,12 +19,20 @@ package scheduling
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-logr/logr"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/filter"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
@@ -33,11 +41,13 @@ import (
 	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
 )
 
-var (
-	lowLatencyFilter = &filter.DecisionTreeFilter{
+// newLowLatencyFilter returns the filter keeping the pods expected to serve a request with the
+// lowest latency, using the given LoRA affinity filter.
+func newLowLatencyFilter(loraAffinity plugins.Filter) *filter.DecisionTreeFilter {
+	return &filter.DecisionTreeFilter{
 		Current: filter.LowQueueFilter,
 		NextOnSuccess: &filter.DecisionTreeFilter{
-			Current: filter.LoRAAffinityFilter,
+			Current: loraAffinity,
 			NextOnSuccessOrFailure: &filter.DecisionTreeFilter{
 				Current: filter.LeastQueueFilter,
 				NextOnSuccessOrFailure: &filter.DecisionTreeFilter{
@@ -48,83 +58,656 @@ var (
 		NextOnFailure: &filter.DecisionTreeFilter{
 			Current: filter.LeastQueueFilter,
 			NextOnSuccessOrFailure: &filter.DecisionTreeFilter{
-				Current: filter.LoRAAffinityFilter,
+				Current: loraAffinity,
 				NextOnSuccessOrFailure: &filter.DecisionTreeFilter{
 					Current: filter.LeastKVCacheFilter,
 				},
 			},
 		},
 	}
+}
 
-	sheddableRequestFilter = &filter.DecisionTreeFilter{
+// newSheddableRequestFilter returns the filter keeping the pods that have capacity for a sheddable
+// request, narrowed down with the given low latency filter.
+func newSheddableRequestFilter(lowLatency plugins.Filter) *filter.DecisionTreeFilter {
+	return &filter.DecisionTreeFilter{
 		// When there is at least one model server that's not queuing requests, and still has KV
 		// cache below a certain threshold, we consider this model server has capacity to handle
 		// a sheddable request without impacting critical requests.
 		Current:       filter.HasCapacityFilter,
-		NextOnSuccess: lowLatencyFilter,
+		NextOnSuccess: lowLatency,
 		// If all pods are queuing or running above the KVCache threshold, we drop the sheddable
 		// request to make room for critical requests. for this, we don't define nextOnFailure.
 	}
+}
+
+var (
+	// fallbackPicker is used when the configured picker doesn't select any of the filtered pods.
+	fallbackPicker = &picker.RandomPicker{}
 )
 
-func NewScheduler(datastore Datastore) *Scheduler {
-	return NewSchedulerWithConfig(datastore, defaultConfig)
+// NewScheduler creates a scheduler with the default configuration, extended with the scorers
+// listed in the scheduler config. The scorers are instantiated from the scorer registry.
+func NewScheduler(ctx context.Context, datastore Datastore) (*Scheduler, error) {
+	// The background goroutines of the plugins run until the scheduler is shut down.
+	backgroundCtx, stopBackground := context.WithCancel(ctx)
+	cfg, err := newSchedulerConfig(backgroundCtx, datastore, config.Conf)
+	if err != nil {
+		stopBackground()
+		return nil, err
+	}
+	scheduler := NewSchedulerWithConfig(datastore, cfg)
+	scheduler.stopBackground = stopBackground
+	return scheduler, nil
 }
 
 func NewSchedulerWithConfig(datastore Datastore, config *SchedulerConfig) *Scheduler {
 	scheduler := &Scheduler{
-		datastore:           datastore,
-		preSchedulePlugins:  config.preSchedulePlugins,
-		scorers:             config.scorers,
-		filters:             config.filters,
-		postSchedulePlugins: config.postSchedulePlugins,
-		picker:              config.picker,
+		datastore:              datastore,
+		requestPreprocessors:   config.requestPreprocessors,
+		preSchedulePlugins:     config.preSchedulePlugins,
+		scorers:                config.scorers,
+		filters:                config.filters,
+		postSchedulePlugins:    config.postSchedulePlugins,
+		picker:                 config.picker,
+		criticalScorerWeights:  config.criticalScorerWeights,
+		sheddableScorerWeights: config.sheddableScorerWeights,
+		scoreCombiner:          scoreCombiners[ScoreCombinationSum],
+		decisionSampler:        newDecisionSampler(config.decisionLogSampleRate),
+		errorThrottler:         newErrorThrottler(config.errorLogInterval, clock.RealClock{}),
+		clock:                  clock.RealClock{},
+		pinSingleReplicaModels: config.pinSingleReplicaModels,
+		tieBreaker:             config.tieBreaker,
+		freshnessDecay:         config.freshnessDecay,
+		decisionReporter:       config.decisionReporter,
+		scorerTimeouts:         config.scorerTimeouts,
+		concurrencyLimiter:     newPoolConcurrencyLimiter(config.poolConcurrencyLimit, config.poolQueueTimeout),
+		poolSyncGrace:          config.poolSyncGrace,
+		minCandidatePods:       config.minCandidatePods,
+		drain:                  &requestDrain{},
+	}
+	scheduler.abandonedScorerCalls = make(map[string]*atomic.Int64, len(config.scorerTimeouts))
+	for name := range config.scorerTimeouts {
+		scheduler.abandonedScorerCalls[name] = &atomic.Int64{}
+	}
+	if scheduler.decisionReporter == nil {
+		scheduler.decisionReporter = noopDecisionReporter{}
+	}
+	if combiner, ok := scoreCombiners[config.scoreCombination]; ok {
+		scheduler.scoreCombiner = combiner
 	}
 
 	return scheduler
 }
 
 type Scheduler struct {
-	datastore           Datastore
-	preSchedulePlugins  []plugins.PreSchedule
-	filters             []plugins.Filter
-	scorers             []plugins.Scorer
-	postSchedulePlugins []plugins.PostSchedule
-	picker              plugins.Picker
+	datastore              Datastore
+	requestPreprocessors   []plugins.RequestPreprocessor
+	preSchedulePlugins     []plugins.PreSchedule
+	filters                []plugins.Filter
+	scorers                []plugins.Scorer
+	postSchedulePlugins    []plugins.PostSchedule
+	picker                 plugins.Picker
+	criticalScorerWeights  map[string]float64
+	sheddableScorerWeights map[string]float64
+	scoreCombiner          scoreCombiner
+	decisionSampler        *decisionSampler
+	errorThrottler         *errorThrottler
+	pinSingleReplicaModels bool
+	tieBreaker             plugins.Scorer
+	freshnessDecay         *freshnessDecay
+	decisionReporter       DecisionReporter
+	scorerTimeouts         map[string]time.Duration
+	concurrencyLimiter     *poolConcurrencyLimiter
+	poolSyncGrace          time.Duration
+	minCandidatePods       int
+	decisionCapture        *DecisionCapture
+	drain                  *requestDrain
+	stopBackground         context.CancelFunc
+	weightFunc             WeightFunc
+	// clock is the clock the age of the pod metrics is measured against. Replays stop it at the
+	// time the decision was captured.
+	clock clock.PassiveClock
+	// abandonedScorerCalls counts, for each timed scorer, the calls abandoned on timeout that are
+	// still running.
+	abandonedScorerCalls map[string]*atomic.Int64
+	// topK is the number of candidate pods ranked by ScheduleTopK, 0 when scheduling picks a
+	// target pod.
+	topK int
 }
 
 type Datastore interface {
+	PoolHasSynced() bool
 	PodGetAll() []backendmetrics.PodMetrics
+	PodIsUnhealthy(namespacedName k8stypes.NamespacedName) bool
+	PoolIsScalingUp() bool
 }
 
 // Schedule finds the target pod based on metrics and the requested lora adapter.
 func (s *Scheduler) Schedule(ctx context.Context, req *types.LLMRequest) (*types.Result, error) {
+	if !s.drain.start() {
+		return nil, errShuttingDown
+	}
+	defer s.drain.done()
+	var res *types.Result
+	var err error
+	before := time.Now()
+	if s.decisionCapture != nil {
+		res, err = s.scheduleCaptured(ctx, req)
+	} else {
+		res, err = s.schedule(ctx, req)
+	}
+	if err == nil {
+		metrics.RecordSchedulerE2ELatency(ctx, time.Since(before), res.TargetPod.GetPod().NamespacedName.String(), res.TargetPod.Score())
+	} else {
+		metrics.RecordSchedulerE2ELatency(ctx, time.Since(before), "", 0)
+	}
+	if logger := log.FromContext(ctx); s.decisionSampler.sample(err) && s.errorThrottler.allow(logger, err) {
+		logDecision(logger, req, res, err)
+	}
+	s.decisionReporter.Report(newDecision(req, res, err))
+	return res, err
+}
+
+// WithDecisionHistory records the decisions of the scheduler, along with the metrics they were
+// made on, in the given history. It must be called before the scheduler is used.
+func (s *Scheduler) WithDecisionHistory(history *DecisionHistory) *Scheduler {
+	s.postSchedulePlugins = append(append([]plugins.PostSchedule{}, s.postSchedulePlugins...), history)
+	return s
+}
+
+// WithDecisionCapture captures the inputs and outcome of the decisions of the scheduler to the
+// given capture, for replaying them with ReplayDecisions. It must be called before the scheduler
+// is used.
+func (s *Scheduler) WithDecisionCapture(capture *DecisionCapture) *Scheduler {
+	s.decisionCapture = capture
+	return s
+}
+
+// WithWeightFunc computes the scorer weights of each request with the given function instead of
+// the configured weights, e.g. to weight a scorer higher for long prompts. The weights set by the
+// request itself still take precedence. It must be called before the scheduler is used.
+func (s *Scheduler) WithWeightFunc(f WeightFunc) *Scheduler {
+	s.weightFunc = f
+	return s
+}
+
+// Close releases the resources of the scheduler, reporting the decisions still buffered.
+func (s *Scheduler) Close() {
+	s.decisionReporter.Close()
+}
+
+// Shutdown stops accepting requests, rejecting the new ones with a SchedulerShuttingDown error, and
+// waits for the requests being scheduled to complete until the context is done. It then stops the
+// background goroutines of the scheduler and closes it. The context error is returned if requests
+// were still being scheduled.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	err := s.drain.wait(ctx)
+	if s.stopBackground != nil {
+		s.stopBackground()
+	}
+	s.Close()
+	return err
+}
+
+var errShuttingDown = errutil.Error{
+	Code:              errutil.SchedulerShuttingDown,
+	Msg:               "the scheduler is shutting down",
+	RetryAfterSeconds: 1,
+}
+
+// requestDrain tracks the requests being scheduled, so that the scheduler can wait for them when
+// shutting down.
+type requestDrain struct {
+	mu       sync.RWMutex
+	draining bool
+	inFlight sync.WaitGroup
+}
+
+// start registers a request being scheduled, unless the scheduler is shutting down.
+func (d *requestDrain) start() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.draining {
+		return false
+	}
+	d.inFlight.Add(1)
+	return true
+}
+
+func (d *requestDrain) done() {
+	d.inFlight.Done()
+}
+
+// wait rejects the new requests and waits for the registered ones until the context is done.
+func (d *requestDrain) wait(ctx context.Context) error {
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+	drained := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) schedule(ctx context.Context, req *types.LLMRequest) (*types.Result, error) {
+	if err := s.waitForPoolSync(ctx); err != nil {
+		return nil, err
+	}
+	return s.scheduleSynced(ctx, req)
+}
+
+// scheduleSynced schedules the request once the pool is synced.
+func (s *Scheduler) scheduleSynced(ctx context.Context, req *types.LLMRequest) (*types.Result, error) {
+	if err := s.runRequestPreprocessors(ctx, req); err != nil {
+		return nil, err
+	}
+	if s.concurrencyLimiter == nil {
+		return s.scheduleRequest(ctx, req)
+	}
+	release, ok, err := s.concurrencyLimiter.acquire(ctx, req.Critical)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, s.concurrencyLimitError()
+	}
+	res, err := s.scheduleRequest(ctx, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	res.Release = release
+	return res, nil
+}
+
+// scheduleCaptured schedules the request on a snapshot of the datastore and captures it, so that
+// the captured pods are exactly the ones the decision was made on. Requests rejected before the
+// pool is synced aren't captured, as no pod was considered.
+// The capture covers the pods, their metrics and health, and the scaling state of the pool. The
+// state the plugins build up across requests, and the measurements the scorers refresh in the
+// background, such as the RTT and throughput of the pods, aren't captured, so a decision depending
+// on them may not replay exactly.
+func (s *Scheduler) scheduleCaptured(ctx context.Context, req *types.LLMRequest) (*types.Result, error) {
+	if err := s.waitForPoolSync(ctx); err != nil {
+		return nil, err
+	}
+	decision := newCapturedDecision(s.datastore, req, s.clock.Now())
+	snapshot := &snapshotDatastore{}
+	snapshot.load(decision)
+	captured := *s
+	captured.datastore = snapshot
+	captured.clock = fixedClock(decision.CapturedAt)
+	res, err := captured.scheduleSynced(ctx, req)
+	decision.setOutcome(res, err)
+	s.decisionCapture.record(log.FromContext(ctx), decision)
+	return res, err
+}
+
+// waitForPoolSync waits up to the pool sync grace period for the pool to be synced, so that the
+// requests received right after the EPP started aren't scheduled on a partial view of the pods.
+func (s *Scheduler) waitForPoolSync(ctx context.Context) error {
+	if s.datastore.PoolHasSynced() {
+		return nil
+	}
+	if s.poolSyncGrace > 0 {
+		timer := time.NewTimer(s.poolSyncGrace)
+		defer timer.Stop()
+		ticker := time.NewTicker(poolSyncPollInterval)
+		defer ticker.Stop()
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return checkAborted(ctx)
+			case <-timer.C:
+				break wait
+			case <-ticker.C:
+				if s.datastore.PoolHasSynced() {
+					return nil
+				}
+			}
+		}
+	}
+	return errPoolNotReady
+}
+
+// poolSyncPollInterval is the interval at which a request waiting for the pool to sync checks it.
+const poolSyncPollInterval = 10 * time.Millisecond
+
+var errPoolNotReady = errutil.Error{
+	Code:              errutil.PoolNotReady,
+	Msg:               "the inference pool is not synced yet",
+	RetryAfterSeconds: 1,
+}
+
+// concurrencyLimitError returns the error of a request dropped because the pool-wide concurrency
+// limit is reached.
+func (s *Scheduler) concurrencyLimitError() error {
+	return errutil.Error{
+		Code:       errutil.InferencePoolResourceExhausted,
+		Msg:        "the pool-wide limit of requests in flight is reached",
+		HTTPStatus: s.exhaustedStatusCode(),
+	}
+}
+
+// scheduleRequest selects the target pod of a preprocessed request.
+func (s *Scheduler) scheduleRequest(ctx context.Context, req *types.LLMRequest) (*types.Result, error) {
+	if len(req.ModelPreferences) > 0 {
+		return s.schedulePreferred(ctx, req)
+	}
+	if s.pinSingleReplicaModels {
+		if pods := s.podsServing(req.ResolvedTargetModel); len(pods) == 1 {
+			return s.schedulePinned(ctx, req, pods[0])
+		}
+	}
+	return s.scheduleAmong(ctx, req, s.datastore.PodGetAll())
+}
+
+// schedulePreferred schedules a request among the pods serving its most preferred model, falling
+// back to the next model if none of these pods is available, e.g. because they are all saturated.
+// The model the request is scheduled for is set as its resolved target model. The preferences are
+// soft: if none of them can be satisfied, the request is scheduled for the target model resolved
+// from its InferenceModel, like a request without preferences.
+func (s *Scheduler) schedulePreferred(ctx context.Context, req *types.LLMRequest) (*types.Result, error) {
+	logger := log.FromContext(ctx).V(logutil.DEBUG)
+	resolvedTargetModel := req.ResolvedTargetModel
+	for _, model := range req.ModelPreferences {
+		pods := s.podsServing(model)
+		if len(pods) == 0 {
+			logger.Info("No pod serves the preferred model, trying the next one", "model", model)
+			continue
+		}
+		req.ResolvedTargetModel = model
+		res, err := s.scheduleAmong(ctx, req, pods)
+		if err == nil {
+			return res, nil
+		}
+		switch errutil.CanonicalCode(err) {
+		case errutil.InferencePoolResourceExhausted, errutil.NoCandidateAfterFilter, errutil.NoReadyEndpoints:
+			logger.Info("No pod serving the preferred model is available, trying the next one", "model", model, "error", err)
+		default:
+			return nil, err
+		}
+	}
+	logger.Info("No preferred model can be served, falling back to the resolved target model",
+		"preferences", req.ModelPreferences, "model", resolvedTargetModel)
+	req.ResolvedTargetModel = resolvedTargetModel
+	return s.scheduleAmong(ctx, req, s.datastore.PodGetAll())
+}
+
+// scheduleAmong schedules the request among the given pods.
+func (s *Scheduler) scheduleAmong(ctx context.Context, req *types.LLMRequest, snapshot []backendmetrics.PodMetrics) (*types.Result, error) {
+	sCtx, pods, err := s.filter(ctx, req, snapshot, false)
+	if err != nil {
+		return nil, err
+	}
+	if s.topK > 0 {
+		return s.rank(sCtx, pods)
+	}
+	loggerDebug := sCtx.Logger.V(logutil.DEBUG)
+
+	// With a single candidate there is nothing to rank, so scoring and picking are skipped.
+	if len(pods) == 1 {
+		loggerDebug.Info("Single candidate pod, skipping scoring", "pod", pods[0].GetPod().NamespacedName)
+		res := &types.Result{TargetPod: pods[0]}
+		s.runPostSchedulePlugins(sCtx, res)
+		return res, nil
+	}
+
+	if err := s.runScorerPlugins(sCtx, pods); err != nil {
+		return nil, err
+	}
+	candidates := s.breakTies(sCtx, pods)
+
+	before := time.Now()
+	res := s.picker.Pick(sCtx, candidates)
+	metrics.RecordSchedulerPluginProcessingLatency(plugins.PickerPluginType, s.picker.Name(), time.Since(before))
+	loggerDebug.Info("After running picker plugins", "result", res)
+	if res == nil || res.TargetPod == nil {
+		// Filtering succeeded, so there is a candidate to serve the request even though the picker
+		// could not rank one. Fall back to picking uniformly among the filtered pods.
+		loggerDebug.Info("Picker did not select a pod, falling back to a random filtered pod", "picker", s.picker.Name())
+		res = fallbackPicker.Pick(sCtx, candidates)
+	}
+	if res == nil || res.TargetPod == nil {
+		return nil, errutil.Error{Code: errutil.NoCandidateAfterFilter, Msg: "no candidate pod to pick from", HTTPStatus: s.scalingUpStatusCode()}
+	}
+	if req.WantBackupPod || req.Redundant {
+		// The backup is chosen among all the filtered pods, not only the ones tied for the top score.
+		res.BackupPod = backupPod(pods, res.TargetPod, req.Redundant)
+		loggerDebug.Info("Selected backup pod", "backupPod", res.BackupPod, "redundant", req.Redundant)
+	}
+
+	s.runPostSchedulePlugins(sCtx, res)
+
+	return res, nil
+}
+
+// podsServing returns the pods that can serve the model: the pods that have it loaded or are
+// loading it, and the pods with room to load it.
+func (s *Scheduler) podsServing(model string) []backendmetrics.PodMetrics {
+	var pods []backendmetrics.PodMetrics
+	for _, pm := range s.datastore.PodGetAll() {
+		m := pm.GetMetrics()
+		if m == nil {
+			continue
+		}
+		_, active := m.ActiveModels[model]
+		_, waiting := m.WaitingModels[model]
+		if active || waiting || len(m.ActiveModels)+len(m.WaitingModels) < m.MaxActiveModels {
+			pods = append(pods, pm)
+		}
+	}
+	return pods
+}
+
+// schedulePinned schedules a request for a model that a single pod can serve among that pod alone,
+// so that a momentary metrics glitch doesn't route it to a pod that can't serve the model. The
+// filters still run, so the request is shed or rejected like any other, while there is nothing to
+// score nor pick. If the pod is filtered out, the request fails rather than going elsewhere.
+func (s *Scheduler) schedulePinned(ctx context.Context, req *types.LLMRequest, pm backendmetrics.PodMetrics) (*types.Result, error) {
+	log.FromContext(ctx).V(logutil.DEBUG).Info("Model is served by a single pod, pinning the request", "pod", pm.GetPod().NamespacedName)
+	res, err := s.scheduleAmong(ctx, req, []backendmetrics.PodMetrics{pm})
+	return res, s.pinnedPodError(req, pm, err)
+}
+
+// pinnedPodError names the pod a request was pinned to in the error returned when that pod is
+// filtered out.
+func (s *Scheduler) pinnedPodError(req *types.LLMRequest, pm backendmetrics.PodMetrics, err error) error {
+	e, ok := err.(errutil.Error)
+	if !ok || (e.Code != errutil.NoCandidateAfterFilter && e.Code != errutil.InferencePoolResourceExhausted) {
+		return err
+	}
+	e.Msg = fmt.Sprintf("model %q is only served by pod %v, which is unavailable: %s", req.ResolvedTargetModel, pm.GetPod().NamespacedName, e.Msg)
+	return e
+}
+
+// WouldAdmit reports whether Schedule would currently find a pod for the request or drop it, and
+// why it would be dropped. Only the admission part of scheduling runs: the request is filtered in
+// dry run mode, so the plugins don't record it, and no pod is scored nor selected. The request
+// preprocessors run on a copy of the request, so it is left untouched.
+func (s *Scheduler) WouldAdmit(ctx context.Context, req *types.LLMRequest) (bool, string) {
+	if !s.datastore.PoolHasSynced() {
+		return false, errPoolNotReady.Error()
+	}
+	evaluated := *req
+	if err := s.runRequestPreprocessors(ctx, &evaluated); err != nil {
+		return false, err.Error()
+	}
+	if !evaluated.Critical && s.concurrencyLimiter.full() {
+		return false, s.concurrencyLimitError().Error()
+	}
+	if s.pinSingleReplicaModels {
+		if pods := s.podsServing(evaluated.ResolvedTargetModel); len(pods) == 1 {
+			if _, _, err := s.filter(ctx, &evaluated, pods, true); err != nil {
+				return false, s.pinnedPodError(&evaluated, pods[0], err).Error()
+			}
+			return true, ""
+		}
+	}
+	if _, _, err := s.filter(ctx, &evaluated, s.datastore.PodGetAll(), true); err != nil {
+		return false, err.Error()
+	}
+	return true, ""
+}
+
+// backupPod returns the highest scored pod other than the target pod, or nil if there is none.
+// If otherNode is set, the pods on the node of the target pod are skipped too, so that the backup
+// survives the loss of that node.
+func backupPod(pods []types.Pod, target types.Pod, otherNode bool) types.Pod {
+	var backup types.Pod
+	for _, pod := range pods {
+		if pod.GetPod().NamespacedName == target.GetPod().NamespacedName {
+			continue
+		}
+		if otherNode && pod.GetPod().NodeName == target.GetPod().NodeName {
+			continue
+		}
+		if backup == nil || pod.Score() > backup.Score() {
+			backup = pod
+		}
+	}
+	return backup
+}
+
+// ScheduleTopK returns up to k candidate pods for the request ranked by decreasing score, as the
+// Candidates of the result, leaving the final selection to the caller. The request goes through the
+// same steps as with Schedule, up to picking the target pod, and the ties between the top-scored
+// pods are broken the same way, so that the first candidate, also set as the target pod, is one
+// Schedule could pick. Post-schedule plugins are not run since no target is chosen, and the ranking
+// isn't reported nor captured as a decision.
+func (s *Scheduler) ScheduleTopK(ctx context.Context, req *types.LLMRequest, k int) (*types.Result, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be positive, got %d", k)
+	}
+	if !s.drain.start() {
+		return nil, errShuttingDown
+	}
+	defer s.drain.done()
+	ranking := *s
+	ranking.topK = k
+	return ranking.schedule(ctx, req)
+}
+
+// rank returns up to topK of the candidate pods ranked by decreasing score, the pods winning the tie
+// between the top-scored pods first.
+func (s *Scheduler) rank(ctx *types.SchedulingContext, pods []types.Pod) (*types.Result, error) {
+	if len(pods) > 1 {
+		if err := s.runScorerPlugins(ctx, pods); err != nil {
+			return nil, err
+		}
+	}
+	byScore := func(pods []types.Pod) {
+		sort.SliceStable(pods, func(i, j int) bool {
+			return pods[i].Score() > pods[j].Score()
+		})
+	}
+	ranked := append([]types.Pod{}, s.breakTies(ctx, pods)...)
+	byScore(ranked)
+	if len(ranked) < len(pods) {
+		winners := make(map[k8stypes.NamespacedName]bool, len(ranked))
+		for _, pod := range ranked {
+			winners[pod.GetPod().NamespacedName] = true
+		}
+		rest := []types.Pod{}
+		for _, pod := range pods {
+			if !winners[pod.GetPod().NamespacedName] {
+				rest = append(rest, pod)
+			}
+		}
+		byScore(rest)
+		ranked = append(ranked, rest...)
+	}
+	if s.topK < len(ranked) {
+		ranked = ranked[:s.topK]
+	}
+	ctx.Logger.V(logutil.DEBUG).Info("Ranked the candidate pods", "candidates", ranked)
+	return &types.Result{TargetPod: ranked[0], Candidates: ranked}, nil
+}
+
+// filter runs the pre-schedule and filter plugins on a snapshot of the given pods and returns the
+// candidate pods. In a dry run, the pre-schedule plugins are skipped and the filters are told not
+// to record the request.
+func (s *Scheduler) filter(ctx context.Context, req *types.LLMRequest, candidates []backendmetrics.PodMetrics, dryRun bool) (*types.SchedulingContext, []types.Pod, error) {
 	logger := log.FromContext(ctx).WithValues("request", req)
 	loggerDebug := logger.V(logutil.DEBUG)
 
 	// Snapshot pod metrics from the datastore to:
 	// 1. Reduce concurrent access to the datastore.
 	// 2. Ensure consistent data during the scheduling operation of a request.
-	sCtx := types.NewSchedulingContext(ctx, req, types.ToSchedulerPodMetrics(s.datastore.PodGetAll()))
-	loggerDebug.Info(fmt.Sprintf("Scheduling a request. Metrics: %+v", sCtx.PodsSnapshot))
+	sCtx := types.NewSchedulingContext(ctx, req, types.ToSchedulerPodMetrics(candidates))
+	sCtx.PodIsUnhealthy = s.datastore.PodIsUnhealthy
+	sanitizeMetrics(sCtx.Logger, sCtx.PodsSnapshot)
+	if loggerDebug.Enabled() {
+		loggerDebug.Info(fmt.Sprintf("Scheduling a request. Metrics: %+v", sCtx.PodsSnapshot))
+	}
+
+	sCtx.DryRun = dryRun
+	if !dryRun {
+		s.runPreSchedulePlugins(sCtx)
+	}
+	if err := checkAborted(sCtx); err != nil {
+		return nil, nil, err
+	}
 
-	s.runPreSchedulePlugins(sCtx)
+	if len(sCtx.PodsSnapshot) == 0 {
+		return nil, nil, errutil.Error{Code: errutil.NoReadyEndpoints, Msg: "no ready pod in the inference pool", HTTPStatus: s.scalingUpStatusCode()}
+	}
 
 	pods := s.runFilterPlugins(sCtx)
 	if len(pods) == 0 {
-		return nil, errutil.Error{Code: errutil.InferencePoolResourceExhausted, Msg: "failed to find a target pod"}
+		// Sheddable requests are dropped when no pod has capacity for them, while critical requests
+		// are only left without candidates when no pod can serve them at all.
+		if req.Critical {
+			return nil, nil, errutil.Error{Code: errutil.NoCandidateAfterFilter, Msg: "all pods were filtered out", HTTPStatus: s.scalingUpStatusCode()}
+		}
+		return nil, nil, errutil.Error{Code: errutil.InferencePoolResourceExhausted, Msg: "failed to find a target pod", HTTPStatus: s.exhaustedStatusCode()}
 	}
+	return sCtx, pods, nil
+}
 
-	s.runScorerPlugins(sCtx, pods)
-
-	before := time.Now()
-	res := s.picker.Pick(sCtx, pods)
-	metrics.RecordSchedulerPluginProcessingLatency(plugins.PickerPluginType, s.picker.Name(), time.Since(before))
-	loggerDebug.Info("After running picker plugins", "result", res)
+// exhaustedStatusCode returns the HTTP status code for a request dropped for lack of capacity,
+// which depends on whether the pool is scaling up or is saturated.
+func (s *Scheduler) exhaustedStatusCode() int {
+	if s.datastore.PoolIsScalingUp() {
+		return config.Conf.ScalingUpStatusCode
+	}
+	return config.Conf.SaturatedStatusCode
+}
 
-	s.runPostSchedulePlugins(sCtx, res)
+// scalingUpStatusCode returns the HTTP status code for a request that no pod can serve while the
+// pool is scaling up, or 0 to use the default status of the error.
+func (s *Scheduler) scalingUpStatusCode() int {
+	if s.datastore.PoolIsScalingUp() {
+		return config.Conf.ScalingUpStatusCode
+	}
+	return 0
+}
 
-	return res, nil
+// runRequestPreprocessors runs the request preprocessors in order, stopping at the first one that
+// rejects the request. The error is returned as is, so that preprocessors can set the HTTP status
+// of the rejection with an errutil.Error.
+func (s *Scheduler) runRequestPreprocessors(ctx context.Context, req *types.LLMRequest) error {
+	logger := log.FromContext(ctx)
+	for _, preprocessor := range s.requestPreprocessors {
+		logger.V(logutil.DEBUG).Info("Running request preprocessor", "plugin", preprocessor.Name())
+		before := time.Now()
+		err := preprocessor.Preprocess(ctx, req)
+		metrics.RecordSchedulerPluginProcessingLatency(plugins.RequestPreprocessorPluginType, preprocessor.Name(), time.Since(before))
+		if err != nil {
+			logger.V(logutil.DEBUG).Info("Request rejected by preprocessor", "plugin", preprocessor.Name(), "error", err)
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *Scheduler) runPreSchedulePlugins(ctx *types.SchedulingContext) {
@@ -150,46 +733,375 @@ func (s *Scheduler) runFilterPlugins(ctx *types.SchedulingContext) []types.Pod {
 	filteredPods := ctx.PodsSnapshot
 	loggerDebug.Info("Before running filter plugins", "pods", filteredPods)
 
+	// relaxable are the pods dropped by the last filter that dropped any, if that filter can be
+	// relaxed. The pods dropped by the other filters, e.g. because they are terminating, unhealthy or
+	// have no capacity for a sheddable request, are never re-added.
+	var relaxable []types.Pod
 	for _, filter := range s.filters {
 		loggerDebug.Info("Running filter plugin", "plugin", filter.Name())
 		before := time.Now()
+		input := filteredPods
 		filteredPods = filter.Filter(ctx, filteredPods)
-		metrics.RecordSchedulerPluginProcessingLatency(plugins.FilterPluginType, filter.Name(), time.Since(before))
+		if !ctx.DryRun {
+			metrics.RecordSchedulerPluginProcessingLatency(plugins.FilterPluginType, filter.Name(), time.Since(before))
+		}
 		loggerDebug.Info("Filter plugin result", "plugin", filter.Name(), "pods", filteredPods)
 		if len(filteredPods) == 0 {
 			break
 		}
+		if len(filteredPods) < len(input) {
+			relaxable = nil
+			if rf, ok := filter.(relaxableFilter); ok {
+				relaxable = rf.relaxable(ctx, input, filteredPods)
+			}
+		}
+	}
+	// A request left without candidates was rejected by the filters, e.g. to shed it, so it isn't
+	// relaxed.
+	if len(filteredPods) == 0 || len(filteredPods) >= s.minCandidatePods || len(relaxable) == 0 {
+		return filteredPods
 	}
-	return filteredPods
+	return s.relaxFilter(ctx, filteredPods, relaxable)
+}
+
+// relaxableFilter is implemented by the filters that express preferences rather than requirements,
+// so that the pods they drop may be re-added to keep the minimum number of candidate pods.
+type relaxableFilter interface {
+	// relaxable returns the pods dropped by the filter, from input to output, that may be re-added.
+	relaxable(ctx *types.SchedulingContext, input, output []types.Pod) []types.Pod
 }
 
-func (s *Scheduler) runScorerPlugins(ctx *types.SchedulingContext, pods []types.Pod) {
+// relaxFilter re-adds the least loaded of the relaxable pods to the filtered pods, up to the minimum
+// number of candidate pods, and marks them as relaxed in the scheduling context.
+func (s *Scheduler) relaxFilter(ctx *types.SchedulingContext, filtered, relaxable []types.Pod) []types.Pod {
+	sort.SliceStable(relaxable, func(i, j int) bool {
+		mi, mj := relaxable[i].GetMetrics(), relaxable[j].GetMetrics()
+		if mi.WaitingQueueSize != mj.WaitingQueueSize {
+			return mi.WaitingQueueSize < mj.WaitingQueueSize
+		}
+		return mi.KVCacheUsagePercent < mj.KVCacheUsagePercent
+	})
+	relaxed := relaxable[:min(len(relaxable), s.minCandidatePods-len(filtered))]
+	ctx.RelaxedPods = make(map[k8stypes.NamespacedName]bool, len(relaxed))
+	for _, pod := range relaxed {
+		ctx.RelaxedPods[pod.GetPod().NamespacedName] = true
+	}
+	ctx.Logger.V(logutil.DEBUG).Info("Too few candidate pods after filtering, relaxing the last filter",
+		"candidates", len(filtered), "minimum", s.minCandidatePods, "relaxed", relaxed)
+	return append(append([]types.Pod{}, filtered...), relaxed...)
+}
+
+// droppedPods returns the pods of input missing from output.
+func droppedPods(input, output []types.Pod) []types.Pod {
+	kept := make(map[k8stypes.NamespacedName]bool, len(output))
+	for _, pod := range output {
+		kept[pod.GetPod().NamespacedName] = true
+	}
+	dropped := []types.Pod{}
+	for _, pod := range input {
+		if !kept[pod.GetPod().NamespacedName] {
+			dropped = append(dropped, pod)
+		}
+	}
+	return dropped
+}
+
+// relaxedPodScoreFactor lowers the scores of the pods re-added to meet the minimum number of
+// candidate pods, so that the pods passing the filters are preferred unless they score much lower.
+const relaxedPodScoreFactor = 0.5
+
+// runScorerPlugins scores the pods, stopping early if the request context is done, e.g. because
+// the client disconnected. A scorer panic fails the request with a ScoringFailed error rather than
+// the EPP.
+func (s *Scheduler) runScorerPlugins(ctx *types.SchedulingContext, pods []types.Pod) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ctx.Logger.Error(nil, "Scorer panicked", "panic", r)
+			err = errutil.Error{Code: errutil.ScoringFailed, Msg: fmt.Sprintf("scorer panicked: %v", r)}
+		}
+	}()
 	loggerDebug := ctx.Logger.V(logutil.DEBUG)
-	loggerDebug.Info("Before running score plugins", "pods", pods)
+	// Scoring runs for every pod and scorer on the hot path, so the debug logs, whose arguments
+	// allocate even when they are discarded, are only built when enabled.
+	debug := loggerDebug.Enabled()
+	if debug {
+		loggerDebug.Info("Before running score plugins", "pods", pods)
+	}
+	// scores[i][j] is the score of pods[j] from the i-th scorer, and scores[i] is nil if that scorer
+	// timed out.
+	scores := make([][]float64, len(s.scorers))
+	for i, scorer := range s.scorers {
+		if debug {
+			loggerDebug.Info("Running scorer", "scorer", scorer.Name())
+		}
+		before := time.Now()
+		if scores[i], err = s.runScorer(ctx, scorer, pods); err != nil {
+			return err
+		}
+		metrics.RecordSchedulerPluginProcessingLatency(plugins.ScorerPluginType, scorer.Name(), time.Since(before))
+	}
+	if err := checkAborted(ctx); err != nil {
+		return err
+	}
+	// The top choice of each scorer, to detect scorers that disagree.
+	var tops []scorerTop
+	if len(s.scorers) > 1 {
+		tops = make([]scorerTop, len(s.scorers))
+	}
+	for i, pod := range pods {
+		pod.SetScore(s.combineScores(ctx, pod, i, scores, tops, debug))
+	}
+	if debug {
+		loggerDebug.Info("After running score plugins", "pods", pods)
+	}
+	s.recordScorerDisagreement(ctx, tops)
+	return nil
+}
+
+// runScorer scores the pods with the scorer. If the scorer has a timeout and doesn't score all the
+// pods in time, no scores are returned, so that the scorer is left out of the combined scores
+// without holding up the other scorers.
+// The scorer runs in a single goroutine over all the pods, given a context canceled on timeout, so
+// that it can stop its work: a scorer that doesn't honor it keeps its goroutine until it returns.
+// Timed scorers must therefore honor the context and be safe for concurrent use. As long as a call
+// abandoned on timeout is still running, the scorer is left out of the scores of the next requests
+// rather than called again, so that the calls of a stuck scorer don't pile up.
+func (s *Scheduler) runScorer(ctx *types.SchedulingContext, scorer plugins.Scorer, pods []types.Pod) ([]float64, error) {
+	timeout, ok := s.scorerTimeouts[scorer.Name()]
+	if !ok {
+		return scorePods(ctx, scorer, pods)
+	}
+	abandoned := s.abandonedScorerCalls[scorer.Name()]
+	if abandoned.Load() > 0 {
+		ctx.Logger.V(logutil.DEBUG).Info("Scorer is still running a call abandoned on timeout, leaving it out of the scores", "scorer", scorer.Name())
+		metrics.RecordScorerTimeout(scorer.Name())
+		return nil, nil
+	}
+	scorerCtx, cancel := context.WithTimeout(ctx.Context, timeout)
+	defer cancel()
+	scored := make(chan []float64, 1)
+	// A panic in the scorer goroutine is handed over to the caller, which recovers from it.
+	panicked := make(chan any, 1)
+	// state is set to callDone by the scorer goroutine when it returns, or to callAbandoned by the
+	// caller when it stops waiting for it on timeout, whichever comes first.
+	var state atomic.Int32
+	go func() {
+		defer func() {
+			if !state.CompareAndSwap(callRunning, callDone) {
+				abandoned.Add(-1)
+			}
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				panicked <- r
+			}
+		}()
+		// The scores are only handed over if all the pods were scored in time.
+		if scores, err := scorePods(&types.SchedulingContext{
+			Context:      scorerCtx,
+			Logger:       ctx.Logger,
+			Req:          ctx.Req,
+			PodsSnapshot: ctx.PodsSnapshot,
+		}, scorer, pods); err == nil {
+			scored <- scores
+		}
+	}()
+	select {
+	case scores := <-scored:
+		return scores, nil
+	case r := <-panicked:
+		panic(r)
+	case <-scorerCtx.Done():
+		if state.CompareAndSwap(callRunning, callAbandoned) {
+			abandoned.Add(1)
+		}
+		if err := checkAborted(ctx); err != nil {
+			return nil, err
+		}
+		ctx.Logger.V(logutil.DEBUG).Info("Scorer timed out, leaving it out of the scores", "scorer", scorer.Name(), "timeout", timeout)
+		metrics.RecordScorerTimeout(scorer.Name())
+		return nil, nil
+	}
+}
+
+// The states of a call to a timed scorer.
+const (
+	callRunning int32 = iota
+	callDone
+	callAbandoned
+)
+
+// scorePods scores the pods with the scorer, stopping early if the context is done.
+func scorePods(ctx *types.SchedulingContext, scorer plugins.Scorer, pods []types.Pod) ([]float64, error) {
+	scores := make([]float64, len(pods))
+	for i, pod := range pods {
+		if err := checkAborted(ctx); err != nil {
+			return nil, err
+		}
+		scores[i] = scorer.Score(ctx, pod)
+	}
+	return scores, nil
+}
+
+// breakTies narrows the pods down to the top-scored pods that the tie-breaker scores highest, so
+// the picker only chooses among them. Top-scored pods passing the filters are preferred over the
+// ones re-added to keep the minimum number of candidate pods, whatever the tie-breaker. The pods are
+// returned untouched if there is nothing to break, or if a single pod has the top score.
+func (s *Scheduler) breakTies(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	if s.tieBreaker == nil && len(ctx.RelaxedPods) == 0 {
+		return pods
+	}
+	tied := []types.Pod{}
 	for _, pod := range pods {
-		score := s.runScorersForPod(ctx, pod)
-		pod.SetScore(score)
+		switch {
+		case len(tied) == 0 || pod.Score() > tied[0].Score():
+			tied = []types.Pod{pod}
+		case pod.Score() == tied[0].Score():
+			tied = append(tied, pod)
+		}
+	}
+	if len(tied) < 2 {
+		return pods
 	}
-	loggerDebug.Info("After running score plugins", "pods", pods)
+	if passing := notRelaxed(ctx, tied); len(passing) > 0 && len(passing) < len(tied) {
+		ctx.Logger.V(logutil.DEBUG).Info("Preferring the top-scored pods passing the filters over the relaxed ones", "pods", passing)
+		if s.tieBreaker == nil || len(passing) == 1 {
+			return passing
+		}
+		tied = passing
+	}
+	if s.tieBreaker == nil {
+		return pods
+	}
+
+	before := time.Now()
+	winners := []types.Pod{}
+	best := 0.0
+	for _, pod := range tied {
+		score := sanitizeScore(ctx.Logger, s.tieBreaker.Name(), pod, s.tieBreaker.Score(ctx, pod))
+		switch {
+		case len(winners) == 0 || score > best:
+			winners, best = []types.Pod{pod}, score
+		case score == best:
+			winners = append(winners, pod)
+		}
+	}
+	metrics.RecordSchedulerPluginProcessingLatency(plugins.ScorerPluginType, s.tieBreaker.Name(), time.Since(before))
+	ctx.Logger.V(logutil.DEBUG).Info("Broke tie between top-scored pods", "tieBreaker", s.tieBreaker.Name(), "tied", len(tied), "winners", winners)
+	return winners
 }
 
-// Iterate through each scorer in the chain and accumulate the scores.
-func (s *Scheduler) runScorersForPod(ctx *types.SchedulingContext, pod types.Pod) float64 {
-	logger := ctx.Logger.WithValues("pod", pod.GetPod().NamespacedName).V(logutil.DEBUG)
+// notRelaxed returns the pods that aren't relaxed.
+func notRelaxed(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
+	passing := []types.Pod{}
+	for _, pod := range pods {
+		if !ctx.RelaxedPods[pod.GetPod().NamespacedName] {
+			passing = append(passing, pod)
+		}
+	}
+	return passing
+}
+
+// checkAborted returns an error if the context is done: a SchedulingTimeout error if the deadline
+// was exceeded, or a SchedulingCanceled error if it was canceled, e.g. because the client
+// disconnected.
+func checkAborted(ctx context.Context) error {
+	err := ctx.Err()
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return errutil.Error{Code: errutil.SchedulingTimeout, Msg: "scheduling aborted: " + err.Error()}
+	default:
+		return errutil.Error{Code: errutil.SchedulingCanceled, Msg: "scheduling aborted: " + err.Error()}
+	}
+}
+
+// combineScores combines the weighted scores of the pod at the given index from each scorer. The
+// scorers that timed out are left out, as no score is neutral for all the score combinations. The
+// scores of each scorer are also observed in tops, if set.
+func (s *Scheduler) combineScores(ctx *types.SchedulingContext, pod types.Pod, index int, scores [][]float64, tops []scorerTop, debug bool) float64 {
+	var logger logr.Logger
+	if debug {
+		logger = ctx.Logger.WithValues("pod", pod.GetPod().NamespacedName).V(logutil.DEBUG)
+	}
 	score := float64(0)
-	for _, scorer := range s.scorers {
-		logger.Info("Running scorer", "scorer", scorer.Name())
-		before := time.Now()
-		oneScore := scorer.Score(ctx, pod)
-		metrics.RecordSchedulerPluginProcessingLatency(plugins.ScorerPluginType, scorer.Name(), time.Since(before))
-		score += oneScore
-		logger.Info("After scorer", "scorer", scorer.Name(), "score", oneScore, "total score", score)
+	first := true
+	for i, scorer := range s.scorers {
+		if scores[i] == nil {
+			continue
+		}
+		name := scorer.Name()
+		oneScore := sanitizeScore(ctx.Logger, name, pod, scores[i][index])
+		if tops != nil {
+			tops[i].observe(pod, oneScore)
+		}
+		weight := s.scorerWeight(ctx.Req, name)
+		score = s.scoreCombiner(score, first, oneScore, weight)
+		first = false
+		if debug {
+			logger.Info("After scorer", "scorer", name, "score", oneScore, "weight", weight, "total score", score)
+		}
+	}
+	if s.freshnessDecay != nil {
+		age := s.clock.Since(pod.GetMetrics().UpdateTime)
+		multiplier := s.freshnessDecay.multiplier(age)
+		score *= multiplier
+		if debug {
+			logger.Info("After freshness decay", "metricsAge", age, "multiplier", multiplier, "total score", score)
+		}
+	}
+	if ctx.RelaxedPods[pod.GetPod().NamespacedName] {
+		score *= relaxedPodScoreFactor
+		if debug {
+			logger.Info("After relaxed pod penalty", "total score", score)
+		}
 	}
 	return score
 }
 
+// WeightFunc returns the weight applied to the scores of the named scorer for the request.
+type WeightFunc func(req *types.LLMRequest, scorerName string) float64
+
+// scorerWeight returns the weight of the given scorer, using the request overrides if any, or else
+// the weight function if set, or else the configured weight.
+func (s *Scheduler) scorerWeight(req *types.LLMRequest, scorerName string) float64 {
+	if weight, ok := req.ScorerOverrides[scorerName]; ok {
+		return weight
+	}
+	if s.weightFunc != nil {
+		return s.weightFunc(req, scorerName)
+	}
+	return s.StaticWeight(req, scorerName)
+}
+
+// StaticWeight returns the configured weight of the given scorer, from the weight profile that
+// matches the request criticality, the same way the default filter branches on it. A WeightFunc
+// can build on it to adjust the configured weights.
+func (s *Scheduler) StaticWeight(req *types.LLMRequest, scorerName string) float64 {
+	weights := s.sheddableScorerWeights
+	if req.Critical {
+		weights = s.criticalScorerWeights
+	}
+	if weight, ok := weights[scorerName]; ok {
+		return weight
+	}
+	return 1
+}
+
+// newDefaultPlugin returns the default filter and picker, using the given LoRA affinity filter.
+func newDefaultPlugin(loraAffinity plugins.Filter) *defaultPlugin {
+	lowLatency := newLowLatencyFilter(loraAffinity)
+	return &defaultPlugin{
+		lowLatencyFilter:       lowLatency,
+		sheddableRequestFilter: newSheddableRequestFilter(lowLatency),
+	}
+}
+
 type defaultPlugin struct {
 	picker.RandomPicker
+	lowLatencyFilter       plugins.Filter
+	sheddableRequestFilter plugins.Filter
 }
 
 func (p *defaultPlugin) Name() string {
@@ -198,8 +1110,22 @@ func (p *defaultPlugin) Name() string {
 
 func (p *defaultPlugin) Filter(ctx *types.SchedulingContext, pods []types.Pod) []types.Pod {
 	if ctx.Req.Critical {
-		return lowLatencyFilter.Filter(ctx, pods)
+		return p.lowLatencyFilter.Filter(ctx, pods)
 	}
 
-	return sheddableRequestFilter.Filter(ctx, pods)
+	return p.sheddableRequestFilter.Filter(ctx, pods)
+}
+
+// relaxable returns the pods dropped by the low latency filter, which only expresses preferences.
+// For sheddable requests, the pods without capacity dropped by HasCapacityFilter are left out, so
+// that relaxing the filter doesn't defeat shedding.
+func (p *defaultPlugin) relaxable(ctx *types.SchedulingContext, input, output []types.Pod) []types.Pod {
+	dropped := droppedPods(input, output)
+	if ctx.Req.Critical || len(dropped) == 0 {
+		return dropped
+	}
+	// The filter is only evaluated, so its metrics aren't recorded twice.
+	dryRun := *ctx
+	dryRun.DryRun = true
+	return filter.HasCapacityFilter.Filter(&dryRun, dropped)
 }
@@ -18,13 +18,29 @@ package scheduling
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+	metricstestutil "k8s.io/component-base/metrics/testutil"
 	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics" // Import config for thresholds
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/filter"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/picker"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
 	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	errutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/error"
 )
 
 // Tests the default scheduler configuration and expected behavior.
@@ -393,8 +409,1870 @@ func TestSchedulePlugins(t *testing.T) {
 	}
 }
 
+func TestScheduleSingleCandidateSkipsScoring(t *testing.T) {
+	tp := &TestPlugin{
+		NameRes:   "test",
+		FilterRes: []k8stypes.NamespacedName{{Name: "pod2"}},
+	}
+	pickerPlugin := &TestPlugin{NameRes: "picker", PickRes: k8stypes.NamespacedName{Name: "pod1"}}
+	schedConfig := &SchedulerConfig{
+		filters:             []plugins.Filter{tp},
+		scorers:             []plugins.Scorer{tp},
+		postSchedulePlugins: []plugins.PostSchedule{tp},
+		picker:              pickerPlugin,
+	}
+	input := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}},
+	}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: input}, schedConfig)
+
+	got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name := got.TargetPod.GetPod().NamespacedName.Name; name != "pod2" {
+		t.Errorf("Unexpected target pod, got %v, want pod2", name)
+	}
+	if tp.ScoreCallCount != 0 {
+		t.Errorf("Expected no scorer calls, got %d", tp.ScoreCallCount)
+	}
+	if pickerPlugin.PickCallCount != 0 {
+		t.Errorf("Expected no picker calls, got %d", pickerPlugin.PickCallCount)
+	}
+	// Post-schedule plugins, e.g. ones pinning sessions to the target pod, still run.
+	if tp.PostScheduleCallCount != 1 {
+		t.Errorf("Expected 1 post-schedule call, got %d", tp.PostScheduleCallCount)
+	}
+}
+
+func TestScheduleExcludesTerminatingPods(t *testing.T) {
+	running := &backendmetrics.FakePodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "running"}},
+		Metrics: &backendmetrics.Metrics{},
+	}
+	terminating := &backendmetrics.FakePodMetrics{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "terminating"}},
+		Metrics: &backendmetrics.Metrics{},
+	}
+	// Mark the pod for deletion, the way the pod reconciler would update it.
+	terminating.UpdatePod(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "terminating",
+			DeletionTimestamp: &metav1.Time{Time: time.Now()},
+		},
+	})
+
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: []*backendmetrics.FakePodMetrics{running, terminating}}, defaultConfig)
+	for i := 0; i < 20; i++ {
+		got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model", Critical: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got.TargetPod.GetPod().NamespacedName.Name != "running" {
+			t.Errorf("Unexpected target pod, got %v, want running", got.TargetPod.GetPod().NamespacedName)
+		}
+	}
+}
+
+func TestScheduleScorerWeightProfiles(t *testing.T) {
+	// lowQueue is preferred by the queue scorer, lowKVCache is preferred by the KV cache scorer.
+	pods := []*backendmetrics.FakePodMetrics{
+		{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "lowQueue"}},
+			Metrics: &backendmetrics.Metrics{WaitingQueueSize: 0, KVCacheUsagePercent: 0.9},
+		},
+		{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "lowKVCache"}},
+			Metrics: &backendmetrics.Metrics{WaitingQueueSize: 4, KVCacheUsagePercent: 0.1},
+		},
+	}
+	schedConfig := &SchedulerConfig{
+		scorers:                []plugins.Scorer{&scorer.QueueScorer{}, &scorer.KVCacheScorer{}},
+		picker:                 &picker.MaxScorePicker{},
+		criticalScorerWeights:  map[string]float64{"queue": 10, "kv-cache-utilization": 1},
+		sheddableScorerWeights: map[string]float64{"queue": 1, "kv-cache-utilization": 10},
+	}
+
+	tests := []struct {
+		name          string
+		critical      bool
+		wantTargetPod string
+	}{
+		{
+			name:          "critical request weights the queue",
+			critical:      true,
+			wantTargetPod: "lowQueue",
+		},
+		{
+			name:          "sheddable request weights the KV cache",
+			critical:      false,
+			wantTargetPod: "lowKVCache",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, schedConfig)
+			got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model", Critical: test.critical})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.TargetPod.GetPod().NamespacedName.Name != test.wantTargetPod {
+				t.Errorf("Unexpected target pod, got %v, want %v", got.TargetPod.GetPod().NamespacedName, test.wantTargetPod)
+			}
+		})
+	}
+}
+
+func TestScheduleFallsBackWhenPickerSelectsNothing(t *testing.T) {
+	emptyScorer := &TestPlugin{NameRes: "empty scorer"}
+	schedConfig := &SchedulerConfig{
+		filters: []plugins.Filter{&TestPlugin{
+			NameRes:   "filter",
+			FilterRes: []k8stypes.NamespacedName{{Name: "pod1"}, {Name: "pod2"}},
+		}},
+		scorers: []plugins.Scorer{emptyScorer},
+		picker:  &nilPicker{},
+	}
+	input := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}},
+	}
+
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: input}, schedConfig)
+	got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got == nil || got.TargetPod == nil {
+		t.Fatalf("Expected a target pod, got %v", got)
+	}
+	if name := got.TargetPod.GetPod().NamespacedName.Name; name != "pod1" && name != "pod2" {
+		t.Errorf("Unexpected target pod %s, want one of the filtered pods", name)
+	}
+}
+
+func TestScheduleExhaustedStatusCode(t *testing.T) {
+	defer func(saturated, scalingUp int) {
+		config.Conf.SaturatedStatusCode, config.Conf.ScalingUpStatusCode = saturated, scalingUp
+	}(config.Conf.SaturatedStatusCode, config.Conf.ScalingUpStatusCode)
+	config.Conf.SaturatedStatusCode = 503
+	config.Conf.ScalingUpStatusCode = 429
+
+	tests := []struct {
+		name       string
+		scalingUp  bool
+		wantStatus int
+	}{
+		{
+			name:       "saturated pool",
+			wantStatus: 503,
+		},
+		{
+			name:       "pool scaling up",
+			scalingUp:  true,
+			wantStatus: 429,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The only pod is queuing, so the sheddable request is dropped.
+			ds := &fakeDataStore{
+				pods: []*backendmetrics.FakePodMetrics{{
+					Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}},
+					Metrics: &backendmetrics.Metrics{WaitingQueueSize: 10, KVCacheUsagePercent: 0.9},
+				}},
+				scalingUp: test.scalingUp,
+			}
+			scheduler := NewSchedulerWithConfig(ds, defaultConfig)
+			_, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model", Critical: false})
+			e, ok := err.(errutil.Error)
+			if !ok {
+				t.Fatalf("Expected an errutil.Error, got %v", err)
+			}
+			if e.Code != errutil.InferencePoolResourceExhausted {
+				t.Errorf("Unexpected error code, got %v, want %v", e.Code, errutil.InferencePoolResourceExhausted)
+			}
+			if e.HTTPStatus != test.wantStatus {
+				t.Errorf("Unexpected HTTP status, got %v, want %v", e.HTTPStatus, test.wantStatus)
+			}
+		})
+	}
+}
+
+func TestScheduleErrorCodes(t *testing.T) {
+	full := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 10, KVCacheUsagePercent: 0.9}},
+	}
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Scoring is skipped for a single candidate pod.
+	several := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		pods           []*backendmetrics.FakePodMetrics
+		filters        []plugins.Filter
+		scorers        []plugins.Scorer
+		scorerTimeouts map[string]time.Duration
+		critical       bool
+		wantCode       string
+	}{
+		{
+			name:     "no ready pod",
+			ctx:      context.Background(),
+			pods:     []*backendmetrics.FakePodMetrics{},
+			filters:  []plugins.Filter{defPlugin},
+			critical: true,
+			wantCode: errutil.NoReadyEndpoints,
+		},
+		{
+			name:     "sheddable request without capacity",
+			ctx:      context.Background(),
+			pods:     full,
+			filters:  []plugins.Filter{defPlugin},
+			wantCode: errutil.InferencePoolResourceExhausted,
+		},
+		{
+			name:     "critical request without candidate",
+			ctx:      context.Background(),
+			pods:     full,
+			filters:  []plugins.Filter{&TestPlugin{NameRes: "exclude all"}, defPlugin},
+			critical: true,
+			wantCode: errutil.NoCandidateAfterFilter,
+		},
+		{
+			name:     "deadline exceeded",
+			ctx:      expired,
+			pods:     full,
+			filters:  []plugins.Filter{defPlugin},
+			critical: true,
+			wantCode: errutil.SchedulingTimeout,
+		},
+		{
+			name:     "context canceled",
+			ctx:      canceled,
+			pods:     full,
+			filters:  []plugins.Filter{defPlugin},
+			critical: true,
+			wantCode: errutil.SchedulingCanceled,
+		},
+		{
+			name:     "scorer panic",
+			ctx:      context.Background(),
+			pods:     several,
+			filters:  []plugins.Filter{defPlugin},
+			scorers:  []plugins.Scorer{&panickingScorer{}},
+			critical: true,
+			wantCode: errutil.ScoringFailed,
+		},
+		{
+			name:           "scorer panic with a timeout",
+			ctx:            context.Background(),
+			pods:           several,
+			filters:        []plugins.Filter{defPlugin},
+			scorers:        []plugins.Scorer{&panickingScorer{}},
+			scorerTimeouts: map[string]time.Duration{"panicking scorer": time.Minute},
+			critical:       true,
+			wantCode:       errutil.ScoringFailed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: test.pods}, &SchedulerConfig{
+				filters:        test.filters,
+				scorers:        test.scorers,
+				scorerTimeouts: test.scorerTimeouts,
+				picker:         defPlugin,
+			})
+			_, err := scheduler.Schedule(test.ctx, &types.LLMRequest{Model: "test-model", Critical: test.critical})
+			e, ok := err.(errutil.Error)
+			if !ok {
+				t.Fatalf("Expected an errutil.Error, got %v", err)
+			}
+			if e.Code != test.wantCode {
+				t.Errorf("Unexpected error code, got %v, want %v", e.Code, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestScheduleTopK(t *testing.T) {
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.5}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.1}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.3}},
+	}
+	schedConfig := &SchedulerConfig{
+		scorers: []plugins.Scorer{&scorer.KVCacheScorer{}},
+		picker:  &picker.MaxScorePicker{},
+	}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, schedConfig)
+	req := &types.LLMRequest{Model: "test-model"}
+
+	names := func(pods []types.Pod) []string {
+		res := []string{}
+		for _, pod := range pods {
+			res = append(res, pod.GetPod().NamespacedName.Name)
+		}
+		return res
+	}
+	tests := []struct {
+		name string
+		k    int
+		want []string
+	}{
+		{
+			name: "k smaller than the number of candidates",
+			k:    2,
+			want: []string{"pod2", "pod3"},
+		},
+		{
+			name: "k larger than the number of candidates",
+			k:    5,
+			want: []string{"pod2", "pod3", "pod1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := scheduler.ScheduleTopK(context.Background(), req, test.k)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(test.want, names(got.Candidates)); diff != "" {
+				t.Errorf("Unexpected ranking (-want +got): %s", diff)
+			}
+		})
+	}
+
+	// The top candidate is the pod Schedule picks.
+	top, err := scheduler.ScheduleTopK(context.Background(), req, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res, err := scheduler.Schedule(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(top.Candidates) != 1 || top.TargetPod.GetPod().NamespacedName != res.TargetPod.GetPod().NamespacedName {
+		t.Errorf("Unexpected top pod %v, want %v", names(top.Candidates), res.TargetPod.GetPod().NamespacedName.Name)
+	}
+
+	if _, err := scheduler.ScheduleTopK(context.Background(), req, 0); err == nil {
+		t.Errorf("Expected an error for k=0")
+	}
+}
+
+func TestScheduleTopKTieBreaker(t *testing.T) {
+	// pod1 and pod2 are tied for the top score, and pod2 wins the tie with its lower address.
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}, Address: "10.0.0.3"}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}, Address: "10.0.0.2"}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}, Address: "10.0.0.1"}, Metrics: &backendmetrics.Metrics{}},
+	}
+	cfg := &SchedulerConfig{
+		scorers:              []plugins.Scorer{&podScoresScorer{name: "test", scores: map[string]float64{"pod1": 0.5, "pod2": 0.5, "pod3": 0.2}}},
+		picker:               &picker.MaxScorePicker{},
+		tieBreaker:           &scorer.LowestAddressScorer{},
+		poolConcurrencyLimit: 1,
+	}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, cfg)
+	req := &types.LLMRequest{Model: "test-model", ResolvedTargetModel: "test-model", Critical: true}
+
+	for i := 0; i < 10; i++ {
+		top, err := scheduler.ScheduleTopK(context.Background(), req, 1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		top.Release()
+		res, err := scheduler.Schedule(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		res.Release()
+		if got, want := top.TargetPod.GetPod().NamespacedName, res.TargetPod.GetPod().NamespacedName; got != want {
+			t.Errorf("Unexpected top pod, got %v, want %v", got, want)
+		}
+	}
+
+	top, err := scheduler.ScheduleTopK(context.Background(), req, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var got []string
+	for _, pod := range top.Candidates {
+		got = append(got, pod.GetPod().NamespacedName.Name)
+	}
+	if diff := cmp.Diff([]string{"pod2", "pod1", "pod3"}, got); diff != "" {
+		t.Errorf("Unexpected ranking (-want +got): %s", diff)
+	}
+
+	// The ranking holds a slot in the pool-wide limit of requests in flight until it is released.
+	if _, err := scheduler.ScheduleTopK(context.Background(), &types.LLMRequest{Model: "test-model", ResolvedTargetModel: "test-model"}, 1); err == nil {
+		t.Errorf("Expected the ranking of a sheddable request to be dropped while the limit is reached")
+	}
+	top.Release()
+}
+
+func TestScheduleScoreCombination(t *testing.T) {
+	// pod1 is preferred by the first scorer but vetoed by the second one.
+	scorers := []plugins.Scorer{
+		&podScoresScorer{name: "first", scores: map[string]float64{"pod1": 1, "pod2": 0.4}},
+		&podScoresScorer{name: "veto", scores: map[string]float64{"pod1": 0, "pod2": 0.5}},
+	}
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+
+	tests := []struct {
+		combination string
+		wantPod     string
+	}{
+		{combination: "", wantPod: "pod1"},
+		{combination: ScoreCombinationSum, wantPod: "pod1"},
+		{combination: ScoreCombinationProduct, wantPod: "pod2"},
+		{combination: ScoreCombinationMax, wantPod: "pod1"},
+		{combination: ScoreCombinationMin, wantPod: "pod2"},
+	}
+	for _, test := range tests {
+		t.Run(test.combination, func(t *testing.T) {
+			schedConfig := &SchedulerConfig{
+				scorers:          scorers,
+				picker:           &picker.MaxScorePicker{},
+				scoreCombination: test.combination,
+			}
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, schedConfig)
+			got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if name := got.TargetPod.GetPod().NamespacedName.Name; name != test.wantPod {
+				t.Errorf("Unexpected target pod, got %v, want %v", name, test.wantPod)
+			}
+		})
+	}
+}
+
+func TestScheduleScorerOverrides(t *testing.T) {
+	scorers := []plugins.Scorer{
+		&podScoresScorer{name: "prefer-pod1", scores: map[string]float64{"pod1": 1}},
+		&podScoresScorer{name: "prefer-pod2", scores: map[string]float64{"pod2": 0.5}},
+	}
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	schedConfig := &SchedulerConfig{
+		scorers: scorers,
+		picker:  &picker.MaxScorePicker{},
+	}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, schedConfig)
+	schedule := func(req *types.LLMRequest) string {
+		got, err := scheduler.Schedule(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return got.TargetPod.GetPod().NamespacedName.Name
+	}
+
+	if got := schedule(&types.LLMRequest{Model: "test-model"}); got != "pod1" {
+		t.Errorf("Unexpected target pod without overrides, got %v, want pod1", got)
+	}
+	overridden := &types.LLMRequest{Model: "test-model", ScorerOverrides: map[string]float64{"prefer-pod1": 0}}
+	if got := schedule(overridden); got != "pod2" {
+		t.Errorf("Unexpected target pod with overrides, got %v, want pod2", got)
+	}
+	// The override only applies to the request that carries it.
+	if got := schedule(&types.LLMRequest{Model: "test-model"}); got != "pod1" {
+		t.Errorf("Unexpected target pod after an overridden request, got %v, want pod1", got)
+	}
+}
+
+func TestScheduleWeightFunc(t *testing.T) {
+	scorers := []plugins.Scorer{
+		&podScoresScorer{name: "kv-cache", scores: map[string]float64{"pod1": 1}},
+		&podScoresScorer{name: "affinity", scores: map[string]float64{"pod2": 1}},
+	}
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, &SchedulerConfig{
+		scorers:                scorers,
+		picker:                 &picker.MaxScorePicker{},
+		sheddableScorerWeights: map[string]float64{"affinity": 0.5},
+	})
+	// Boost the KV cache scorer for long prompts and the affinity scorer for requests of a session.
+	scheduler.WithWeightFunc(func(req *types.LLMRequest, scorerName string) float64 {
+		weight := scheduler.StaticWeight(req, scorerName)
+		if (scorerName == "kv-cache" && req.PromptTokens > 1000) || (scorerName == "affinity" && req.ExperimentKey != "") {
+			weight *= 4
+		}
+		return weight
+	})
+	tests := []struct {
+		name    string
+		req     *types.LLMRequest
+		wantPod string
+	}{
+		{
+			name:    "static weights",
+			req:     &types.LLMRequest{Model: "test-model", PromptTokens: 10},
+			wantPod: "pod1",
+		},
+		{
+			name:    "affinity boosted for a session",
+			req:     &types.LLMRequest{Model: "test-model", PromptTokens: 10, ExperimentKey: "session"},
+			wantPod: "pod2",
+		},
+		{
+			name:    "KV cache boosted for a long prompt",
+			req:     &types.LLMRequest{Model: "test-model", PromptTokens: 2000, ExperimentKey: "session"},
+			wantPod: "pod1",
+		},
+		{
+			name:    "request overrides take precedence",
+			req:     &types.LLMRequest{Model: "test-model", PromptTokens: 2000, ScorerOverrides: map[string]float64{"kv-cache": 0}},
+			wantPod: "pod2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := scheduler.Schedule(context.Background(), test.req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.TargetPod.GetPod().NamespacedName.Name != test.wantPod {
+				t.Errorf("Unexpected target pod, got %v, want %v", got.TargetPod.GetPod().NamespacedName, test.wantPod)
+			}
+		})
+	}
+}
+
+func TestRunScorerPlugins(t *testing.T) {
+	pods := []types.Pod{
+		&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 1, KVCacheUsagePercent: 0.5}},
+		&types.PodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 3, KVCacheUsagePercent: 0.25}},
+	}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{}, &SchedulerConfig{
+		scorers:               []plugins.Scorer{&scorer.QueueScorer{}, &scorer.KVCacheScorer{}},
+		criticalScorerWeights: map[string]float64{"kv-cache-utilization": 2},
+	})
+	ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{Model: "test-model", Critical: true}, pods)
+	scheduler.runScorerPlugins(ctx, pods)
+
+	// queue score + 2 * kv-cache score
+	want := []float64{0.5 + 2*0.5, 0.25 + 2*0.75}
+	for i, pod := range pods {
+		if pod.Score() != want[i] {
+			t.Errorf("Unexpected score for %v, got %v, want %v", pod.GetPod().NamespacedName, pod.Score(), want[i])
+		}
+	}
+}
+
+func TestSchedulerShutdown(t *testing.T) {
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	gated := &gatedScorer{started: make(chan struct{}, len(pods)), release: make(chan struct{})}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, &SchedulerConfig{
+		scorers: []plugins.Scorer{gated},
+		picker:  &picker.MaxScorePicker{},
+	})
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	scheduler.stopBackground = stopBackground
+
+	type outcome struct {
+		res *types.Result
+		err error
+	}
+	inFlight := make(chan outcome, 1)
+	go func() {
+		res, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+		inFlight <- outcome{res, err}
+	}()
+	<-gated.started
+
+	// The request being scored holds up the shutdown past its deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := scheduler.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the shutdown to time out, got %v", err)
+	}
+	if backgroundCtx.Err() == nil {
+		t.Errorf("Expected the background goroutines to be stopped")
+	}
+
+	// New requests are rejected.
+	_, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+	if e, ok := err.(errutil.Error); !ok || e.Code != errutil.SchedulerShuttingDown || e.RetryAfterSeconds <= 0 {
+		t.Errorf("Expected a %s error with a retry delay, got %v", errutil.SchedulerShuttingDown, err)
+	}
+
+	// The request being scored completes, letting the shutdown complete.
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- scheduler.Shutdown(context.Background())
+	}()
+	close(gated.release)
+	got := <-inFlight
+	if got.err != nil {
+		t.Fatalf("Unexpected error for the request being scored: %v", got.err)
+	}
+	if got.res.TargetPod == nil {
+		t.Errorf("Expected a target pod for the request being scored")
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Unexpected shutdown error: %v", err)
+	}
+}
+
+func TestScheduleAbortsWhenContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pods := []*backendmetrics.FakePodMetrics{}
+	for i := 0; i < 10; i++ {
+		pods = append(pods, &backendmetrics.FakePodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: fmt.Sprintf("pod%d", i)}}})
+	}
+	// The client disconnects while the first pod is being scored.
+	slow := &slowScorer{delay: 50 * time.Millisecond, onScore: cancel}
+	postSchedule := &TestPlugin{NameRes: "post schedule"}
+	schedConfig := &SchedulerConfig{
+		scorers:             []plugins.Scorer{slow},
+		postSchedulePlugins: []plugins.PostSchedule{postSchedule},
+		picker:              &picker.MaxScorePicker{},
+	}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, schedConfig)
+
+	start := time.Now()
+	got, err := scheduler.Schedule(ctx, &types.LLMRequest{Model: "test-model"})
+	if e, ok := err.(errutil.Error); !ok || e.Code != errutil.SchedulingCanceled {
+		t.Fatalf("Expected a SchedulingCanceled error, got %v", err)
+	}
+	if got != nil {
+		t.Errorf("Expected no result, got %v", got)
+	}
+	if slow.calls != 1 {
+		t.Errorf("Expected scoring to stop after the first pod, got %d scorer calls", slow.calls)
+	}
+	if postSchedule.PostScheduleCallCount != 0 {
+		t.Errorf("Expected post-schedule plugins not to run, got %d calls", postSchedule.PostScheduleCallCount)
+	}
+	if elapsed := time.Since(start); elapsed > 5*slow.delay {
+		t.Errorf("Expected scheduling to abort promptly, took %v", elapsed)
+	}
+}
+
+func TestScheduleScorerTimeouts(t *testing.T) {
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	// The blocked scorer would route to pod1 if it returned, the fast scorer prefers pod2.
+	blocked := &blockedScorer{release: make(chan struct{}), canceled: make(chan struct{}, len(pods))}
+	fast := &podScoresScorer{name: "fast", scores: map[string]float64{"pod1": 0.2, "pod2": 1}}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, &SchedulerConfig{
+		scorers: []plugins.Scorer{blocked, fast},
+		picker:  &picker.MaxScorePicker{},
+		scorerTimeouts: map[string]time.Duration{
+			blocked.Name(): 10 * time.Millisecond,
+			fast.Name():    time.Minute,
+		},
+	})
+
+	start := time.Now()
+	got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.TargetPod.GetPod().NamespacedName.Name != "pod2" {
+		t.Errorf("Unexpected target pod, got %v, want pod2", got.TargetPod.GetPod().NamespacedName)
+	}
+	// The timed out scorer is left out, so the scores are the ones of the fast scorer.
+	if got.TargetPod.Score() != 1 {
+		t.Errorf("Unexpected target pod score, got %v, want 1", got.TargetPod.Score())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the blocked scorer to be cut off, scheduling took %v", elapsed)
+	}
+	// The blocked scorer is notified of the timeout while scoring the first pod.
+	select {
+	case <-blocked.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the context of the blocked scorer to be canceled")
+	}
+	// Once released, it doesn't go on scoring the other pods.
+	close(blocked.release)
+	select {
+	case <-blocked.canceled:
+		t.Error("Expected the timed out scorer to stop scoring the pods")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestScheduleScorerTimeoutsWithProductCombination(t *testing.T) {
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	hanging := &hangingScorer{}
+	fast := &podScoresScorer{name: "fast", scores: map[string]float64{"pod1": 0.2, "pod2": 0.8}}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, &SchedulerConfig{
+		scorers:          []plugins.Scorer{hanging, fast},
+		picker:           &picker.MaxScorePicker{},
+		scoreCombination: ScoreCombinationProduct,
+		scorerTimeouts:   map[string]time.Duration{hanging.Name(): 10 * time.Millisecond},
+	})
+
+	got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// A zero score from the timed out scorer would zero the product of every pod.
+	if got.TargetPod.GetPod().NamespacedName.Name != "pod2" || got.TargetPod.Score() != 0.8 {
+		t.Errorf("Unexpected target pod, got %v with score %v, want pod2 with score 0.8",
+			got.TargetPod.GetPod().NamespacedName, got.TargetPod.Score())
+	}
+}
+
+func TestScheduleSkipsScorerWithAbandonedCall(t *testing.T) {
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	stuck := &stuckScorer{release: make(chan struct{})}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, &SchedulerConfig{
+		scorers:        []plugins.Scorer{stuck},
+		picker:         &picker.MaxScorePicker{},
+		scorerTimeouts: map[string]time.Duration{stuck.Name(): 10 * time.Millisecond},
+	})
+	schedule := func() {
+		t.Helper()
+		if _, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// The first call times out and keeps running, so the next requests leave the scorer out.
+	schedule()
+	schedule()
+	schedule()
+	if calls, _ := stuck.stats(); calls != 1 {
+		t.Errorf("Expected the scorer not to be called while its abandoned call runs, got %d calls", calls)
+	}
+
+	// Once the abandoned call returns, the scorer is called again.
+	close(stuck.release)
+	deadline := time.Now().Add(time.Second)
+	for scheduler.abandonedScorerCalls[stuck.Name()].Load() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the abandoned call to complete")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	schedule()
+	calls, maxRunning := stuck.stats()
+	if calls != 3 {
+		t.Errorf("Expected the scorer to be called again for both pods, got %d calls", calls)
+	}
+	if maxRunning != 1 {
+		t.Errorf("Expected the calls of the scorer not to overlap, got %d concurrent calls", maxRunning)
+	}
+}
+
+func TestScheduleSpreadsConcurrentRequests(t *testing.T) {
+	// pod1 is slightly less loaded, so without in-flight reservations it would get all requests.
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.1}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.2}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.2}},
+	}
+	inFlight := scorer.NewInFlightScorer(time.Minute, 0)
+	schedConfig := &SchedulerConfig{
+		scorers:             []plugins.Scorer{&scorer.KVCacheScorer{}, inFlight},
+		postSchedulePlugins: []plugins.PostSchedule{inFlight},
+		picker:              &picker.MaxScorePicker{},
+	}
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, schedConfig)
+
+	var mu sync.Mutex
+	counts := map[string]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+				mu.Lock()
+				counts[got.TargetPod.GetPod().NamespacedName.Name]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, pod := range pods {
+		if counts[pod.Pod.NamespacedName.Name] == 0 {
+			t.Errorf("Expected requests to spread across all pods, got %v", counts)
+		}
+	}
+}
+
+func TestScheduleExcludesUnhealthyPods(t *testing.T) {
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	ds := &fakeDataStore{pods: pods, unhealthy: []k8stypes.NamespacedName{{Name: "pod1"}}}
+	cfg, err := newSchedulerConfig(context.Background(), ds, config.Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error creating scheduler config: %v", err)
+	}
+	scheduler := NewSchedulerWithConfig(ds, cfg)
+	for i := 0; i < 20; i++ {
+		got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model", Critical: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got.TargetPod.GetPod().NamespacedName.Name != "pod2" {
+			t.Errorf("Unexpected target pod, got %v, want pod2", got.TargetPod.GetPod().NamespacedName)
+		}
+	}
+}
+
+func TestSchedulePoolSaturation(t *testing.T) {
+	// pod3 momentarily looks free, but the pool as a whole is saturated.
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.95}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.95}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.1}},
+	}
+	tests := []struct {
+		name      string
+		threshold float64
+		critical  bool
+		wantErr   bool
+	}{
+		{
+			name:      "sheddable request is shed when the pool is saturated",
+			threshold: 0.6,
+			wantErr:   true,
+		},
+		{
+			name:      "critical request is admitted when the pool is saturated",
+			threshold: 0.6,
+			critical:  true,
+		},
+		{
+			name:      "sheddable request is admitted below the threshold",
+			threshold: 0.7,
+		},
+		{
+			name: "check disabled",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ds := &fakeDataStore{pods: pods}
+			cfg, err := newSchedulerConfig(context.Background(), ds, config.Config{PoolSaturationThreshold: test.threshold})
+			if err != nil {
+				t.Fatalf("Unexpected error creating scheduler config: %v", err)
+			}
+			scheduler := NewSchedulerWithConfig(ds, cfg)
+			got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model", Critical: test.critical})
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Expected the request to be shed, got %v", got.TargetPod)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !test.critical && got.TargetPod.GetPod().NamespacedName.Name != "pod3" {
+				t.Errorf("Unexpected target pod, got %v, want pod3", got.TargetPod.GetPod().NamespacedName)
+			}
+		})
+	}
+}
+
+func TestScheduleWouldAdmit(t *testing.T) {
+	full := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.9}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 10}},
+	}
+	withCapacity := append([]*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.1}},
+	}, full...)
+	tests := []struct {
+		name          string
+		pods          []*backendmetrics.FakePodMetrics
+		preprocessors []plugins.RequestPreprocessor
+		critical      bool
+		wantAdmit     bool
+	}{
+		{
+			name: "sheddable request without capacity",
+			pods: full,
+		},
+		{
+			name:      "critical request without capacity",
+			pods:      full,
+			critical:  true,
+			wantAdmit: true,
+		},
+		{
+			name:      "sheddable request with capacity",
+			pods:      withCapacity,
+			wantAdmit: true,
+		},
+		{
+			name:          "request made critical by a preprocessor",
+			pods:          full,
+			preprocessors: []plugins.RequestPreprocessor{&criticalityPreprocessor{}},
+			wantAdmit:     true,
+		},
+		{
+			name:          "request rejected by a preprocessor",
+			pods:          withCapacity,
+			preprocessors: []plugins.RequestPreprocessor{&rejectingPreprocessor{err: errors.New("rejected")}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			preSchedule := &TestPlugin{NameRes: "pre schedule"}
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: test.pods}, &SchedulerConfig{
+				requestPreprocessors: test.preprocessors,
+				preSchedulePlugins:   []plugins.PreSchedule{preSchedule},
+				filters:              []plugins.Filter{defPlugin},
+				picker:               defPlugin,
+			})
+
+			req := &types.LLMRequest{Model: "test-model", Critical: test.critical}
+			admit, reason := scheduler.WouldAdmit(context.Background(), req)
+			if admit != test.wantAdmit {
+				t.Errorf("Unexpected admission, got %v (%q), want %v", admit, reason, test.wantAdmit)
+			}
+			if !admit && reason == "" {
+				t.Error("Expected a reason for dropping the request")
+			}
+			if req.Critical != test.critical {
+				t.Error("Expected the evaluated request to be left untouched")
+			}
+			if preSchedule.PreScheduleCallCount != 0 {
+				t.Errorf("Expected the pre-schedule plugins not to run, got %d calls", preSchedule.PreScheduleCallCount)
+			}
+
+			// Schedule drops the request if and only if it wouldn't be admitted.
+			_, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model", Critical: test.critical})
+			if (err == nil) != test.wantAdmit {
+				t.Errorf("Schedule doesn't match the admission, got error %v, want admission %v", err, test.wantAdmit)
+			}
+		})
+	}
+}
+
+func TestSchedulePinsSingleReplicaModels(t *testing.T) {
+	pod := func(name string, queue int, kvCache float64, maxActiveModels int, adapters ...string) *backendmetrics.FakePodMetrics {
+		active := map[string]int{}
+		for _, adapter := range adapters {
+			active[adapter] = 1
+		}
+		return &backendmetrics.FakePodMetrics{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: name}},
+			Metrics: &backendmetrics.Metrics{WaitingQueueSize: queue, KVCacheUsagePercent: kvCache, ActiveModels: active, MaxActiveModels: maxActiveModels},
+		}
+	}
+	tests := []struct {
+		name      string
+		pods      []*backendmetrics.FakePodMetrics
+		unhealthy []k8stypes.NamespacedName
+		// shed runs the default filter, shedding sheddable requests, after the unhealthy pod filter,
+		// instead of a filter keeping all the pods.
+		shed     bool
+		critical bool
+		wantPod  string
+		wantCode string
+		// wantPinned is set when the request is scheduled among the pinned pod alone, without
+		// scoring nor picking.
+		wantPinned bool
+	}{
+		{
+			// pod1 is the only pod serving the adapter, as pod2 has no room to load it, so the
+			// request goes to pod1 even though it looks busier than pod2.
+			name:       "pinned to the only pod that can serve the model",
+			pods:       []*backendmetrics.FakePodMetrics{pod("pod1", 3, 0.7, 1, "pinned"), pod("pod2", 0, 0, 1, "other")},
+			critical:   true,
+			wantPod:    "pod1",
+			wantPinned: true,
+		},
+		{
+			// pod2 can load the adapter, so the request is scheduled as usual.
+			name:     "not pinned when another pod has room to load the model",
+			pods:     []*backendmetrics.FakePodMetrics{pod("pod1", 3, 0.7, 2, "pinned"), pod("pod2", 0, 0, 2, "other")},
+			critical: true,
+			wantPod:  "pod2",
+		},
+		{
+			name:      "unhealthy pinned pod",
+			pods:      []*backendmetrics.FakePodMetrics{pod("pod1", 0, 0, 1, "pinned"), pod("pod2", 0, 0, 1, "other")},
+			unhealthy: []k8stypes.NamespacedName{{Name: "pod1"}},
+			critical:  true,
+			wantCode:  errutil.NoCandidateAfterFilter,
+		},
+		{
+			// The pinned pod has no capacity for a sheddable request, which is shed although pod2
+			// is idle.
+			name:     "sheddable request shed by the pinned pod",
+			pods:     []*backendmetrics.FakePodMetrics{pod("pod1", 10, 0.9, 1, "pinned"), pod("pod2", 0, 0, 1, "other")},
+			shed:     true,
+			wantCode: errutil.InferencePoolResourceExhausted,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ds := &fakeDataStore{pods: test.pods, unhealthy: test.unhealthy}
+			plugin := &TestPlugin{
+				NameRes:   "test",
+				FilterRes: []k8stypes.NamespacedName{{Name: "pod1"}, {Name: "pod2"}},
+				PickRes:   k8stypes.NamespacedName{Name: "pod2"},
+			}
+			filters := []plugins.Filter{filter.UnhealthyPodFilter, plugin}
+			if test.shed {
+				filters[1] = defPlugin
+			}
+			scheduler := NewSchedulerWithConfig(ds, &SchedulerConfig{
+				scorers:                []plugins.Scorer{plugin},
+				filters:                filters,
+				postSchedulePlugins:    []plugins.PostSchedule{plugin},
+				picker:                 plugin,
+				pinSingleReplicaModels: true,
+			})
+			req := &types.LLMRequest{Model: "pinned", ResolvedTargetModel: "pinned", Critical: test.critical}
+			admitted, _ := scheduler.WouldAdmit(context.Background(), req)
+			got, err := scheduler.Schedule(context.Background(), req)
+			if admitted != (err == nil) {
+				t.Errorf("Schedule doesn't match the admission, got error %v, want admission %v", err, admitted)
+			}
+			if test.wantCode != "" {
+				if e, ok := err.(errutil.Error); !ok || e.Code != test.wantCode {
+					t.Fatalf("Expected a %s error, got %v", test.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.TargetPod.GetPod().NamespacedName.Name != test.wantPod {
+				t.Errorf("Unexpected target pod, got %v, want %v", got.TargetPod.GetPod().NamespacedName, test.wantPod)
+			}
+			if pinned := plugin.ScoreCallCount == 0 && plugin.PickCallCount == 0; pinned != test.wantPinned {
+				t.Errorf("Unexpected pinning, got %d score and %d pick calls, want pinned %v",
+					plugin.ScoreCallCount, plugin.PickCallCount, test.wantPinned)
+			}
+			if plugin.PostScheduleCallCount != 1 {
+				t.Errorf("Unexpected post-schedule call count, got %d, want 1", plugin.PostScheduleCallCount)
+			}
+		})
+	}
+}
+
+func TestScheduleModelPreferences(t *testing.T) {
+	// podA serves model-a but is queuing, podB serves model-b and is available.
+	ds := &fakeDataStore{
+		pods: []*backendmetrics.FakePodMetrics{
+			{
+				Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "podA"}},
+				Metrics: &backendmetrics.Metrics{WaitingQueueSize: 10, KVCacheUsagePercent: 0.9, ActiveModels: map[string]int{"model-a": 1}},
+			},
+			{
+				Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "podB"}},
+				Metrics: &backendmetrics.Metrics{ActiveModels: map[string]int{"model-b": 1}},
+			},
+		},
+	}
+	scheduler := NewSchedulerWithConfig(ds, defaultConfig)
+
+	tests := []struct {
+		name        string
+		critical    bool
+		preferences []string
+		wantPod     string
+		wantModel   string
+		wantCode    string
+	}{
+		{
+			name:        "critical request served by the top preference",
+			critical:    true,
+			preferences: []string{"model-a", "model-b"},
+			wantPod:     "podA",
+			wantModel:   "model-a",
+		},
+		{
+			name:        "sheddable request falls back to the next preference",
+			preferences: []string{"model-a", "model-b"},
+			wantPod:     "podB",
+			wantModel:   "model-b",
+		},
+		{
+			name:        "model without pods is skipped",
+			preferences: []string{"model-c", "model-b"},
+			wantPod:     "podB",
+			wantModel:   "model-b",
+		},
+		{
+			name:        "no preference available falls back to the resolved target model",
+			preferences: []string{"model-a"},
+			wantPod:     "podB",
+			wantModel:   "test-model",
+		},
+		{
+			name:        "no pod serves any preference falls back to the resolved target model",
+			preferences: []string{"model-c"},
+			wantPod:     "podB",
+			wantModel:   "test-model",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &types.LLMRequest{Model: "test-model", ResolvedTargetModel: "test-model", Critical: test.critical, ModelPreferences: test.preferences}
+			got, err := scheduler.Schedule(context.Background(), req)
+			if test.wantCode != "" {
+				if e, ok := err.(errutil.Error); !ok || e.Code != test.wantCode {
+					t.Fatalf("Expected an error with code %v, got %v", test.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.TargetPod.GetPod().NamespacedName.Name != test.wantPod {
+				t.Errorf("Unexpected target pod, got %v, want %v", got.TargetPod.GetPod().NamespacedName.Name, test.wantPod)
+			}
+			if req.ResolvedTargetModel != test.wantModel {
+				t.Errorf("Unexpected resolved target model, got %v, want %v", req.ResolvedTargetModel, test.wantModel)
+			}
+		})
+	}
+}
+
+func TestScheduleModelPreferencesUnloadedAdapter(t *testing.T) {
+	// The preferred adapter isn't loaded on any pod yet, but podB has room to load it.
+	ds := &fakeDataStore{
+		pods: []*backendmetrics.FakePodMetrics{
+			{
+				Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "podA"}},
+				Metrics: &backendmetrics.Metrics{ActiveModels: map[string]int{"other": 1}, MaxActiveModels: 1},
+			},
+			{
+				Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "podB"}},
+				Metrics: &backendmetrics.Metrics{ActiveModels: map[string]int{}, MaxActiveModels: 1},
+			},
+		},
+	}
+	scheduler := NewSchedulerWithConfig(ds, defaultConfig)
+	for i := 0; i < 10; i++ {
+		req := &types.LLMRequest{Model: "test-model", ResolvedTargetModel: "test-model", ModelPreferences: []string{"adapter", "other"}}
+		got, err := scheduler.Schedule(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got.TargetPod.GetPod().NamespacedName.Name != "podB" {
+			t.Errorf("Unexpected target pod, got %v, want podB", got.TargetPod.GetPod().NamespacedName.Name)
+		}
+		if req.ResolvedTargetModel != "adapter" {
+			t.Errorf("Unexpected resolved target model, got %v, want adapter", req.ResolvedTargetModel)
+		}
+	}
+
+	// A pod loading the adapter serves it too.
+	ds.pods[0].Metrics = &backendmetrics.Metrics{ActiveModels: map[string]int{"other": 1}, WaitingModels: map[string]int{"adapter": 1}, MaxActiveModels: 1}
+	ds.pods[1].Metrics = &backendmetrics.Metrics{ActiveModels: map[string]int{"full": 1}, MaxActiveModels: 1}
+	req := &types.LLMRequest{Model: "test-model", ResolvedTargetModel: "test-model", ModelPreferences: []string{"adapter"}}
+	got, err := scheduler.Schedule(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got.TargetPod.GetPod().NamespacedName.Name != "podA" {
+		t.Errorf("Unexpected target pod, got %v, want podA", got.TargetPod.GetPod().NamespacedName.Name)
+	}
+}
+
+func TestSchedulePoolConcurrencyLimit(t *testing.T) {
+	ds := &fakeDataStore{pods: []*backendmetrics.FakePodMetrics{{
+		Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}},
+		Metrics: &backendmetrics.Metrics{ActiveModels: map[string]int{}},
+	}}}
+	cfg := *defaultConfig
+	cfg.poolConcurrencyLimit = 2
+	cfg.poolQueueTimeout = 100 * time.Millisecond
+	scheduler := NewSchedulerWithConfig(ds, &cfg)
+	schedule := func(critical bool) (*types.Result, error) {
+		return scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model", Critical: critical})
+	}
+	wantExhausted := func(err error, msg string) {
+		t.Helper()
+		if e, ok := err.(errutil.Error); !ok || e.Code != errutil.InferencePoolResourceExhausted {
+			t.Errorf("%s: expected a resource exhausted error, got %v", msg, err)
+		}
+	}
+
+	first, err := schedule(true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := schedule(false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The pool is at its limit: sheddable requests are dropped right away, and critical requests
+	// are dropped once they waited for the queue timeout.
+	_, err = schedule(false)
+	wantExhausted(err, "sheddable request")
+	if admitted, _ := scheduler.WouldAdmit(context.Background(), &types.LLMRequest{Model: "test-model"}); admitted {
+		t.Errorf("Expected a sheddable request not to be admitted")
+	}
+	start := time.Now()
+	_, err = schedule(true)
+	wantExhausted(err, "critical request")
+	if waited := time.Since(start); waited < cfg.poolQueueTimeout {
+		t.Errorf("Expected the critical request to wait for %v, waited %v", cfg.poolQueueTimeout, waited)
+	}
+
+	// A critical request gets the slot of a request completing while it waits.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		first.Release()
+	}()
+	second, err := schedule(true)
+	if err != nil {
+		t.Fatalf("Expected the critical request to get the released slot, got %v", err)
+	}
+
+	// Releasing is idempotent, so the pool is still at its limit until second completes.
+	first.Release()
+	_, err = schedule(false)
+	wantExhausted(err, "sheddable request after a duplicate release")
+	second.Release()
+	if _, err := schedule(false); err != nil {
+		t.Errorf("Expected a sheddable request to be scheduled once a slot is free, got %v", err)
+	}
+}
+
+func TestSchedulePoolNotSynced(t *testing.T) {
+	newDataStore := func() *fakeDataStore {
+		ds := &fakeDataStore{pods: []*backendmetrics.FakePodMetrics{{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}},
+			Metrics: &backendmetrics.Metrics{ActiveModels: map[string]int{}},
+		}}}
+		ds.notSynced.Store(true)
+		return ds
+	}
+	req := func() *types.LLMRequest { return &types.LLMRequest{Model: "test-model", Critical: true} }
+
+	// Without a grace period, the request is rejected right away.
+	ds := newDataStore()
+	scheduler := NewSchedulerWithConfig(ds, defaultConfig)
+	_, err := scheduler.Schedule(context.Background(), req())
+	if e, ok := err.(errutil.Error); !ok || e.Code != errutil.PoolNotReady || e.RetryAfterSeconds <= 0 {
+		t.Errorf("Expected a pool not ready error with a retry delay, got %v", err)
+	}
+	if admitted, _ := scheduler.WouldAdmit(context.Background(), req()); admitted {
+		t.Errorf("Expected the request not to be admitted before the pool is synced")
+	}
+
+	// With a grace period, the request waits for the pool to sync.
+	ds = newDataStore()
+	cfg := *defaultConfig
+	cfg.poolSyncGrace = time.Minute
+	scheduler = NewSchedulerWithConfig(ds, &cfg)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		ds.notSynced.Store(false)
+	}()
+	got, err := scheduler.Schedule(context.Background(), req())
+	if err != nil {
+		t.Fatalf("Expected the request to be scheduled once the pool synced, got %v", err)
+	}
+	if got.TargetPod.GetPod().NamespacedName.Name != "pod1" {
+		t.Errorf("Unexpected target pod %v", got.TargetPod.GetPod().NamespacedName)
+	}
+
+	// The grace period is bounded by the deadline of the request.
+	ds = newDataStore()
+	scheduler = NewSchedulerWithConfig(ds, &cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = scheduler.Schedule(ctx, req())
+	if e, ok := err.(errutil.Error); !ok || e.Code != errutil.SchedulingTimeout {
+		t.Errorf("Expected a scheduling timeout while waiting for the sync, got %v", err)
+	}
+}
+
+func TestScheduleTieBreaker(t *testing.T) {
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}, Address: "10.0.0.3"}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}, Address: "10.0.0.2"}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}, Address: "10.0.0.1"}, Metrics: &backendmetrics.Metrics{}},
+	}
+	tests := []struct {
+		name    string
+		scores  map[string]float64
+		wantPod string
+	}{
+		{
+			// pod3 has the lowest address, but isn't among the tied pods.
+			name:    "tie between top-scored pods",
+			scores:  map[string]float64{"pod1": 0.5, "pod2": 0.5, "pod3": 0.2},
+			wantPod: "pod2",
+		},
+		{
+			name:    "clear winner",
+			scores:  map[string]float64{"pod1": 1, "pod2": 0.5, "pod3": 0.5},
+			wantPod: "pod1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, &SchedulerConfig{
+				scorers:    []plugins.Scorer{&podScoresScorer{name: "test", scores: test.scores}},
+				picker:     &picker.MaxScorePicker{},
+				tieBreaker: &scorer.LowestAddressScorer{},
+			})
+			for i := 0; i < 10; i++ {
+				got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if got.TargetPod.GetPod().NamespacedName.Name != test.wantPod {
+					t.Errorf("Unexpected target pod, got %v, want %v", got.TargetPod.GetPod().NamespacedName, test.wantPod)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleBackupPod(t *testing.T) {
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}, NodeName: "node-a"}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}, NodeName: "node-a"}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}, NodeName: "node-b"}, Metrics: &backendmetrics.Metrics{}},
+	}
+	scores := map[string]float64{"pod1": 1, "pod2": 0.5, "pod3": 0.2}
+	tests := []struct {
+		name          string
+		filter        []k8stypes.NamespacedName
+		wantBackup    bool
+		redundant     bool
+		wantPod       string
+		wantBackupPod string
+	}{
+		{
+			name:          "backup is the next best pod",
+			wantBackup:    true,
+			wantPod:       "pod1",
+			wantBackupPod: "pod2",
+		},
+		{
+			name:    "backup not requested",
+			wantPod: "pod1",
+		},
+		{
+			// pod2 is filtered out, so it must not be returned as the backup.
+			name:          "backup passes the filters",
+			filter:        []k8stypes.NamespacedName{{Name: "pod1"}, {Name: "pod3"}},
+			wantBackup:    true,
+			wantPod:       "pod1",
+			wantBackupPod: "pod3",
+		},
+		{
+			name:       "no other candidate",
+			filter:     []k8stypes.NamespacedName{{Name: "pod1"}},
+			wantBackup: true,
+			wantPod:    "pod1",
+		},
+		{
+			// pod2 scores better than pod3, but is on the node of the target pod.
+			name:          "standby on another node",
+			redundant:     true,
+			wantPod:       "pod1",
+			wantBackupPod: "pod3",
+		},
+		{
+			name:      "no candidate on another node",
+			filter:    []k8stypes.NamespacedName{{Name: "pod1"}, {Name: "pod2"}},
+			redundant: true,
+			wantPod:   "pod1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &SchedulerConfig{
+				scorers: []plugins.Scorer{&podScoresScorer{name: "test", scores: scores}},
+				picker:  &picker.MaxScorePicker{},
+			}
+			if test.filter != nil {
+				config.filters = []plugins.Filter{&TestPlugin{NameRes: "test", FilterRes: test.filter}}
+			}
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, config)
+			got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model", WantBackupPod: test.wantBackup, Redundant: test.redundant})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.TargetPod.GetPod().NamespacedName.Name != test.wantPod {
+				t.Errorf("Unexpected target pod, got %v, want %v", got.TargetPod.GetPod().NamespacedName, test.wantPod)
+			}
+			if test.wantBackupPod == "" {
+				if got.BackupPod != nil {
+					t.Errorf("Unexpected backup pod %v", got.BackupPod.GetPod().NamespacedName)
+				}
+				return
+			}
+			if got.BackupPod == nil {
+				t.Fatalf("Expected backup pod %v, got none", test.wantBackupPod)
+			}
+			if got.BackupPod.GetPod().NamespacedName.Name != test.wantBackupPod {
+				t.Errorf("Unexpected backup pod, got %v, want %v", got.BackupPod.GetPod().NamespacedName, test.wantBackupPod)
+			}
+		})
+	}
+}
+
+func TestScheduleMinCandidatePods(t *testing.T) {
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 5}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 10}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 1, KVCacheUsagePercent: 0.5}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod4"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 1, KVCacheUsagePercent: 0.2}},
+		// The least loaded pods can't serve requests.
+		{
+			Pod:     &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "terminating"}, DeletionTimestamp: &metav1.Time{Time: time.Now()}},
+			Metrics: &backendmetrics.Metrics{},
+		},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "unhealthy"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	unhealthy := []k8stypes.NamespacedName{{Name: "unhealthy"}}
+	hardFilters := []plugins.Filter{
+		filter.TerminatingPodFilter,
+		filter.UnhealthyPodFilter,
+	}
+	soft := func(names ...string) plugins.Filter {
+		f := &softFilter{TestPlugin{NameRes: "soft"}}
+		for _, name := range names {
+			f.FilterRes = append(f.FilterRes, k8stypes.NamespacedName{Name: name})
+		}
+		return f
+	}
+	allScores := map[string]float64{"pod1": 1, "pod2": 1, "pod3": 1, "pod4": 1}
+	tests := []struct {
+		name             string
+		minCandidatePods int
+		filters          []plugins.Filter
+		scores           map[string]float64
+		wantPod          string
+		wantScored       []string
+	}{
+		{
+			name:             "enough candidates",
+			minCandidatePods: 1,
+			filters:          append(hardFilters, soft("pod1")),
+			wantPod:          "pod1",
+			wantScored:       []string{"pod1"},
+		},
+		{
+			// The least loaded pods dropped by the soft filter are re-added, but pod1 still wins a tie.
+			name:             "relaxed filter prefers passing pods",
+			minCandidatePods: 3,
+			filters:          append(hardFilters, soft("pod1")),
+			scores:           allScores,
+			wantPod:          "pod1",
+			wantScored:       []string{"pod1", "pod3", "pod4"},
+		},
+		{
+			name:             "relaxed pod scoring much higher",
+			minCandidatePods: 2,
+			filters:          append(hardFilters, soft("pod1")),
+			scores:           map[string]float64{"pod1": 0.2, "pod4": 1},
+			wantPod:          "pod4",
+			wantScored:       []string{"pod1", "pod4"},
+		},
+		{
+			name:             "fewer pods than the minimum",
+			minCandidatePods: 10,
+			filters:          append(hardFilters, soft("pod1", "pod2")),
+			scores:           map[string]float64{"pod1": 1, "pod2": 0.9, "pod3": 1, "pod4": 1},
+			wantPod:          "pod1",
+			wantScored:       []string{"pod1", "pod2", "pod3", "pod4"},
+		},
+		{
+			// The unhealthy filter is the last to drop pods.
+			name:             "terminating and unhealthy pods are never re-added",
+			minCandidatePods: 6,
+			filters:          append(hardFilters, soft("pod1", "pod2", "pod3", "pod4")),
+			scores:           map[string]float64{"pod1": 1, "pod2": 0.5, "pod3": 0.5, "pod4": 0.5},
+			wantPod:          "pod1",
+			wantScored:       []string{"pod1", "pod2", "pod3", "pod4"},
+		},
+		{
+			// The soft filter dropped pods, but the hard filter after it has the last word.
+			name:             "hard filter after the soft filter",
+			minCandidatePods: 3,
+			filters:          []plugins.Filter{soft("pod1", "pod2"), &TestPlugin{NameRes: "hard", FilterRes: []k8stypes.NamespacedName{{Name: "pod1"}}}},
+			wantPod:          "pod1",
+			wantScored:       []string{"pod1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scorer := &recordingScorer{podScoresScorer: podScoresScorer{name: "test", scores: test.scores}}
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods, unhealthy: unhealthy}, &SchedulerConfig{
+				filters:          test.filters,
+				scorers:          []plugins.Scorer{scorer},
+				picker:           &picker.MaxScorePicker{},
+				minCandidatePods: test.minCandidatePods,
+			})
+			got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.TargetPod.GetPod().NamespacedName.Name != test.wantPod {
+				t.Errorf("Unexpected target pod, got %v, want %v", got.TargetPod.GetPod().NamespacedName, test.wantPod)
+			}
+			// A single candidate skips scoring.
+			if len(test.wantScored) == 1 {
+				test.wantScored = nil
+			}
+			sort.Strings(scorer.scored)
+			if diff := cmp.Diff(test.wantScored, scorer.scored); diff != "" {
+				t.Errorf("Unexpected scored pods (-want +got): %s", diff)
+			}
+		})
+	}
+
+	// Without scorers, all the pods score 0, and the pod passing the filters is still preferred.
+	scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods, unhealthy: unhealthy}, &SchedulerConfig{
+		filters:          append(hardFilters, soft("pod1")),
+		picker:           &picker.MaxScorePicker{},
+		minCandidatePods: 3,
+	})
+	for range 20 {
+		got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got.TargetPod.GetPod().NamespacedName.Name != "pod1" {
+			t.Fatalf("Unexpected target pod without scorers, got %v, want pod1", got.TargetPod.GetPod().NamespacedName)
+		}
+	}
+
+	// A request rejected by the filters isn't relaxed.
+	scheduler = NewSchedulerWithConfig(&fakeDataStore{pods: pods}, &SchedulerConfig{
+		filters:          []plugins.Filter{soft()},
+		picker:           &picker.MaxScorePicker{},
+		minCandidatePods: 3,
+	})
+	if _, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"}); err == nil {
+		t.Errorf("Expected an error when the filters exclude all the pods")
+	}
+}
+
+func TestDefaultPluginRelaxable(t *testing.T) {
+	pods := types.ToSchedulerPodMetrics([]backendmetrics.PodMetrics{
+		&backendmetrics.FakePodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "passing"}}, Metrics: &backendmetrics.Metrics{}},
+		&backendmetrics.FakePodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "queuing"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 200}},
+		&backendmetrics.FakePodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "idle"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.1}},
+	})
+	names := func(pods []types.Pod) []string {
+		res := []string{}
+		for _, pod := range pods {
+			res = append(res, pod.GetPod().NamespacedName.Name)
+		}
+		return res
+	}
+	tests := []struct {
+		name     string
+		critical bool
+		want     []string
+	}{
+		{
+			name:     "critical request",
+			critical: true,
+			want:     []string{"queuing", "idle"},
+		},
+		{
+			// The queuing pod has no capacity for a sheddable request.
+			name: "sheddable request",
+			want: []string{"idle"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := types.NewSchedulingContext(context.Background(), &types.LLMRequest{Model: "test-model", Critical: test.critical}, pods)
+			got := defPlugin.relaxable(ctx, pods, pods[:1])
+			if diff := cmp.Diff(test.want, names(got)); diff != "" {
+				t.Errorf("Unexpected relaxable pods (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestScheduleRequestPreprocessors(t *testing.T) {
+	// The pods have no capacity left, so only critical requests can be scheduled.
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 200, KVCacheUsagePercent: 0.9}},
+	}
+	tests := []struct {
+		name          string
+		preprocessors []plugins.RequestPreprocessor
+		wantErrCode   string
+		wantStatus    int
+		wantFiltered  bool
+	}{
+		{
+			name:        "no preprocessor",
+			wantErrCode: errutil.InferencePoolResourceExhausted,
+			// The request is filtered, but no pod has capacity for it.
+			wantFiltered: true,
+		},
+		{
+			name:          "preprocessor sets criticality",
+			preprocessors: []plugins.RequestPreprocessor{&criticalityPreprocessor{}},
+			wantFiltered:  true,
+		},
+		{
+			name:          "preprocessor rejects the request",
+			preprocessors: []plugins.RequestPreprocessor{&rejectingPreprocessor{err: errutil.Error{Code: errutil.BadRequest, Msg: "rejected", HTTPStatus: 400}}, &criticalityPreprocessor{}},
+			wantErrCode:   errutil.BadRequest,
+			wantStatus:    400,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tp := &TestPlugin{NameRes: "test", FilterRes: []k8stypes.NamespacedName{{Name: "pod1"}}}
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, &SchedulerConfig{
+				requestPreprocessors: test.preprocessors,
+				filters:              []plugins.Filter{tp, defPlugin},
+				picker:               defPlugin,
+			})
+			req := &types.LLMRequest{Model: "test-model"}
+			got, err := scheduler.Schedule(context.Background(), req)
+			if test.wantErrCode != "" {
+				var gotErr errutil.Error
+				if !errors.As(err, &gotErr) || gotErr.Code != test.wantErrCode {
+					t.Fatalf("Unexpected error, got %v, want code %v", err, test.wantErrCode)
+				}
+				if test.wantStatus != 0 && gotErr.HTTPStatus != test.wantStatus {
+					t.Errorf("Unexpected HTTP status, got %d, want %d", gotErr.HTTPStatus, test.wantStatus)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !req.Critical {
+					t.Error("Expected the preprocessor to mark the request as critical")
+				}
+				if got.TargetPod.GetPod().NamespacedName.Name != "pod1" {
+					t.Errorf("Unexpected target pod %v", got.TargetPod.GetPod().NamespacedName)
+				}
+			}
+			if gotFiltered := tp.FilterCallCount > 0; gotFiltered != test.wantFiltered {
+				t.Errorf("Unexpected filtering, got %v, want %v", gotFiltered, test.wantFiltered)
+			}
+		})
+	}
+}
+
+func TestScheduleInvalidValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		pods   []*backendmetrics.FakePodMetrics
+		scorer plugins.Scorer
+	}{
+		{
+			name: "NaN KV cache utilization",
+			pods: []*backendmetrics.FakePodMetrics{
+				{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: math.NaN()}},
+				{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.5}},
+				{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: math.Inf(-1)}},
+			},
+			scorer: &scorer.KVCacheScorer{},
+		},
+		{
+			name: "NaN and infinite scores",
+			pods: []*backendmetrics.FakePodMetrics{
+				{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+				{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+				{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{}},
+			},
+			scorer: &podScoresScorer{name: "test", scores: map[string]float64{"pod1": math.NaN(), "pod2": 0.5, "pod3": math.Inf(1)}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: test.pods}, &SchedulerConfig{
+				scorers: []plugins.Scorer{test.scorer},
+				picker:  &picker.MaxScorePicker{},
+			})
+			// The invalid values are treated as the worst case, so pod2 is always picked.
+			for i := 0; i < 10; i++ {
+				got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"})
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if got.TargetPod.GetPod().NamespacedName.Name != "pod2" {
+					t.Errorf("Unexpected target pod, got %v, want pod2", got.TargetPod.GetPod().NamespacedName)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleScorerDisagreement(t *testing.T) {
+	metrics.Register()
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	tests := []struct {
+		name         string
+		scores       map[string]float64
+		otherScores  map[string]float64
+		wantDisagree bool
+	}{
+		{
+			name:         "conflicting scorers with close combined scores",
+			scores:       map[string]float64{"pod1": 1, "pod2": 0},
+			otherScores:  map[string]float64{"pod1": 0.05, "pod2": 1},
+			wantDisagree: true,
+		},
+		{
+			name:        "conflicting scorers with a clear winner",
+			scores:      map[string]float64{"pod1": 1, "pod2": 0},
+			otherScores: map[string]float64{"pod1": 0, "pod2": 0.5},
+		},
+		{
+			name:        "agreeing scorers",
+			scores:      map[string]float64{"pod1": 1, "pod2": 0},
+			otherScores: map[string]float64{"pod1": 0.8, "pod2": 0.2},
+		},
+		{
+			name:        "scorer without a top choice",
+			scores:      map[string]float64{"pod1": 1, "pod2": 1},
+			otherScores: map[string]float64{"pod1": 0, "pod2": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			counter := metrics.SchedulerScorerDisagreements.WithLabelValues("first", "second")
+			before, err := metricstestutil.GetCounterMetricValue(counter)
+			if err != nil {
+				t.Fatalf("Failed to get the disagreements: %v", err)
+			}
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, &SchedulerConfig{
+				scorers: []plugins.Scorer{
+					&podScoresScorer{name: "first", scores: test.scores},
+					&podScoresScorer{name: "second", scores: test.otherScores},
+				},
+				picker: &picker.MaxScorePicker{},
+			})
+			if _, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model"}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			after, err := metricstestutil.GetCounterMetricValue(counter)
+			if err != nil {
+				t.Fatalf("Failed to get the disagreements: %v", err)
+			}
+			if got := after > before; got != test.wantDisagree {
+				t.Errorf("Unexpected disagreement, got %v, want %v", got, test.wantDisagree)
+			}
+		})
+	}
+}
+
+func TestLowLatencyFilterPodCounts(t *testing.T) {
+	metrics.Register()
+	active := map[string]int{"model": 1}
+	pods := types.ToSchedulerPodMetrics([]backendmetrics.PodMetrics{
+		&backendmetrics.FakePodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.1, ActiveModels: active}},
+		&backendmetrics.FakePodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.9, ActiveModels: active}},
+		&backendmetrics.FakePodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{WaitingQueueSize: 200, KVCacheUsagePercent: 0.1, ActiveModels: active}},
+		&backendmetrics.FakePodMetrics{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod4"}}, Metrics: &backendmetrics.Metrics{KVCacheUsagePercent: 0.2}},
+	})
+	// pod3 is queuing, pod4 doesn't have the adapter and pod2 has more KV cache in use than pod1.
+	want := map[string][2]float64{
+		"low queueing filter":    {4, 3},
+		"affinity LoRA":          {3, 2},
+		"least queuing":          {2, 2},
+		"least KV cache percent": {2, 1},
+	}
+	counts := func() map[string][2]float64 {
+		res := map[string][2]float64{}
+		for name := range want {
+			in, err := metricstestutil.GetCounterMetricValue(metrics.SchedulerFilterInputPods.WithLabelValues(name))
+			if err != nil {
+				t.Fatalf("Failed to get the input pods of filter %q: %v", name, err)
+			}
+			out, err := metricstestutil.GetCounterMetricValue(metrics.SchedulerFilterOutputPods.WithLabelValues(name))
+			if err != nil {
+				t.Fatalf("Failed to get the output pods of filter %q: %v", name, err)
+			}
+			res[name] = [2]float64{in, out}
+		}
+		return res
+	}
+
+	before := counts()
+	req := &types.LLMRequest{Model: "model", ResolvedTargetModel: "model", Critical: true}
+	got := defPlugin.lowLatencyFilter.Filter(types.NewSchedulingContext(context.Background(), req, pods), pods)
+	if len(got) != 1 || got[0].GetPod().NamespacedName.Name != "pod1" {
+		t.Fatalf("Unexpected filtered pods, got %v, want pod1", got)
+	}
+	after := counts()
+	for name, w := range want {
+		delta := [2]float64{after[name][0] - before[name][0], after[name][1] - before[name][1]}
+		if delta != w {
+			t.Errorf("Unexpected pod counts for filter %q, got %v in and %v out, want %v in and %v out", name, delta[0], delta[1], w[0], w[1])
+		}
+	}
+}
+
 type fakeDataStore struct {
-	pods []*backendmetrics.FakePodMetrics
+	pods      []*backendmetrics.FakePodMetrics
+	unhealthy []k8stypes.NamespacedName
+	scalingUp bool
+	// notSynced reports the pool as not synced until it is cleared.
+	notSynced atomic.Bool
+}
+
+func (fds *fakeDataStore) PoolHasSynced() bool {
+	return !fds.notSynced.Load()
+}
+
+func (fds *fakeDataStore) PoolIsScalingUp() bool {
+	return fds.scalingUp
+}
+
+func (fds *fakeDataStore) PodIsUnhealthy(namespacedName k8stypes.NamespacedName) bool {
+	for _, name := range fds.unhealthy {
+		if name == namespacedName {
+			return true
+		}
+	}
+	return false
 }
 
 func (fds *fakeDataStore) PodGetAll() []backendmetrics.PodMetrics {
@@ -463,3 +2341,195 @@ func findPods(ctx *types.SchedulingContext, names ...k8stypes.NamespacedName) []
 	}
 	return res
 }
+
+// nilPicker doesn't select any pod.
+// criticalityPreprocessor marks all requests as critical.
+type criticalityPreprocessor struct{}
+
+func (p *criticalityPreprocessor) Name() string { return "criticality" }
+
+func (p *criticalityPreprocessor) Preprocess(ctx context.Context, req *types.LLMRequest) error {
+	req.Critical = true
+	return nil
+}
+
+// rejectingPreprocessor rejects all requests with the given error.
+type rejectingPreprocessor struct {
+	err error
+}
+
+func (p *rejectingPreprocessor) Name() string { return "rejecting" }
+
+func (p *rejectingPreprocessor) Preprocess(ctx context.Context, req *types.LLMRequest) error {
+	return p.err
+}
+
+type nilPicker struct{}
+
+func (p *nilPicker) Name() string { return "nil picker" }
+
+func (p *nilPicker) Pick(ctx *types.SchedulingContext, pods []types.Pod) *types.Result {
+	return nil
+}
+
+// podScoresScorer scores the pods by name, and unlisted pods with 0.
+type podScoresScorer struct {
+	name   string
+	scores map[string]float64
+}
+
+func (s *podScoresScorer) Name() string { return s.name }
+
+func (s *podScoresScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	return s.scores[pod.GetPod().NamespacedName.Name]
+}
+
+// softFilter is a TestPlugin whose choices may be relaxed.
+type softFilter struct {
+	TestPlugin
+}
+
+func (f *softFilter) relaxable(ctx *types.SchedulingContext, input, output []types.Pod) []types.Pod {
+	return droppedPods(input, output)
+}
+
+// recordingScorer records the names of the pods it scores.
+type recordingScorer struct {
+	podScoresScorer
+	scored []string
+}
+
+func (s *recordingScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	s.scored = append(s.scored, pod.GetPod().NamespacedName.Name)
+	return s.podScoresScorer.Score(ctx, pod)
+}
+
+func BenchmarkSchedule(b *testing.B) {
+	for _, podCount := range []int{10, 100, 500} {
+		b.Run(fmt.Sprintf("%d pods", podCount), func(b *testing.B) {
+			pods := make([]*backendmetrics.FakePodMetrics, 0, podCount)
+			for i := 0; i < podCount; i++ {
+				pods = append(pods, &backendmetrics.FakePodMetrics{
+					Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: fmt.Sprintf("pod%d", i)}},
+					Metrics: &backendmetrics.Metrics{
+						WaitingQueueSize:    i % 7,
+						KVCacheUsagePercent: float64(i%10) / 10,
+						ActiveModels:        map[string]int{"foo": 1},
+						WaitingModels:       map[string]int{},
+					},
+				})
+			}
+			schedConfig := &SchedulerConfig{
+				filters: []plugins.Filter{defPlugin},
+				scorers: []plugins.Scorer{&scorer.QueueScorer{}, &scorer.KVCacheScorer{}},
+				picker:  &picker.MaxScorePicker{},
+			}
+			scheduler := NewSchedulerWithConfig(&fakeDataStore{pods: pods}, schedConfig)
+			req := &types.LLMRequest{Model: "foo", ResolvedTargetModel: "foo", Critical: true}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := scheduler.Schedule(context.Background(), req); err != nil {
+					b.Fatalf("Unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// blockedScorer scores every pod 10, but only returns once released. It reports the cancellation of
+// its context on canceled.
+type blockedScorer struct {
+	release  chan struct{}
+	canceled chan struct{}
+}
+
+func (s *blockedScorer) Name() string { return "blocked scorer" }
+
+func (s *blockedScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	<-ctx.Done()
+	s.canceled <- struct{}{}
+	<-s.release
+	return 10
+}
+
+// hangingScorer only returns once its context is done.
+type hangingScorer struct{}
+
+func (s *hangingScorer) Name() string { return "hanging scorer" }
+
+func (s *hangingScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	<-ctx.Done()
+	return 1
+}
+
+// stuckScorer ignores its context and blocks until release is closed, tracking how many of its
+// calls overlap.
+type stuckScorer struct {
+	release    chan struct{}
+	mu         sync.Mutex
+	calls      int
+	running    int
+	maxRunning int
+}
+
+func (s *stuckScorer) Name() string { return "stuck scorer" }
+
+func (s *stuckScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	s.mu.Lock()
+	s.calls++
+	s.running++
+	s.maxRunning = max(s.maxRunning, s.running)
+	s.mu.Unlock()
+	<-s.release
+	s.mu.Lock()
+	s.running--
+	s.mu.Unlock()
+	return 1
+}
+
+func (s *stuckScorer) stats() (calls, maxRunning int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls, s.maxRunning
+}
+
+// gatedScorer signals started when it scores a pod, and blocks until release is closed.
+type gatedScorer struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *gatedScorer) Name() string { return "gated scorer" }
+
+func (s *gatedScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	s.started <- struct{}{}
+	<-s.release
+	return 1
+}
+
+// panickingScorer panics when it scores a pod.
+type panickingScorer struct{}
+
+func (s *panickingScorer) Name() string { return "panicking scorer" }
+
+func (s *panickingScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	panic("scorer bug")
+}
+
+// slowScorer takes delay to score a pod, and calls onScore before returning.
+type slowScorer struct {
+	delay   time.Duration
+	onScore func()
+	calls   int
+}
+
+func (s *slowScorer) Name() string { return "slow scorer" }
+
+func (s *slowScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	s.calls++
+	time.Sleep(s.delay)
+	s.onScore()
+	return 0
+}
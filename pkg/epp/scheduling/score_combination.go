@@ -0,0 +1,59 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import "math"
+
+const (
+	// ScoreCombinationSum adds up the weighted scores.
+	ScoreCombinationSum = "sum"
+	// ScoreCombinationProduct multiplies the scores raised to the power of their weights, so a zero
+	// score from any scorer vetoes the pod.
+	ScoreCombinationProduct = "product"
+	// ScoreCombinationMax keeps the highest weighted score.
+	ScoreCombinationMax = "max"
+	// ScoreCombinationMin keeps the lowest weighted score.
+	ScoreCombinationMin = "min"
+)
+
+// scoreCombiner combines the weighted score of a scorer into the total score of a pod. first is
+// true for the first scorer, whose score initializes the total.
+type scoreCombiner func(total float64, first bool, score, weight float64) float64
+
+var scoreCombiners = map[string]scoreCombiner{
+	ScoreCombinationSum: func(total float64, first bool, score, weight float64) float64 {
+		return total + weight*score
+	},
+	ScoreCombinationProduct: func(total float64, first bool, score, weight float64) float64 {
+		if first {
+			return math.Pow(score, weight)
+		}
+		return total * math.Pow(score, weight)
+	},
+	ScoreCombinationMax: func(total float64, first bool, score, weight float64) float64 {
+		if first {
+			return weight * score
+		}
+		return math.Max(total, weight*score)
+	},
+	ScoreCombinationMin: func(total float64, first bool, score, weight float64) float64 {
+		if first {
+			return weight * score
+		}
+		return math.Min(total, weight*score)
+	},
+}
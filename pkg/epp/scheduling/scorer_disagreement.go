@@ -0,0 +1,83 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"math"
+
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+	logutil "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/util/logging"
+)
+
+// scorerDisagreementMargin is the relative gap between the combined scores of the top choices of
+// two scorers under which the scorers are considered to disagree: the combined score then barely
+// settles between pods that each scorer strongly prefers.
+const scorerDisagreementMargin = 0.1
+
+// scorerTop tracks the pod a scorer scores highest.
+type scorerTop struct {
+	pod   types.Pod
+	score float64
+	// tied is set when several pods share the top score, so the scorer has no top choice.
+	tied bool
+}
+
+func (t *scorerTop) observe(pod types.Pod, score float64) {
+	switch {
+	case t.pod == nil || score > t.score:
+		t.pod, t.score, t.tied = pod, score, false
+	case score == t.score:
+		t.tied = true
+	}
+}
+
+// scoreGap returns the gap between the combined scores of the pods relative to the highest one,
+// the disagreement measure of two scorers preferring each pod. The lower the gap, the higher the
+// disagreement.
+func scoreGap(a, b types.Pod) float64 {
+	highest := math.Max(math.Abs(a.Score()), math.Abs(b.Score()))
+	if highest == 0 {
+		return 0
+	}
+	return math.Abs(a.Score()-b.Score()) / highest
+}
+
+// recordScorerDisagreement records the pairs of scorers whose top choices differ while the combined
+// scores of these choices are within scorerDisagreementMargin, so that operators can tune the
+// scorer weights. It must be called once the pods have their combined scores.
+func (s *Scheduler) recordScorerDisagreement(ctx *types.SchedulingContext, tops []scorerTop) {
+	for i := range tops {
+		if tops[i].pod == nil || tops[i].tied {
+			continue
+		}
+		for j := i + 1; j < len(tops); j++ {
+			if tops[j].pod == nil || tops[j].tied || tops[j].pod.GetPod().NamespacedName == tops[i].pod.GetPod().NamespacedName {
+				continue
+			}
+			gap := scoreGap(tops[i].pod, tops[j].pod)
+			if gap > scorerDisagreementMargin {
+				continue
+			}
+			first, second := s.scorers[i].Name(), s.scorers[j].Name()
+			metrics.RecordScorerDisagreement(first, second)
+			ctx.Logger.V(logutil.VERBOSE).Info("Scorers disagree on the best pod", "scorer", first,
+				"pod", tops[i].pod.GetPod().NamespacedName, "otherScorer", second,
+				"otherPod", tops[j].pod.GetPod().NamespacedName, "scoreGap", gap)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+)
+
+// ScorerFactory creates a scorer with the given scheduler configuration. It's invoked once, when
+// the scheduler is created.
+type ScorerFactory func(ctx context.Context, datastore Datastore, conf config.Config) (plugins.Scorer, error)
+
+var (
+	scorerRegistryMu sync.RWMutex
+	// key: scorer name, value: ScorerFactory
+	scorerRegistry = map[string]ScorerFactory{}
+)
+
+func init() {
+	RegisterScorer("queue", func(context.Context, Datastore, config.Config) (plugins.Scorer, error) {
+		return &scorer.QueueScorer{}, nil
+	})
+	RegisterScorer("queue-split", func(_ context.Context, _ Datastore, conf config.Config) (plugins.Scorer, error) {
+		return &scorer.QueueSplitScorer{PrefillBoundPromptTokens: conf.PrefillBoundPromptTokens}, nil
+	})
+	RegisterScorer("kv-cache-utilization", func(context.Context, Datastore, config.Config) (plugins.Scorer, error) {
+		return &scorer.KVCacheScorer{}, nil
+	})
+	RegisterScorer("in-flight", func(_ context.Context, _ Datastore, conf config.Config) (plugins.Scorer, error) {
+		window := time.Duration(conf.InFlightWindowSeconds * float64(time.Second))
+		return scorer.NewInFlightScorer(window, conf.InFlightCostUnit), nil
+	})
+	RegisterScorer("uptime", func(_ context.Context, _ Datastore, conf config.Config) (plugins.Scorer, error) {
+		warmUp := time.Duration(conf.UptimeWarmUpSeconds * float64(time.Second))
+		return scorer.NewUptimeScorer(conf.UptimeCacheSensitiveTokens, warmUp, clock.RealClock{}), nil
+	})
+	RegisterScorer("cost", func(_ context.Context, _ Datastore, conf config.Config) (plugins.Scorer, error) {
+		return &scorer.CostScorer{ExpensiveRequestCost: conf.ExpensiveRequestCost}, nil
+	})
+	RegisterScorer("lowest-address", func(context.Context, Datastore, config.Config) (plugins.Scorer, error) {
+		return &scorer.LowestAddressScorer{}, nil
+	})
+	RegisterScorer("rtt", func(ctx context.Context, datastore Datastore, conf config.Config) (plugins.Scorer, error) {
+		interval := time.Duration(conf.RTTRefreshIntervalSeconds * float64(time.Second))
+		if interval <= 0 {
+			return nil, fmt.Errorf("invalid RTT refresh interval %v, must be positive", conf.RTTRefreshIntervalSeconds)
+		}
+		s := scorer.NewRTTScorer(scorer.NewTCPProbe(conf.RTTProbePort, rttProbeTimeout), func() []*backendmetrics.Pod {
+			pods := []*backendmetrics.Pod{}
+			for _, pm := range datastore.PodGetAll() {
+				pods = append(pods, pm.GetPod())
+			}
+			return pods
+		})
+		go s.Run(ctx, interval)
+		return s, nil
+	})
+	RegisterScorer("throughput", func(ctx context.Context, datastore Datastore, conf config.Config) (plugins.Scorer, error) {
+		window := time.Duration(conf.ThroughputWindowSeconds * float64(time.Second))
+		s := scorer.NewThroughputScorer(window, datastore.PodGetAll)
+		go s.Run(ctx, throughputRefreshInterval)
+		return s, nil
+	})
+}
+
+// rttProbeTimeout bounds the time to connect to a pod when measuring its RTT.
+const rttProbeTimeout = time.Second
+
+// throughputRefreshInterval is the interval at which the throughput of the pods is measured.
+const throughputRefreshInterval = time.Second
+
+// RegisterScorer makes a scorer available by the given name, so it can be enabled through the
+// scheduler configuration without modifying the scheduler. The scorers created by the factory must
+// be named after the registered name, as their weights and timeouts are configured by that name:
+// the scheduler fails to be created otherwise.
+// It panics if the factory is nil or if a scorer with the same name is already registered.
+func RegisterScorer(name string, factory ScorerFactory) {
+	scorerRegistryMu.Lock()
+	defer scorerRegistryMu.Unlock()
+	if factory == nil {
+		panic("scheduling: RegisterScorer factory is nil")
+	}
+	if _, dup := scorerRegistry[name]; dup {
+		panic("scheduling: RegisterScorer called twice for scorer " + name)
+	}
+	scorerRegistry[name] = factory
+}
+
+// RegisteredScorers returns the sorted names of the registered scorers.
+func RegisteredScorers() []string {
+	scorerRegistryMu.RLock()
+	defer scorerRegistryMu.RUnlock()
+	names := make([]string, 0, len(scorerRegistry))
+	for name := range scorerRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newScorers instantiates the scorers with the given names from the registry.
+func newScorers(ctx context.Context, datastore Datastore, conf config.Config, names []string) ([]plugins.Scorer, error) {
+	scorerRegistryMu.RLock()
+	defer scorerRegistryMu.RUnlock()
+	scorers := make([]plugins.Scorer, 0, len(names))
+	for _, name := range names {
+		factory, ok := scorerRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown scorer %q", name)
+		}
+		s, err := factory(ctx, datastore, conf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create scorer %q: %w", name, err)
+		}
+		if s.Name() != name {
+			return nil, fmt.Errorf("scorer registered as %q is named %q, the names must match", name, s.Name())
+		}
+		scorers = append(scorers, s)
+	}
+	return scorers, nil
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"testing"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/config"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/plugins/scorer"
+	"sigs.k8s.io/gateway-api-inference-extension/pkg/epp/scheduling/types"
+)
+
+func TestRegisteredScorerParticipatesInScheduling(t *testing.T) {
+	custom := &TestPlugin{NameRes: "test-prefer-pod2"}
+	RegisterScorer("test-prefer-pod2", func(context.Context, Datastore, config.Config) (plugins.Scorer, error) {
+		return &preferPodScorer{TestPlugin: custom, preferred: "pod2"}, nil
+	})
+
+	// All pods are equally loaded, so the default filters keep all of them and the custom scorer
+	// decides the target pod.
+	pods := []*backendmetrics.FakePodMetrics{
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod1"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod2"}}, Metrics: &backendmetrics.Metrics{}},
+		{Pod: &backendmetrics.Pod{NamespacedName: k8stypes.NamespacedName{Name: "pod3"}}, Metrics: &backendmetrics.Metrics{}},
+	}
+	ds := &fakeDataStore{pods: pods}
+	cfg, err := newSchedulerConfig(context.Background(), ds, config.Config{Scorers: []string{"test-prefer-pod2"}})
+	if err != nil {
+		t.Fatalf("Unexpected error creating scheduler config: %v", err)
+	}
+	scheduler := NewSchedulerWithConfig(ds, cfg)
+
+	for i := 0; i < 10; i++ {
+		got, err := scheduler.Schedule(context.Background(), &types.LLMRequest{Model: "test-model", Critical: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got.TargetPod.GetPod().NamespacedName.Name != "pod2" {
+			t.Errorf("Unexpected target pod, got %v, want pod2", got.TargetPod.GetPod().NamespacedName)
+		}
+	}
+	if custom.ScoreCallCount != 30 {
+		t.Errorf("Custom scorer Score() called %d times, expected 30", custom.ScoreCallCount)
+	}
+}
+
+func TestNewSchedulerConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		scorers     []string
+		wantScorers []string
+		err         bool
+	}{
+		{
+			name: "no scorers keeps the default config",
+		},
+		{
+			name:        "built-in scorers",
+			scorers:     []string{"queue", "kv-cache-utilization"},
+			wantScorers: []string{"queue", "kv-cache-utilization"},
+		},
+		{
+			name:    "unknown scorer",
+			scorers: []string{"queue", "unknown"},
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, err := newSchedulerConfig(context.Background(), &fakeDataStore{}, config.Config{Scorers: test.scorers})
+			if test.err != (err != nil) {
+				t.Fatalf("Unexpected error, got %v, want %v", err, test.err)
+			}
+			if err != nil {
+				return
+			}
+			if len(cfg.scorers) != len(test.wantScorers) {
+				t.Fatalf("Unexpected number of scorers, got %d, want %d", len(cfg.scorers), len(test.wantScorers))
+			}
+			for i, scorer := range cfg.scorers {
+				if scorer.Name() != test.wantScorers[i] {
+					t.Errorf("Unexpected scorer at index %d, got %s, want %s", i, scorer.Name(), test.wantScorers[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewSchedulerConfigScorerNameMismatch(t *testing.T) {
+	// The weights and timeouts of the scorers are looked up by their name, so a scorer named
+	// differently from its registry name would silently not get the ones configured for it.
+	RegisterScorer("test-misnamed", func(context.Context, Datastore, config.Config) (plugins.Scorer, error) {
+		return &TestPlugin{NameRes: "other"}, nil
+	})
+	_, err := newSchedulerConfig(context.Background(), &fakeDataStore{}, config.Config{
+		Scorers:               []string{"test-misnamed"},
+		CriticalScorerWeights: map[string]float64{"test-misnamed": 2},
+	})
+	if err == nil {
+		t.Errorf("Expected an error for a scorer named differently from its registry name")
+	}
+}
+
+func TestNewSchedulerConfigScorerSettings(t *testing.T) {
+	cfg, err := newSchedulerConfig(context.Background(), &fakeDataStore{}, config.Config{
+		Scorers:                  []string{"cost", "queue-split"},
+		ExpensiveRequestCost:     10,
+		PrefillBoundPromptTokens: 64,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s, ok := cfg.scorers[0].(*scorer.CostScorer); !ok || s.ExpensiveRequestCost != 10 {
+		t.Errorf("Expected a cost scorer with the configured expensive request cost, got %+v", cfg.scorers[0])
+	}
+	if s, ok := cfg.scorers[1].(*scorer.QueueSplitScorer); !ok || s.PrefillBoundPromptTokens != 64 {
+		t.Errorf("Expected a queue-split scorer with the configured threshold, got %+v", cfg.scorers[1])
+	}
+
+	// The factories validate the configuration they are given.
+	_, err = newSchedulerConfig(context.Background(), &fakeDataStore{}, config.Config{
+		Scorers:                   []string{"rtt"},
+		RTTRefreshIntervalSeconds: 0,
+	})
+	if err == nil {
+		t.Errorf("Expected an error for an RTT scorer without a refresh interval")
+	}
+}
+
+func TestNewSchedulerConfigLoRAAffinityTuning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cfg, err := newSchedulerConfig(ctx, &fakeDataStore{}, config.Config{
+		LoraAffinityThreshold:    0.99,
+		LoraAffinityMinThreshold: 0.9,
+		LoraAffinityMaxThreshold: 1,
+		LoraSwapCheapSeconds:     0.1,
+		LoraSwapExpensiveSeconds: 1,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The tuned scheduler gets its own default plugin, leaving the shared one on the static
+	// threshold.
+	for _, f := range cfg.filters {
+		if f == plugins.Filter(defPlugin) {
+			t.Errorf("Expected the tuned scheduler not to use the shared default plugin")
+		}
+	}
+	if _, ok := cfg.picker.(*defaultPlugin); !ok || cfg.picker == plugins.Picker(defPlugin) {
+		t.Errorf("Expected the picker to be the default plugin of the tuned scheduler, got %v", cfg.picker)
+	}
+}
+
+// preferPodScorer scores the preferred pod with 1 and all other pods with 0.
+type preferPodScorer struct {
+	*TestPlugin
+	preferred string
+}
+
+func (s *preferPodScorer) Score(ctx *types.SchedulingContext, pod types.Pod) float64 {
+	s.TestPlugin.Score(ctx, pod)
+	if pod.GetPod().NamespacedName.Name == s.preferred {
+		return 1
+	}
+	return 0
+}
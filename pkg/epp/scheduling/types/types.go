@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	backendmetrics "sigs.k8s.io/gateway-api-inference-extension/pkg/epp/backend/metrics"
 )
@@ -31,13 +32,48 @@ type LLMRequest struct {
 	// Target models is a map of target model name to weight.
 	TargetModels map[string]int
 	Prompt       string
+	// PromptTokens is the estimated number of tokens in the prompt.
+	PromptTokens int
 	// Resolved target model is the final target model after traffic split.
 	ResolvedTargetModel string
 	Critical            bool
+	// CostMultiplier is the per-model multiplier of the request cost. A value of 0 means 1.
+	CostMultiplier float64
+	// PreviousNodes are the nodes of the pods previous attempts of the request were sent to. When
+	// scheduling a retry, pods on other nodes are preferred.
+	PreviousNodes []string
+	// ScorerOverrides maps scorer names to the weight applied to their scores for this request
+	// only, taking precedence over the configured weights.
+	ScorerOverrides map[string]float64
+	// ExperimentKey identifies the client or session of the request, so all its requests land in
+	// the same experiment group. Requests without a key are never part of an experiment.
+	ExperimentKey string
+	// TenantID identifies the tenant of the request, to share the pool fairly between tenants.
+	TenantID string
+	// ModelPreferences lists the target models the client accepts, most preferred first. When set,
+	// the request is scheduled on a pod serving the first of these models that has an available
+	// pod, and ResolvedTargetModel is set to that model. If none has, ResolvedTargetModel is kept.
+	ModelPreferences []string
+	// WantBackupPod asks the scheduler to also return a backup pod, distinct from the target pod,
+	// e.g. to speculatively send the request to both and use the first response.
+	WantBackupPod bool
+	// Redundant asks the scheduler to also return a standby pod on another node than the target
+	// pod, e.g. to send a critical request to both and cancel the standby once the target responds.
+	Redundant bool
+}
+
+// Cost estimates the cost of serving the request, combining the number of prompt tokens and the
+// cost multiplier of the model.
+func (r *LLMRequest) Cost() float64 {
+	multiplier := r.CostMultiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	return float64(r.PromptTokens) * multiplier
 }
 
 func (r *LLMRequest) String() string {
-	return fmt.Sprintf("Model: %s, TargetModels: %v, ResolvedTargetModel: %s, Critical: %t, PromptLength: %v", r.Model, r.TargetModels, r.ResolvedTargetModel, r.Critical, len(r.Prompt))
+	return fmt.Sprintf("Model: %s, TargetModels: %v, ResolvedTargetModel: %s, Critical: %t, PromptLength: %v, PromptTokens: %v", r.Model, r.TargetModels, r.ResolvedTargetModel, r.Critical, len(r.Prompt), r.PromptTokens)
 }
 
 type Pod interface {
@@ -54,6 +90,15 @@ type SchedulingContext struct {
 	Logger       logr.Logger
 	Req          *LLMRequest
 	PodsSnapshot []Pod
+	// DryRun is set when the request is only evaluated, e.g. to check whether it would be admitted,
+	// and won't be sent. Plugins must not record the request nor its outcome during a dry run.
+	DryRun bool
+	// PodIsUnhealthy reports whether a pod is unhealthy in the datastore the request is scheduled
+	// on, which is a snapshot when the decision is captured. Nil reports all pods as healthy.
+	PodIsUnhealthy func(k8stypes.NamespacedName) bool
+	// RelaxedPods are the pods dropped by a filter but re-added to keep the minimum number of
+	// candidate pods. Their scores are lowered, so that the pods passing the filters are preferred.
+	RelaxedPods map[k8stypes.NamespacedName]bool
 }
 
 func (pm *PodMetrics) String() string {
@@ -106,4 +151,14 @@ func ToSchedulerPodMetrics(pods []backendmetrics.PodMetrics) []Pod {
 // Result captures the scheduler result.
 type Result struct {
 	TargetPod Pod
+	// BackupPod is a pod other than the target pod that passed the filters, set only if the
+	// request asks for one and such a pod is available. It can be used to hedge the request. For
+	// redundant requests, it is the standby pod, on another node than the target pod.
+	BackupPod Pod
+	// Candidates are the pods ranked by Scheduler.ScheduleTopK, by decreasing score. The target pod
+	// is the first one.
+	Candidates []Pod
+	// Release, if set, must be called once the request completes, to free the slot it holds in the
+	// pool-wide limit of requests in flight. It can be called more than once.
+	Release func()
 }
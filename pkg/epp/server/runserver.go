@@ -49,6 +49,13 @@ type ExtProcServerRunner struct {
 	CertPath                                 string
 	UseStreaming                             bool
 	RefreshPrometheusMetricsInterval         time.Duration
+	// ModelRolloutWindow is the window over which traffic shifts from the previous to the new target
+	// models of an InferenceModel that changed. 0 disables the gradual rollout.
+	ModelRolloutWindow time.Duration
+	// DecisionHistory records the recent scheduling decisions for debugging, if set.
+	DecisionHistory *scheduling.DecisionHistory
+	// DecisionCapture captures the scheduling decisions for replaying them in tests, if set.
+	DecisionCapture *scheduling.DecisionCapture
 
 	// This should only be used in tests. We won't need this once we don't inject metrics in the tests.
 	// TODO:(https://github.com/kubernetes-sigs/gateway-api-inference-extension/issues/432) Cleanup
@@ -79,6 +86,10 @@ func NewDefaultExtProcServerRunner() *ExtProcServerRunner {
 	}
 }
 
+// schedulerShutdownGrace bounds the time the requests being scheduled have to complete on
+// shutdown.
+const schedulerShutdownGrace = 10 * time.Second
+
 // SetupWithManager sets up the runner with the given manager.
 func (r *ExtProcServerRunner) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
 	// Create the controllers and register them with the manager
@@ -137,13 +148,44 @@ func (r *ExtProcServerRunner) AsRunnable(logger logr.Logger) manager.Runnable {
 		} else {
 			srv = grpc.NewServer()
 		}
-		extProcServer := handlers.NewStreamingServer(scheduling.NewScheduler(r.Datastore), r.DestinationEndpointHintMetadataNamespace, r.DestinationEndpointHintKey, r.Datastore)
+		scheduler, err := scheduling.NewScheduler(ctx, r.Datastore)
+		if err != nil {
+			logger.Error(err, "Failed to create scheduler")
+			return err
+		}
+		if r.DecisionHistory != nil {
+			scheduler.WithDecisionHistory(r.DecisionHistory)
+		}
+		if r.DecisionCapture != nil {
+			scheduler.WithDecisionCapture(r.DecisionCapture)
+		}
+		extProcServer := handlers.NewStreamingServer(scheduler, r.DestinationEndpointHintMetadataNamespace, r.DestinationEndpointHintKey, r.Datastore, r.ModelRolloutWindow)
 		extProcPb.RegisterExternalProcessorServer(
 			srv,
 			extProcServer,
 		)
 
+		// Drain the scheduler alongside the gRPC server, rejecting the requests of the streams still
+		// open while letting the ones being scheduled complete.
+		serverDone := make(chan struct{})
+		schedulerDone := make(chan struct{})
+		go func() {
+			defer close(schedulerDone)
+			select {
+			case <-ctx.Done():
+			case <-serverDone:
+			}
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), schedulerShutdownGrace)
+			defer cancel()
+			if err := scheduler.Shutdown(shutdownCtx); err != nil {
+				logger.Error(err, "Scheduler shut down with requests still being scheduled")
+			}
+		}()
+
 		// Forward to the gRPC runnable.
-		return runnable.GRPCServer("ext-proc", srv, r.GrpcPort).Start(ctx)
+		err = runnable.GRPCServer("ext-proc", srv, r.GrpcPort).Start(ctx)
+		close(serverDone)
+		<-schedulerDone
+		return err
 	}))
 }
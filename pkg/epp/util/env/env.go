@@ -49,3 +49,17 @@ func GetEnvInt(key string, defaultVal int, logger logr.Logger) int {
 		"key", key, "value", intVal)
 	return intVal
 }
+
+// GetEnvString gets a string from an environment variable with a default value
+func GetEnvString(key string, defaultVal string, logger logr.Logger) string {
+	val, exists := os.LookupEnv(key)
+	if !exists {
+		logger.V(logutil.VERBOSE).Info("Environment variable not set, using default value",
+			"key", key, "defaultValue", defaultVal)
+		return defaultVal
+	}
+
+	logger.V(logutil.VERBOSE).Info("Successfully loaded environment variable",
+		"key", key, "value", val)
+	return val
+}
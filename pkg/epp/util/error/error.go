@@ -8,6 +8,10 @@ import (
 type Error struct {
 	Code string
 	Msg  string
+	// HTTPStatus optionally overrides the HTTP status code returned to the client for the error.
+	HTTPStatus int
+	// RetryAfterSeconds, if positive, is returned to the client in the Retry-After header.
+	RetryAfterSeconds int
 }
 
 const (
@@ -17,6 +21,25 @@ const (
 	ModelServerError               = "ModelServerError"
 	BadConfiguration               = "BadConfiguration"
 	InferencePoolResourceExhausted = "InferencePoolResourceExhausted"
+	// NoReadyEndpoints is returned by the scheduler when the pool has no ready pod.
+	NoReadyEndpoints = "NoReadyEndpoints"
+	// NoCandidateAfterFilter is returned by the scheduler when the filters exclude all the pods for
+	// a request that isn't shed for lack of capacity, e.g. because all the pods are unhealthy.
+	NoCandidateAfterFilter = "NoCandidateAfterFilter"
+	// SchedulingTimeout is returned by the scheduler when the scheduling deadline is exceeded.
+	SchedulingTimeout = "SchedulingTimeout"
+	// SchedulingCanceled is returned by the scheduler when the request context is canceled while
+	// scheduling, e.g. because the client disconnected.
+	SchedulingCanceled = "SchedulingCanceled"
+	// ScoringFailed is returned by the scheduler when a scorer fails to score the pods, e.g. because
+	// it panicked.
+	ScoringFailed = "ScoringFailed"
+	// PoolNotReady is returned by the scheduler when the pods of the pool are not synced yet, e.g.
+	// right after the EPP started.
+	PoolNotReady = "PoolNotReady"
+	// SchedulerShuttingDown is returned by the scheduler for the requests received while the EPP is
+	// shutting down.
+	SchedulerShuttingDown = "SchedulerShuttingDown"
 )
 
 // Error returns a string version of the error.